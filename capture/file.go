@@ -0,0 +1,52 @@
+package capture
+
+import (
+	pb "SnakeGame/model/proto"
+	"bufio"
+	"errors"
+	"fmt"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"io"
+)
+
+// Writer запись захвата: CaptureRecord подряд, каждая с префиксом длины
+type Writer struct {
+	w *bufio.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+func (w *Writer) Write(record *pb.CaptureRecord) error {
+	if _, err := protodelim.MarshalTo(w.w, record); err != nil {
+		return fmt.Errorf("error writing capture record: %w", err)
+	}
+	return nil
+}
+
+// Flush дописывает буфер, вызывается перед закрытием файла
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// Reader чтение захвата, записанного Writer
+type Reader struct {
+	r *bufio.Reader
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read следующая запись, io.EOF в конце захвата
+func (r *Reader) Read() (*pb.CaptureRecord, error) {
+	var record pb.CaptureRecord
+	if err := protodelim.UnmarshalFrom(r.r, &record); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("error reading capture record: %w", err)
+	}
+	return &record, nil
+}
@@ -0,0 +1,191 @@
+package capture
+
+import (
+	"SnakeGame/logging"
+	pb "SnakeGame/model/proto"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// сообщение, ждущее подтверждения, с точки зрения одного узла. Счётчики msg_seq у сторон
+// независимы и пересекаются, поэтому ключ включает направление самого сообщения
+type flowKey struct {
+	node, peer string
+	direction  pb.CaptureRecord_Direction
+	seq        int64
+}
+
+type pending struct {
+	msgType string
+	at      time.Time
+	sends   int
+}
+
+// Timeline разбор захваченных пакетов в читаемую ленту: кто, что и с каким msg_seq отправил,
+// какое сообщение подтверждает каждый AckMsg, где были переотправки и пропуски state_order
+type Timeline struct {
+	w io.Writer
+
+	pending map[flowKey]*pending
+	// последний state_order в каждом потоке состояний: узел, направление, другая сторона
+	stateOrders map[string]int32
+
+	// итоги для Summary
+	messages    map[string]int
+	retransmits int
+	unmatched   int
+	gaps        int
+	undecodable int
+}
+
+func NewTimeline(w io.Writer) *Timeline {
+	return &Timeline{
+		w:           w,
+		pending:     make(map[flowKey]*pending),
+		stateOrders: make(map[string]int32),
+		messages:    make(map[string]int),
+	}
+}
+
+// Add разбирает запись и печатает строку ленты
+func (t *Timeline) Add(record *pb.CaptureRecord) {
+	at := time.Unix(0, record.GetTimeUnixNano())
+	prefix := fmt.Sprintf("%s %s", at.Format("15:04:05.000"), route(record))
+
+	var msg pb.GameMessage
+	if err := proto.Unmarshal(record.GetPayload(), &msg); err != nil {
+		t.undecodable++
+		fmt.Fprintf(t.w, "%s undecodable %d bytes: %v\n", prefix, len(record.GetPayload()), err)
+		return
+	}
+
+	msgType := logging.MessageType(&msg)
+	t.messages[msgType]++
+	line := fmt.Sprintf("%s id %d→%d %s seq=%d", prefix, msg.GetSenderId(), msg.GetReceiverId(), msgType, msg.GetMsgSeq())
+	if details := describe(&msg); details != "" {
+		line += " " + details
+	}
+	if notes := t.track(record, &msg, msgType, at); len(notes) > 0 {
+		line += " (" + strings.Join(notes, ", ") + ")"
+	}
+	fmt.Fprintln(t.w, line)
+}
+
+// откуда и куда шёл пакет
+func route(record *pb.CaptureRecord) string {
+	switch record.GetDirection() {
+	case pb.CaptureRecord_INBOUND:
+		return fmt.Sprintf("[%s] IN  <- %s", record.GetNode(), record.GetPeer())
+	case pb.CaptureRecord_OUTBOUND:
+		return fmt.Sprintf("[%s] OUT -> %s", record.GetNode(), record.GetPeer())
+	default:
+		return fmt.Sprintf("[group] %s", record.GetPeer())
+	}
+}
+
+// содержимое сообщения, важное при отладке
+func describe(msg *pb.GameMessage) string {
+	switch t := msg.Type.(type) {
+	case *pb.GameMessage_Steer:
+		return t.Steer.GetDirection().String()
+	case *pb.GameMessage_State:
+		state := t.State.GetState()
+		return fmt.Sprintf("order=%d snakes=%d foods=%d players=%d", state.GetStateOrder(),
+			len(state.GetSnakes()), len(state.GetFoods()), len(state.GetPlayers().GetPlayers()))
+	case *pb.GameMessage_Join:
+		return fmt.Sprintf("name=%q game=%q role=%v", t.Join.GetPlayerName(), t.Join.GetGameName(), t.Join.GetRequestedRole())
+	case *pb.GameMessage_Announcement:
+		var games []string
+		for _, game := range t.Announcement.GetGames() {
+			games = append(games, fmt.Sprintf("%q(%d players)", game.GetGameName(), len(game.GetPlayers().GetPlayers())))
+		}
+		return strings.Join(games, " ")
+	case *pb.GameMessage_RoleChange:
+		return fmt.Sprintf("sender=%v receiver=%v", t.RoleChange.GetSenderRole(), t.RoleChange.GetReceiverRole())
+	case *pb.GameMessage_Error:
+		return fmt.Sprintf("%q", t.Error.GetErrorMessage())
+	}
+	return ""
+}
+
+// сопоставление подтверждений, переотправки и пропуски состояний
+func (t *Timeline) track(record *pb.CaptureRecord, msg *pb.GameMessage, msgType string, at time.Time) []string {
+	// на multicast-группе подтверждений нет, а узел-наблюдатель неизвестен
+	if record.GetDirection() == pb.CaptureRecord_GROUP {
+		return nil
+	}
+
+	var notes []string
+	retransmit := false
+	key := flowKey{node: record.GetNode(), peer: record.GetPeer(), direction: record.GetDirection(), seq: msg.GetMsgSeq()}
+	switch msg.Type.(type) {
+	case *pb.GameMessage_Ack:
+		// подтверждение идёт в обратную сторону с тем же msg_seq
+		key.direction = reverse(key.direction)
+		if p, ok := t.pending[key]; ok {
+			notes = append(notes, fmt.Sprintf("acks %s after %v", p.msgType, at.Sub(p.at).Round(time.Millisecond)))
+			delete(t.pending, key)
+		} else {
+			t.unmatched++
+			notes = append(notes, "unmatched")
+		}
+	case *pb.GameMessage_Announcement, *pb.GameMessage_Discover:
+		// не подтверждаются
+	default:
+		if p, ok := t.pending[key]; ok && p.msgType == msgType {
+			p.sends++
+			t.retransmits++
+			retransmit = true
+			notes = append(notes, fmt.Sprintf("retransmit #%d", p.sends-1))
+		} else {
+			t.pending[key] = &pending{msgType: msgType, at: at, sends: 1}
+		}
+	}
+
+	// переотправка повторяет state_order, пропуском это не считается
+	if state := msg.GetState(); state != nil && !retransmit {
+		stream := fmt.Sprintf("%s %v %s", record.GetNode(), record.GetDirection(), record.GetPeer())
+		order := state.GetState().GetStateOrder()
+		if last, ok := t.stateOrders[stream]; ok {
+			switch {
+			case order > last+1:
+				t.gaps++
+				notes = append(notes, fmt.Sprintf("gap: %d states missing", order-last-1))
+			case order <= last:
+				notes = append(notes, fmt.Sprintf("stale, last order %d", last))
+			}
+		}
+		if order > t.stateOrders[stream] {
+			t.stateOrders[stream] = order
+		}
+	}
+	return notes
+}
+
+// reverse направление ответа на сообщение
+func reverse(direction pb.CaptureRecord_Direction) pb.CaptureRecord_Direction {
+	if direction == pb.CaptureRecord_INBOUND {
+		return pb.CaptureRecord_OUTBOUND
+	}
+	return pb.CaptureRecord_INBOUND
+}
+
+// Summary итоги захвата: сообщения по типам, переотправки, пропуски и неподтверждённые сообщения
+func (t *Timeline) Summary() {
+	types := make([]string, 0, len(t.messages))
+	for msgType := range t.messages {
+		types = append(types, msgType)
+	}
+	sort.Strings(types)
+
+	fmt.Fprintln(t.w, "--- summary")
+	for _, msgType := range types {
+		fmt.Fprintf(t.w, "%-12s %d\n", msgType, t.messages[msgType])
+	}
+	fmt.Fprintf(t.w, "retransmits %d, state gaps %d, unmatched acks %d, unacked %d, undecodable %d\n",
+		t.retransmits, t.gaps, t.unmatched, len(t.pending), t.undecodable)
+}
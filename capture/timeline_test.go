@@ -0,0 +1,130 @@
+package capture
+
+import (
+	pb "SnakeGame/model/proto"
+	"bytes"
+	"errors"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func record(t *testing.T, at time.Duration, direction pb.CaptureRecord_Direction, msg *pb.GameMessage) *pb.CaptureRecord {
+	t.Helper()
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &pb.CaptureRecord{
+		TimeUnixNano: proto.Int64(time.Unix(0, 0).Add(at).UnixNano()),
+		Direction:    direction.Enum(),
+		Node:         proto.String("10.0.0.1:5000"),
+		Peer:         proto.String("10.0.0.2:6000"),
+		Payload:      payload,
+	}
+}
+
+func state(seq int64, order int32) *pb.GameMessage {
+	return &pb.GameMessage{
+		MsgSeq:   proto.Int64(seq),
+		SenderId: proto.Int32(1),
+		Type: &pb.GameMessage_State{State: &pb.GameMessage_StateMsg{
+			State: &pb.GameState{StateOrder: proto.Int32(order), Players: &pb.GamePlayers{}},
+		}},
+	}
+}
+
+func ack(seq int64) *pb.GameMessage {
+	return &pb.GameMessage{
+		MsgSeq:   proto.Int64(seq),
+		SenderId: proto.Int32(2),
+		Type:     &pb.GameMessage_Ack{Ack: &pb.GameMessage_AckMsg{}},
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	records := []*pb.CaptureRecord{
+		record(t, 0, pb.CaptureRecord_OUTBOUND, state(10, 1)),
+		record(t, 20*time.Millisecond, pb.CaptureRecord_OUTBOUND, state(10, 1)),
+		record(t, 25*time.Millisecond, pb.CaptureRecord_INBOUND, ack(10)),
+		record(t, 30*time.Millisecond, pb.CaptureRecord_OUTBOUND, state(11, 4)),
+		record(t, 40*time.Millisecond, pb.CaptureRecord_INBOUND, ack(99)),
+	}
+
+	// захват переживает запись в файл и чтение обратно
+	var file bytes.Buffer
+	writer := NewWriter(&file)
+	for _, r := range records {
+		if err := writer.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	timeline := NewTimeline(&out)
+	reader := NewReader(&file)
+	for {
+		r, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		timeline.Add(r)
+	}
+	timeline.Summary()
+
+	lines := strings.Split(out.String(), "\n")
+	want := []string{
+		"state seq=10 order=1",
+		"retransmit #1",
+		"acks state after 25ms",
+		"gap: 2 states missing",
+		"unmatched",
+	}
+	for i, w := range want {
+		if !strings.Contains(lines[i], w) {
+			t.Errorf("line %d = %q, want it to contain %q", i, lines[i], w)
+		}
+	}
+	if !strings.Contains(out.String(), "retransmits 1, state gaps 1, unmatched acks 1, unacked 1") {
+		t.Errorf("unexpected summary:\n%s", out.String())
+	}
+}
+
+func TestTimelineOverlappingSeq(t *testing.T) {
+	steer := &pb.GameMessage{
+		MsgSeq:   proto.Int64(5),
+		SenderId: proto.Int32(2),
+		Type:     &pb.GameMessage_Steer{Steer: &pb.GameMessage_SteerMsg{Direction: pb.Direction_UP.Enum()}},
+	}
+	// у мастера и игрока свои счётчики msg_seq: оба отправляют сообщение с seq=5,
+	// и каждое подтверждение относится к своему сообщению
+	var out bytes.Buffer
+	timeline := NewTimeline(&out)
+	for _, r := range []*pb.CaptureRecord{
+		record(t, 0, pb.CaptureRecord_OUTBOUND, state(5, 1)),
+		record(t, 5*time.Millisecond, pb.CaptureRecord_INBOUND, steer),
+		record(t, 10*time.Millisecond, pb.CaptureRecord_INBOUND, ack(5)),
+		record(t, 15*time.Millisecond, pb.CaptureRecord_OUTBOUND, ack(5)),
+	} {
+		timeline.Add(r)
+	}
+	timeline.Summary()
+
+	lines := strings.Split(out.String(), "\n")
+	for i, w := range []string{"order=1", "UP", "acks state after 10ms", "acks steer after 10ms"} {
+		if !strings.Contains(lines[i], w) {
+			t.Errorf("line %d = %q, want it to contain %q", i, lines[i], w)
+		}
+	}
+	if !strings.Contains(out.String(), "retransmits 0, state gaps 0, unmatched acks 0, unacked 0") {
+		t.Errorf("unexpected summary:\n%s", out.String())
+	}
+}
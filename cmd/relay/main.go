@@ -0,0 +1,37 @@
+package main
+
+import (
+	"SnakeGame/logging"
+	"SnakeGame/relay"
+	"flag"
+	"log"
+	"net"
+	"os"
+)
+
+func main() {
+	listen := flag.String("listen", ":9193", "адрес, на котором ретранслятор принимает узлы")
+	flag.Parse()
+
+	logFile, err := logging.SetupFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if logFile != nil {
+		defer logFile.Close()
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", *listen)
+	if err != nil {
+		logging.Network.Error("Error resolving listen address", "listen", *listen, logging.Err(err))
+		os.Exit(1)
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		logging.Network.Error("Error creating relay socket", logging.Addr(addr), logging.Err(err))
+		os.Exit(1)
+	}
+
+	logging.Network.Info("Relay listening", logging.Addr(conn.LocalAddr()))
+	relay.NewServer(conn).Serve()
+}
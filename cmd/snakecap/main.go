@@ -0,0 +1,142 @@
+package main
+
+import (
+	"SnakeGame/capture"
+	"SnakeGame/connection"
+	pb "SnakeGame/model/proto"
+	"errors"
+	"flag"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+)
+
+func main() {
+	read := flag.String("r", "", "прочитать захват из файла вместо прослушивания сети")
+	write := flag.String("w", "", "записать захват в файл")
+	mirror := flag.String("mirror", "", "адрес, на который узлы с SNAKE_MIRROR присылают копии unicast-пакетов, например :9194")
+	group := flag.Bool("group", true, "слушать multicast-группу")
+	flag.Parse()
+
+	timeline := capture.NewTimeline(os.Stdout)
+	if *read != "" {
+		if err := replay(*read, timeline); err != nil {
+			log.Fatal(err)
+		}
+		timeline.Summary()
+		return
+	}
+
+	var writer *capture.Writer
+	if *write != "" {
+		file, err := os.Create(*write)
+		if err != nil {
+			log.Fatalf("Error creating capture file: %v", err)
+		}
+		defer file.Close()
+		writer = capture.NewWriter(file)
+		defer writer.Flush()
+	}
+
+	records := make(chan *pb.CaptureRecord, 256)
+	if *group {
+		conn, err := connection.Connection()
+		if err != nil {
+			log.Fatalf("Error joining multicast group: %v", err)
+		}
+		go listenGroup(conn, records)
+	}
+	if *mirror != "" {
+		addr, err := net.ResolveUDPAddr("udp", *mirror)
+		if err != nil {
+			log.Fatalf("Error resolving mirror address: %v", err)
+		}
+		conn, err := net.ListenUDP("udp", addr)
+		if err != nil {
+			log.Fatalf("Error creating mirror socket: %v", err)
+		}
+		log.Printf("Waiting for mirrored traffic on %v", conn.LocalAddr())
+		go listenMirror(conn, records)
+	}
+	if !*group && *mirror == "" {
+		log.Fatal("nothing to capture: enable -group or set -mirror")
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	for {
+		select {
+		case record := <-records:
+			timeline.Add(record)
+			if writer != nil {
+				if err := writer.Write(record); err != nil {
+					log.Fatal(err)
+				}
+			}
+		case <-interrupt:
+			timeline.Summary()
+			return
+		}
+	}
+}
+
+// replay разбор ранее записанного захвата
+func replay(path string, timeline *capture.Timeline) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := capture.NewReader(file)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		timeline.Add(record)
+	}
+}
+
+// listenGroup пакеты, отправленные на multicast-группу
+func listenGroup(conn connection.Conn, records chan<- *pb.CaptureRecord) {
+	buf := make([]byte, 65536)
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			log.Printf("Error receiving multicast packet: %v", err)
+			return
+		}
+		records <- &pb.CaptureRecord{
+			TimeUnixNano: proto.Int64(time.Now().UnixNano()),
+			Direction:    pb.CaptureRecord_GROUP.Enum(),
+			Peer:         proto.String(addr.String()),
+			Payload:      append([]byte(nil), buf[:n]...),
+		}
+	}
+}
+
+// listenMirror записи, присланные зеркалирующими узлами
+func listenMirror(conn connection.Conn, records chan<- *pb.CaptureRecord) {
+	buf := make([]byte, 65536)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			log.Printf("Error receiving mirrored packet: %v", err)
+			return
+		}
+		var record pb.CaptureRecord
+		if err := proto.Unmarshal(buf[:n], &record); err != nil {
+			log.Printf("Error unmarshalling mirrored packet: %v", err)
+			continue
+		}
+		records <- &record
+	}
+}
@@ -1,26 +1,116 @@
 package connection
 
 import (
-	"log"
+	"fmt"
 	"net"
+	"os"
 )
 
 const (
 	multicastAddress = "239.192.0.4:9192"
+	// группа IPv6 в пределах канала, ff02::114 выделен для экспериментов
+	multicastAddress6 = "[ff02::114]:9192"
+	multicastPort     = "9192"
+
+	// SNAKE_IPV6=1 - играть по IPv6
+	ipv6Env = "SNAKE_IPV6"
+	// сетевой интерфейс для multicast и link-local адресов, например "eth0"
+	ifaceEnv = "SNAKE_IFACE"
 )
 
-func Connection() *net.UDPConn {
+// Conn UDP-сокет, через который узел отправляет и получает сообщения.
+// *net.UDPConn удовлетворяет этому интерфейсу, обёртки (LossyConn) и сокеты MemNetwork тоже
+type Conn interface {
+	ReadFromUDP(b []byte) (int, *net.UDPAddr, error)
+	WriteToUDP(b []byte, addr *net.UDPAddr) (int, error)
+	LocalAddr() net.Addr
+	Close() error
+}
+
+// IPv6 работаем ли по IPv6 (SNAKE_IPV6=1)
+func IPv6() bool {
+	v := os.Getenv(ipv6Env)
+	return v != "" && v != "0"
+}
+
+// Interface сетевой интерфейс из SNAKE_IFACE, пустая строка - интерфейс выбирает система
+func Interface() string {
+	return os.Getenv(ifaceEnv)
+}
+
+// MulticastGroup адрес multicast-группы, на которой объявляются игры
+func MulticastGroup() string {
+	if !IPv6() {
+		return multicastAddress
+	}
+	if iface := Interface(); iface != "" {
+		// link-local группе нужна зона, иначе непонятно, в какой канал отправлять
+		return net.JoinHostPort("ff02::114%"+iface, multicastPort)
+	}
+	return multicastAddress6
+}
+
+func network() string {
+	if IPv6() {
+		return "udp6"
+	}
+	return "udp4"
+}
+
+// Connection создает сокет, подписанный на multicast-группу.
+// Если задан SNAKE_RELAY, multicast-группу заменяет ретранслятор
+func Connection() (Conn, error) {
+	if relay := relayFromEnv(); relay != "" {
+		relayConn, err := DialRelay(relay, true)
+		if err != nil {
+			return nil, err
+		}
+		return WrapFromEnv(relayConn), nil
+	}
+
 	// резолвим multicast-адрес
-	multicastUDPAddr, err := net.ResolveUDPAddr("udp4", multicastAddress)
+	multicastUDPAddr, err := net.ResolveUDPAddr(network(), MulticastGroup())
 	if err != nil {
-		log.Fatalf("Error resolving multicast address: %v", err)
+		return nil, fmt.Errorf("error resolving multicast address: %w", err)
+	}
+
+	var iface *net.Interface
+	if name := Interface(); name != "" {
+		iface, err = net.InterfaceByName(name)
+		if err != nil {
+			return nil, fmt.Errorf("error finding interface %q: %w", name, err)
+		}
 	}
 
 	// создаем сокет для multicast
-	multicastConn, err := net.ListenMulticastUDP("udp4", nil, multicastUDPAddr)
+	multicastConn, err := net.ListenMulticastUDP(network(), iface, multicastUDPAddr)
+	if err != nil {
+		return nil, fmt.Errorf("error creating multicast socket: %w", err)
+	}
+
+	return WrapFromEnv(multicastConn), nil
+}
+
+// Unicast создает сокет для остальных сообщений на свободном порту,
+// при заданном SNAKE_RELAY - сокет, сообщения которого идут через ретранслятор
+func Unicast() (Conn, error) {
+	if relay := relayFromEnv(); relay != "" {
+		relayConn, err := DialRelay(relay, false)
+		if err != nil {
+			return nil, err
+		}
+		return WrapFromEnv(relayConn), nil
+	}
+
+	localAddr, err := net.ResolveUDPAddr(network(), ":0")
+	if err != nil {
+		return nil, fmt.Errorf("error resolving local UDP address: %w", err)
+	}
+
+	unicastConn, err := net.ListenUDP(network(), localAddr)
 	if err != nil {
-		log.Fatalf("Error creating multicast socket: %v", err)
+		return nil, fmt.Errorf("error creating unicast socket: %w", err)
 	}
 
-	return multicastConn
+	return WrapFromEnv(unicastConn), nil
 }
@@ -0,0 +1,328 @@
+package connection
+
+import (
+	"SnakeGame/logging"
+	pb "SnakeGame/model/proto"
+	"errors"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// переменные окружения с профилями искажения трафика, см. ParseLossProfile
+const (
+	envLossIn  = "SNAKE_NETEM_IN"
+	envLossOut = "SNAKE_NETEM_OUT"
+)
+
+// дополнительная задержка для пакетов, отправляемых вне очереди
+const reorderGap = 10 * time.Millisecond
+
+// Direction направление трафика относительно узла
+type Direction int
+
+const (
+	Inbound Direction = iota
+	Outbound
+)
+
+// LossProfile параметры искажения трафика в одном направлении
+type LossProfile struct {
+	Loss      float64       // вероятность потери пакета
+	Delay     time.Duration // базовая задержка
+	Jitter    time.Duration // случайное отклонение задержки в пределах ±Jitter
+	Duplicate float64       // вероятность доставить пакет дважды
+	Reorder   float64       // вероятность задержать пакет так, чтобы его обогнали следующие
+}
+
+// ParseLossProfile разбирает профиль вида "loss=0.1 delay=50ms jitter=20ms dup=0.01 reorder=0.05"
+func ParseLossProfile(s string) (LossProfile, error) {
+	var p LossProfile
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' }) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return p, fmt.Errorf("invalid loss profile field %q", field)
+		}
+
+		var err error
+		switch key {
+		case "loss":
+			p.Loss, err = parseProbability(value)
+		case "dup":
+			p.Duplicate, err = parseProbability(value)
+		case "reorder":
+			p.Reorder, err = parseProbability(value)
+		case "delay":
+			p.Delay, err = time.ParseDuration(value)
+		case "jitter":
+			p.Jitter, err = time.ParseDuration(value)
+		default:
+			err = fmt.Errorf("unknown key %q", key)
+		}
+		if err != nil {
+			return p, fmt.Errorf("invalid loss profile field %q: %w", field, err)
+		}
+	}
+	return p, nil
+}
+
+func parseProbability(s string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if v < 0 || v > 1 {
+		return 0, fmt.Errorf("probability %v out of range [0, 1]", v)
+	}
+	return v, nil
+}
+
+// WrapFromEnv оборачивает сокет в LossyConn, если заданы SNAKE_NETEM_IN или SNAKE_NETEM_OUT
+func WrapFromEnv(conn Conn) Conn {
+	inSpec, outSpec := os.Getenv(envLossIn), os.Getenv(envLossOut)
+	if inSpec == "" && outSpec == "" {
+		return conn
+	}
+
+	in, err := ParseLossProfile(inSpec)
+	if err != nil {
+		logging.Network.Warn("Ignoring loss profile", "env", envLossIn, logging.Err(err))
+		return conn
+	}
+	out, err := ParseLossProfile(outSpec)
+	if err != nil {
+		logging.Network.Warn("Ignoring loss profile", "env", envLossOut, logging.Err(err))
+		return conn
+	}
+
+	logging.Network.Info("Simulating lossy network", logging.Addr(conn.LocalAddr()),
+		"in", fmt.Sprintf("%+v", in), "out", fmt.Sprintf("%+v", out))
+	return NewLossyConn(conn, in, out)
+}
+
+// правило сценарного режима: отбросить left следующих сообщений
+type dropRule struct {
+	dir     Direction
+	msgType string
+	addr    *net.UDPAddr
+	left    int
+}
+
+type packet struct {
+	data []byte
+	addr *net.UDPAddr
+}
+
+// LossyConn обёртка над сокетом, имитирующая потери, задержки, дублирование
+// и переупорядочивание пакетов отдельно для входящего и исходящего трафика
+type LossyConn struct {
+	inner Conn
+
+	mu    sync.Mutex
+	rnd   *rand.Rand
+	in    LossProfile
+	out   LossProfile
+	rules []*dropRule
+
+	inbox     chan packet
+	dead      chan struct{}
+	readErr   error
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewLossyConn(inner Conn, in, out LossProfile) *LossyConn {
+	c := &LossyConn{
+		inner: inner,
+		rnd:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		in:    in,
+		out:   out,
+		inbox: make(chan packet, 256),
+		dead:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go c.readLoop()
+	return c
+}
+
+// Seed задаёт зерно генератора, чтобы прогоны были воспроизводимыми
+func (c *LossyConn) Seed(seed int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rnd = rand.New(rand.NewSource(seed))
+}
+
+// SetProfile меняет профиль искажений для направления на лету
+func (c *LossyConn) SetProfile(dir Direction, p LossProfile) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if dir == Inbound {
+		c.in = p
+	} else {
+		c.out = p
+	}
+}
+
+// DropNext отбрасывает count следующих сообщений в направлении dir.
+// msgType - имя варианта GameMessage.Type ("ack", "state", "ping"...), пустая строка - любой тип.
+// addr - адрес получателя для исходящих и отправителя для входящих, nil - любой адрес.
+// Например, "потерять следующие 3 Ack заместителю": DropNext(Outbound, 3, "ack", deputyAddr)
+func (c *LossyConn) DropNext(dir Direction, count int, msgType string, addr *net.UDPAddr) {
+	if count <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rules = append(c.rules, &dropRule{dir: dir, msgType: msgType, addr: addr, left: count})
+}
+
+func (c *LossyConn) ReadFromUDP(b []byte) (int, *net.UDPAddr, error) {
+	select {
+	case p := <-c.inbox:
+		return copy(b, p.data), p.addr, nil
+	case <-c.dead:
+		return 0, nil, c.readErr
+	case <-c.done:
+		return 0, nil, net.ErrClosed
+	}
+}
+
+func (c *LossyConn) WriteToUDP(b []byte, addr *net.UDPAddr) (int, error) {
+	select {
+	case <-c.done:
+		return 0, net.ErrClosed
+	default:
+	}
+
+	data := append([]byte(nil), b...)
+	c.schedule(Outbound, data, addr, func() {
+		if _, err := c.inner.WriteToUDP(data, addr); err != nil && !errors.Is(err, net.ErrClosed) {
+			logging.Network.Error("Error sending delayed packet", logging.Addr(addr), logging.Err(err))
+		}
+	})
+	return len(b), nil
+}
+
+func (c *LossyConn) LocalAddr() net.Addr {
+	return c.inner.LocalAddr()
+}
+
+func (c *LossyConn) Close() error {
+	err := net.ErrClosed
+	c.closeOnce.Do(func() {
+		close(c.done)
+		err = c.inner.Close()
+	})
+	return err
+}
+
+// чтение из настоящего сокета и отложенная доставка в inbox
+func (c *LossyConn) readLoop() {
+	buf := make([]byte, 65536)
+	for {
+		n, addr, err := c.inner.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				c.readErr = err
+				close(c.dead)
+				return
+			}
+			logging.Network.Error("Error receiving packet", logging.Err(err))
+			continue
+		}
+
+		data := append([]byte(nil), buf[:n]...)
+		c.schedule(Inbound, data, addr, func() {
+			select {
+			case c.inbox <- packet{data: data, addr: addr}:
+			case <-c.done:
+			}
+		})
+	}
+}
+
+// schedule решает судьбу пакета: отбросить, задержать, продублировать
+func (c *LossyConn) schedule(dir Direction, data []byte, addr *net.UDPAddr, deliver func()) {
+	c.mu.Lock()
+	if c.matchRule(dir, data, addr) {
+		c.mu.Unlock()
+		return
+	}
+
+	p := c.out
+	if dir == Inbound {
+		p = c.in
+	}
+	if c.rnd.Float64() < p.Loss {
+		c.mu.Unlock()
+		return
+	}
+
+	delays := []time.Duration{c.delay(p)}
+	if c.rnd.Float64() < p.Duplicate {
+		delays = append(delays, c.delay(p))
+	}
+	c.mu.Unlock()
+
+	for _, d := range delays {
+		if d <= 0 {
+			deliver()
+			continue
+		}
+		time.AfterFunc(d, func() {
+			select {
+			case <-c.done:
+			default:
+				deliver()
+			}
+		})
+	}
+}
+
+func (c *LossyConn) delay(p LossProfile) time.Duration {
+	d := p.Delay
+	if p.Jitter > 0 {
+		d += time.Duration(c.rnd.Int63n(int64(2*p.Jitter)+1)) - p.Jitter
+	}
+	if c.rnd.Float64() < p.Reorder {
+		d += p.Delay + p.Jitter + reorderGap
+	}
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
+
+// matchRule проверяет сценарные правила и уменьшает счётчик сработавшего
+func (c *LossyConn) matchRule(dir Direction, data []byte, addr *net.UDPAddr) bool {
+	if len(c.rules) == 0 {
+		return false
+	}
+
+	msgType := ""
+	var msg pb.GameMessage
+	if err := proto.Unmarshal(data, &msg); err == nil {
+		msgType = logging.MessageType(&msg)
+	}
+
+	for i, r := range c.rules {
+		if r.dir != dir || (r.msgType != "" && r.msgType != msgType) {
+			continue
+		}
+		if r.addr != nil && (addr == nil || !r.addr.IP.Equal(addr.IP) || r.addr.Port != addr.Port) {
+			continue
+		}
+		r.left--
+		if r.left <= 0 {
+			c.rules = append(c.rules[:i], c.rules[i+1:]...)
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,261 @@
+package connection
+
+import (
+	pb "SnakeGame/model/proto"
+	"google.golang.org/protobuf/proto"
+	"net"
+	"testing"
+	"time"
+)
+
+// message сообщение с номером seq типа ack, state или ping
+func message(t *testing.T, seq int64, msgType string) []byte {
+	t.Helper()
+	msg := &pb.GameMessage{MsgSeq: proto.Int64(seq)}
+	switch msgType {
+	case "ack":
+		msg.Type = &pb.GameMessage_Ack{Ack: &pb.GameMessage_AckMsg{}}
+	case "state":
+		msg.Type = &pb.GameMessage_State{State: &pb.GameMessage_StateMsg{State: &pb.GameState{StateOrder: proto.Int32(1), Players: &pb.GamePlayers{}}}}
+	default:
+		msg.Type = &pb.GameMessage_Ping{Ping: &pb.GameMessage_PingMsg{}}
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// listen сокет в сети в памяти, закрывается в конце теста
+func listen(t *testing.T, network *MemNetwork) Conn {
+	t.Helper()
+	conn, err := network.ListenUDP(network.NewHost(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func addrOf(conn Conn) *net.UDPAddr {
+	return conn.LocalAddr().(*net.UDPAddr)
+}
+
+// receive номера сообщений, пришедших на conn, пока не выполнится done
+func receive(t *testing.T, conn Conn, done func(got []int64) bool) []int64 {
+	t.Helper()
+	result := make(chan []int64, 1)
+	go func() {
+		var got []int64
+		buf := make([]byte, 65536)
+		for !done(got) {
+			n, _, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			var msg pb.GameMessage
+			if err := proto.Unmarshal(buf[:n], &msg); err == nil {
+				got = append(got, msg.GetMsgSeq())
+			}
+		}
+		result <- got
+	}()
+
+	select {
+	case got := <-result:
+		return got
+	case <-time.After(time.Second):
+		t.Fatal("messages not received")
+		return nil
+	}
+}
+
+// until получено сообщение с номером last
+func until(last int64) func([]int64) bool {
+	return func(got []int64) bool {
+		return len(got) > 0 && got[len(got)-1] == last
+	}
+}
+
+// send отправляет сообщения 1..count, потом выключает искажения и отправляет count+1,
+// который приходит последним: все пакеты с нулевой задержкой доставляются сразу
+func send(t *testing.T, lossy *LossyConn, to *net.UDPAddr, count int) {
+	t.Helper()
+	for seq := int64(1); seq <= int64(count); seq++ {
+		if _, err := lossy.WriteToUDP(message(t, seq, "ack"), to); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lossy.SetProfile(Outbound, LossProfile{})
+	if _, err := lossy.WriteToUDP(message(t, int64(count)+1, "ack"), to); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// transmit count сообщений через LossyConn с исходящим профилем out и зерном seed
+func transmit(t *testing.T, out LossProfile, seed int64, count int) []int64 {
+	t.Helper()
+	network := NewMemNetwork()
+	lossy := NewLossyConn(listen(t, network), LossProfile{}, out)
+	lossy.Seed(seed)
+	receiver := listen(t, network)
+	send(t, lossy, addrOf(receiver), count)
+	return receive(t, receiver, until(int64(count)+1))
+}
+
+func TestLossyConnLoss(t *testing.T) {
+	got := transmit(t, LossProfile{Loss: 0.3}, 1, 500)
+	delivered := len(got) - 1
+	if delivered < 300 || delivered > 400 {
+		t.Fatalf("%d of 500 packets delivered with loss 0.3", delivered)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i] <= got[i-1] {
+			t.Fatalf("packets reordered without reorder: %v", got)
+		}
+	}
+
+	// то же зерно - те же потери
+	again := transmit(t, LossProfile{Loss: 0.3}, 1, 500)
+	if len(again) != len(got) {
+		t.Fatalf("seed 1 delivered %d and then %d packets", len(got), len(again))
+	}
+	for i := range got {
+		if got[i] != again[i] {
+			t.Fatalf("seed 1 delivered different packets: %v and %v", got, again)
+		}
+	}
+}
+
+func TestLossyConnDuplicate(t *testing.T) {
+	got := transmit(t, LossProfile{Duplicate: 0.3}, 2, 500)
+	copies := make(map[int64]int)
+	for _, seq := range got {
+		copies[seq]++
+	}
+	duplicated := 0
+	for seq := int64(1); seq <= 500; seq++ {
+		switch copies[seq] {
+		case 1:
+		case 2:
+			duplicated++
+		default:
+			t.Fatalf("packet %d delivered %d times", seq, copies[seq])
+		}
+	}
+	if duplicated < 100 || duplicated > 200 {
+		t.Fatalf("%d of 500 packets duplicated with dup 0.3", duplicated)
+	}
+}
+
+func TestLossyConnReorder(t *testing.T) {
+	network := NewMemNetwork()
+	lossy := NewLossyConn(listen(t, network), LossProfile{}, LossProfile{Reorder: 0.3})
+	lossy.Seed(3)
+	receiver := listen(t, network)
+
+	// без задержки задержанные пакеты приходят через reorderGap, после всех остальных
+	for seq := int64(1); seq <= 100; seq++ {
+		if _, err := lossy.WriteToUDP(message(t, seq, "ack"), addrOf(receiver)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := receive(t, receiver, func(got []int64) bool { return len(got) == 100 })
+	seen := make(map[int64]bool)
+	overtaken := 0
+	for i, seq := range got {
+		if seen[seq] {
+			t.Fatalf("packet %d delivered twice", seq)
+		}
+		seen[seq] = true
+		if i > 0 && seq < got[i-1] {
+			overtaken++
+		}
+	}
+	if overtaken == 0 || overtaken > 50 {
+		t.Fatalf("%d packets overtaken with reorder 0.3: %v", overtaken, got)
+	}
+}
+
+func TestLossyConnDropNext(t *testing.T) {
+	network := NewMemNetwork()
+	lossy := NewLossyConn(listen(t, network), LossProfile{}, LossProfile{})
+	deputy, other := listen(t, network), listen(t, network)
+
+	// потерять следующие 2 ack заместителю: ack другому узлу и state заместителю проходят
+	lossy.DropNext(Outbound, 2, "ack", addrOf(deputy))
+	for _, m := range []struct {
+		seq     int64
+		msgType string
+		to      Conn
+	}{
+		{1, "ack", deputy},
+		{2, "ack", other},
+		{3, "state", deputy},
+		{4, "ack", deputy},
+		{5, "ack", deputy},
+		{6, "ack", other},
+	} {
+		if _, err := lossy.WriteToUDP(message(t, m.seq, m.msgType), addrOf(m.to)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := receive(t, deputy, until(5)); len(got) != 2 || got[0] != 3 || got[1] != 5 {
+		t.Errorf("deputy received %v, want [3 5]", got)
+	}
+	if got := receive(t, other, until(6)); len(got) != 2 || got[0] != 2 || got[1] != 6 {
+		t.Errorf("other node received %v, want [2 6]", got)
+	}
+
+	// входящие: правило для любого типа от одного адреса не трогает других отправителей
+	lossy.DropNext(Inbound, 1, "", addrOf(other))
+	// правило на ноль сообщений не добавляется
+	lossy.DropNext(Inbound, 0, "", nil)
+	for _, m := range []struct {
+		seq  int64
+		from Conn
+	}{
+		{7, deputy},
+		{8, other},
+		{9, other},
+		{10, deputy},
+	} {
+		if _, err := m.from.WriteToUDP(message(t, m.seq, "ping"), addrOf(lossy)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := receive(t, lossy, until(10)); len(got) != 3 || got[0] != 7 || got[1] != 9 || got[2] != 10 {
+		t.Errorf("received %v, want [7 9 10]", got)
+	}
+}
+
+func TestParseLossProfile(t *testing.T) {
+	p, err := ParseLossProfile("loss=0.1 delay=50ms jitter=20ms,dup=0.01 reorder=0.05")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := LossProfile{Loss: 0.1, Delay: 50 * time.Millisecond, Jitter: 20 * time.Millisecond, Duplicate: 0.01, Reorder: 0.05}
+	if p != want {
+		t.Errorf("parsed %+v, want %+v", p, want)
+	}
+	if p, err := ParseLossProfile(""); err != nil || p != (LossProfile{}) {
+		t.Errorf("empty profile parsed as %+v, %v", p, err)
+	}
+
+	for _, s := range []string{
+		"loss",
+		"loss=",
+		"loss=1.5",
+		"dup=-0.1",
+		"reorder=often",
+		"delay=50",
+		"jitter=fast",
+		"drop=0.1",
+		"loss=0.1 delay",
+	} {
+		if _, err := ParseLossProfile(s); err == nil {
+			t.Errorf("profile %q accepted", s)
+		}
+	}
+}
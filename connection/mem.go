@@ -0,0 +1,181 @@
+package connection
+
+import (
+	"fmt"
+	"net"
+	"sync"
+)
+
+// размер очереди входящих пакетов сокета в памяти, при переполнении пакеты теряются как в UDP
+const memInboxSize = 1024
+
+// MemNetwork сеть в памяти процесса с виртуальными адресами.
+// Позволяет запустить мастера и игроков в одном процессе без настоящих сокетов
+type MemNetwork struct {
+	mu       sync.Mutex
+	conns    map[string]*memConn
+	groups   map[string][]*memConn
+	nextHost int
+	nextPort int
+}
+
+func NewMemNetwork() *MemNetwork {
+	return &MemNetwork{
+		conns:    make(map[string]*memConn),
+		groups:   make(map[string][]*memConn),
+		nextPort: 40000,
+	}
+}
+
+// NewHost выделяет виртуальный IP-адрес очередного узла: 10.0.0.1, 10.0.0.2...
+func (n *MemNetwork) NewHost() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.nextHost++
+	return fmt.Sprintf("10.0.%d.%d", n.nextHost/250, n.nextHost%250+1)
+}
+
+// NewHost6 выделяет виртуальный IPv6-адрес (ULA) очередного узла: fd00::1, fd00::2...
+func (n *MemNetwork) NewHost6() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.nextHost++
+	return fmt.Sprintf("fd00::%x", n.nextHost)
+}
+
+// ListenUDP открывает сокет на ip:port, при port == 0 порт выбирается автоматически
+func (n *MemNetwork) ListenUDP(ip string, port int) (Conn, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	addr := &net.UDPAddr{IP: net.ParseIP(ip), Port: port}
+	if addr.IP == nil {
+		return nil, fmt.Errorf("invalid address %q", ip)
+	}
+	if port == 0 {
+		for {
+			n.nextPort++
+			addr.Port = n.nextPort
+			if _, busy := n.conns[addr.String()]; !busy {
+				break
+			}
+		}
+	}
+	if _, busy := n.conns[addr.String()]; busy {
+		return nil, fmt.Errorf("address %v already in use", addr)
+	}
+
+	conn := n.newConn(addr)
+	n.conns[addr.String()] = conn
+	return conn, nil
+}
+
+// ListenMulticast открывает сокет, получающий всё, что отправлено на адрес группы
+func (n *MemNetwork) ListenMulticast(group string) (Conn, error) {
+	addr, err := net.ResolveUDPAddr("udp", group)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving multicast address: %w", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	conn := n.newConn(addr)
+	conn.group = true
+	n.groups[addr.String()] = append(n.groups[addr.String()], conn)
+	return conn, nil
+}
+
+func (n *MemNetwork) newConn(addr *net.UDPAddr) *memConn {
+	return &memConn{
+		network: n,
+		local:   addr,
+		inbox:   make(chan packet, memInboxSize),
+		done:    make(chan struct{}),
+	}
+}
+
+// доставка пакета всем участникам группы или одному сокету
+func (n *MemNetwork) deliver(data []byte, from, to *net.UDPAddr) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	key := to.String()
+	if members, ok := n.groups[key]; ok {
+		for _, member := range members {
+			member.push(data, from)
+		}
+		return
+	}
+	if conn, ok := n.conns[key]; ok {
+		conn.push(data, from)
+	}
+}
+
+func (n *MemNetwork) remove(c *memConn) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	key := c.local.String()
+	if !c.group {
+		delete(n.conns, key)
+		return
+	}
+	members := n.groups[key]
+	for i, member := range members {
+		if member == c {
+			n.groups[key] = append(members[:i], members[i+1:]...)
+			break
+		}
+	}
+}
+
+// memConn сокет сети в памяти
+type memConn struct {
+	network *MemNetwork
+	local   *net.UDPAddr
+	group   bool
+
+	inbox     chan packet
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (c *memConn) push(data []byte, from *net.UDPAddr) {
+	select {
+	case c.inbox <- packet{data: append([]byte(nil), data...), addr: from}:
+	default:
+	}
+}
+
+func (c *memConn) ReadFromUDP(b []byte) (int, *net.UDPAddr, error) {
+	select {
+	case p := <-c.inbox:
+		return copy(b, p.data), p.addr, nil
+	case <-c.done:
+		return 0, nil, net.ErrClosed
+	}
+}
+
+func (c *memConn) WriteToUDP(b []byte, addr *net.UDPAddr) (int, error) {
+	select {
+	case <-c.done:
+		return 0, net.ErrClosed
+	default:
+	}
+	c.network.deliver(b, c.local, addr)
+	return len(b), nil
+}
+
+func (c *memConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *memConn) Close() error {
+	err := net.ErrClosed
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.network.remove(c)
+		err = nil
+	})
+	return err
+}
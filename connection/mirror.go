@@ -0,0 +1,85 @@
+package connection
+
+import (
+	"SnakeGame/logging"
+	pb "SnakeGame/model/proto"
+	"errors"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"net"
+	"os"
+	"time"
+)
+
+// переменная окружения с адресом инструмента захвата, например "127.0.0.1:9194"
+const mirrorEnv = "SNAKE_MIRROR"
+
+// MirrorConn обёртка сокета, копирующая каждый полученный и отправленный пакет
+// инструменту захвата в виде CaptureRecord
+type MirrorConn struct {
+	Conn
+	out    Conn
+	target *net.UDPAddr
+}
+
+// NewMirrorConn зеркалирует трафик inner на адрес target через сокет out
+func NewMirrorConn(inner, out Conn, target *net.UDPAddr) *MirrorConn {
+	return &MirrorConn{Conn: inner, out: out, target: target}
+}
+
+// MirrorTarget адрес инструмента захвата из SNAKE_MIRROR, пустая строка - зеркалирование выключено
+func MirrorTarget() string {
+	return os.Getenv(mirrorEnv)
+}
+
+// DialMirror сокет для отправки копий пакетов инструменту захвата на target
+func DialMirror(target string) (Conn, *net.UDPAddr, error) {
+	addr, err := net.ResolveUDPAddr("udp", target)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error resolving mirror address: %w", err)
+	}
+	conn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating mirror socket: %w", err)
+	}
+	return conn, addr, nil
+}
+
+func (c *MirrorConn) ReadFromUDP(b []byte) (int, *net.UDPAddr, error) {
+	n, addr, err := c.Conn.ReadFromUDP(b)
+	if err == nil {
+		c.mirror(pb.CaptureRecord_INBOUND, b[:n], addr)
+	}
+	return n, addr, err
+}
+
+func (c *MirrorConn) WriteToUDP(b []byte, addr *net.UDPAddr) (int, error) {
+	n, err := c.Conn.WriteToUDP(b, addr)
+	if err == nil {
+		c.mirror(pb.CaptureRecord_OUTBOUND, b, addr)
+	}
+	return n, err
+}
+
+func (c *MirrorConn) Close() error {
+	c.out.Close()
+	return c.Conn.Close()
+}
+
+func (c *MirrorConn) mirror(direction pb.CaptureRecord_Direction, payload []byte, peer *net.UDPAddr) {
+	record := &pb.CaptureRecord{
+		TimeUnixNano: proto.Int64(time.Now().UnixNano()),
+		Direction:    direction.Enum(),
+		Node:         proto.String(c.Conn.LocalAddr().String()),
+		Peer:         proto.String(peer.String()),
+		Payload:      payload,
+	}
+	data, err := proto.Marshal(record)
+	if err != nil {
+		logging.Network.Error("Error marshalling capture record", logging.Err(err))
+		return
+	}
+	if _, err := c.out.WriteToUDP(data, c.target); err != nil && !errors.Is(err, net.ErrClosed) {
+		logging.Network.Error("Error mirroring packet", logging.Addr(c.target), logging.Err(err))
+	}
+}
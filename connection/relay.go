@@ -0,0 +1,216 @@
+package connection
+
+import (
+	"SnakeGame/logging"
+	pb "SnakeGame/model/proto"
+	"errors"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// переменная окружения с адресом ретранслятора, например "10.1.2.3:9193"
+	relayEnv = "SNAKE_RELAY"
+	// как часто узел напоминает ретранслятору о себе
+	relayKeepAlive = 5 * time.Second
+	// сколько ждать ответа на регистрацию
+	relayRegisterTimeout = 3 * time.Second
+	// размер буфера под пакет с конвертом ретранслятора
+	relayBufferSize = 65536
+)
+
+// RelayConn сокет, все сообщения которого идут через ретранслятор.
+// Для узла он выглядит как обычный UDP-сокет: адреса других узлов - это адреса, с которых их видит ретранслятор
+type RelayConn struct {
+	inner     Conn
+	relay     *net.UDPAddr
+	multicast bool
+
+	mu    sync.Mutex
+	local *net.UDPAddr
+
+	// буфер чтения конвертов, один на сокет: ReadFromUDP вызывается на каждый пакет
+	readMu  sync.Mutex
+	readBuf []byte
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// DialRelay регистрирует у ретранслятора новый сокет.
+// multicast - сокет получает сообщения, отправленные на multicast-группу
+func DialRelay(relayAddr string, multicast bool) (*RelayConn, error) {
+	addr, err := net.ResolveUDPAddr("udp", relayAddr)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving relay address: %w", err)
+	}
+	conn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating relay socket: %w", err)
+	}
+	return NewRelayConn(conn, addr, multicast)
+}
+
+// NewRelayConn регистрирует сокет inner у ретранслятора relay и ждёт ответа
+func NewRelayConn(inner Conn, relay *net.UDPAddr, multicast bool) (*RelayConn, error) {
+	c := &RelayConn{
+		inner:     inner,
+		relay:     relay,
+		multicast: multicast,
+		readBuf:   make([]byte, relayBufferSize),
+		done:      make(chan struct{}),
+	}
+
+	registered := make(chan *net.UDPAddr, 1)
+	go func() {
+		buf := make([]byte, relayBufferSize)
+		for {
+			n, from, err := inner.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			if local := c.registeredAddr(buf[:n], from); local != nil {
+				registered <- local
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(relayRegisterTimeout / 6)
+	defer ticker.Stop()
+	deadline := time.After(relayRegisterTimeout)
+	for {
+		c.register()
+		select {
+		case local := <-registered:
+			c.local = local
+			go c.keepAlive()
+			return c, nil
+		case <-deadline:
+			inner.Close()
+			return nil, fmt.Errorf("relay %v did not answer", relay)
+		case <-ticker.C:
+		}
+	}
+}
+
+// адрес узла из ответа ретранслятора на регистрацию
+func (c *RelayConn) registeredAddr(data []byte, from *net.UDPAddr) *net.UDPAddr {
+	if !from.IP.Equal(c.relay.IP) || from.Port != c.relay.Port {
+		return nil
+	}
+	var msg pb.RelayMessage
+	if err := proto.Unmarshal(data, &msg); err != nil || msg.GetRegistered() == nil {
+		return nil
+	}
+	ip := net.ParseIP(msg.GetRegistered().GetIpAddress())
+	if ip == nil {
+		return nil
+	}
+	return &net.UDPAddr{IP: ip, Port: int(msg.GetRegistered().GetPort())}
+}
+
+func (c *RelayConn) register() {
+	msg := &pb.RelayMessage{
+		Type: &pb.RelayMessage_Register{
+			Register: &pb.RelayMessage_RegisterMsg{Multicast: proto.Bool(c.multicast)},
+		},
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		logging.Network.Error("Error marshalling relay registration", logging.Err(err))
+		return
+	}
+	if _, err := c.inner.WriteToUDP(data, c.relay); err != nil && !errors.Is(err, net.ErrClosed) {
+		logging.Network.Error("Error registering at relay", logging.Addr(c.relay), logging.Err(err))
+	}
+}
+
+func (c *RelayConn) keepAlive() {
+	ticker := time.NewTicker(relayKeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.register()
+		}
+	}
+}
+
+func (c *RelayConn) ReadFromUDP(b []byte) (int, *net.UDPAddr, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	buf := c.readBuf
+	for {
+		n, from, err := c.inner.ReadFromUDP(buf)
+		if err != nil {
+			return 0, nil, err
+		}
+		if !from.IP.Equal(c.relay.IP) || from.Port != c.relay.Port {
+			continue
+		}
+
+		var msg pb.RelayMessage
+		if err := proto.Unmarshal(buf[:n], &msg); err != nil {
+			continue
+		}
+		switch t := msg.Type.(type) {
+		case *pb.RelayMessage_Forward:
+			ip := net.ParseIP(t.Forward.GetIpAddress())
+			if ip == nil {
+				continue
+			}
+			return copy(b, t.Forward.GetPayload()), &net.UDPAddr{IP: ip, Port: int(t.Forward.GetPort())}, nil
+		case *pb.RelayMessage_Registered:
+			// ретранслятор мог перезапуститься и увидеть нас с другого адреса
+			if local := c.registeredAddr(buf[:n], from); local != nil {
+				c.mu.Lock()
+				c.local = local
+				c.mu.Unlock()
+			}
+		}
+	}
+}
+
+func (c *RelayConn) WriteToUDP(b []byte, addr *net.UDPAddr) (int, error) {
+	msg := &pb.RelayMessage{
+		Type: &pb.RelayMessage_Forward{
+			Forward: &pb.RelayMessage_ForwardMsg{
+				IpAddress: proto.String(addr.IP.String()),
+				Port:      proto.Int32(int32(addr.Port)),
+				Payload:   b,
+			},
+		},
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := c.inner.WriteToUDP(data, c.relay); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// LocalAddr адрес сокета, каким его видит ретранслятор: по нему другие узлы отправляют нам сообщения
+func (c *RelayConn) LocalAddr() net.Addr {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.local
+}
+
+func (c *RelayConn) Close() error {
+	c.closeOnce.Do(func() { close(c.done) })
+	return c.inner.Close()
+}
+
+// relayFromEnv адрес ретранслятора из SNAKE_RELAY, пустая строка - работаем без него
+func relayFromEnv() string {
+	return os.Getenv(relayEnv)
+}
@@ -0,0 +1,149 @@
+package logging
+
+import (
+	pb "SnakeGame/model/proto"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	// уровни подсистем, например "network=debug,engine=info,ui=warn"; уровень без имени относится ко всем
+	levelsEnv = "SNAKE_LOG"
+	// файл, в который пишется лог вместо stderr
+	fileEnv = "SNAKE_LOG_FILE"
+)
+
+// подсистемы, уровень которых настраивается отдельно
+const (
+	network = "network"
+	engine  = "engine"
+	ui      = "ui"
+)
+
+// Логгеры подсистем: Network - сокеты, отправка, подтверждения и переотправки сообщений,
+// Engine - игра, игроки и смена ролей, UI - интерфейс
+var (
+	Network *slog.Logger
+	Engine  *slog.Logger
+	UI      *slog.Logger
+)
+
+var levels = map[string]*slog.LevelVar{
+	network: new(slog.LevelVar),
+	engine:  new(slog.LevelVar),
+	ui:      new(slog.LevelVar),
+}
+
+func init() {
+	setOutput(os.Stderr)
+}
+
+// SetupFromEnv уровни из SNAKE_LOG и файл из SNAKE_LOG_FILE.
+// Возвращает файл лога, который нужно закрыть при выходе, или nil
+func SetupFromEnv() (io.Closer, error) {
+	if err := SetLevels(os.Getenv(levelsEnv)); err != nil {
+		return nil, err
+	}
+	path := os.Getenv(fileEnv)
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening log file: %w", err)
+	}
+	setOutput(file)
+	return file, nil
+}
+
+// SetLevels разбирает уровни вида "debug" или "network=debug,engine=warn"
+func SetLevels(s string) error {
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' }) {
+		name, value, ok := strings.Cut(field, "=")
+		if !ok {
+			name, value = "", field
+		}
+
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(value)); err != nil {
+			return fmt.Errorf("invalid log level %q: %w", field, err)
+		}
+		if name == "" {
+			for _, v := range levels {
+				v.Set(level)
+			}
+			continue
+		}
+		v, ok := levels[name]
+		if !ok {
+			return fmt.Errorf("unknown log subsystem %q", name)
+		}
+		v.Set(level)
+	}
+	return nil
+}
+
+// setOutput логгеры подсистем пишут в w. Вызывается до запуска узлов
+func setOutput(w io.Writer) {
+	out := &lockedWriter{w: w}
+	newLogger := func(name string) *slog.Logger {
+		handler := slog.NewTextHandler(out, &slog.HandlerOptions{Level: levels[name]})
+		return slog.New(handler).With("subsystem", name)
+	}
+	Network = newLogger(network)
+	Engine = newLogger(engine)
+	UI = newLogger(ui)
+}
+
+// lockedWriter у подсистем общий файл, строки разных логгеров не должны перемешиваться
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// MessageType имя варианта GameMessage.Type: "ack", "state", "join"...
+func MessageType(msg *pb.GameMessage) string {
+	m := msg.ProtoReflect()
+	field := m.WhichOneof(m.Descriptor().Oneofs().ByName("Type"))
+	if field == nil {
+		return ""
+	}
+	return string(field.Name())
+}
+
+// Message поля msg_type и msg_seq сообщения
+func Message(msg *pb.GameMessage) slog.Attr {
+	return slog.Attr{Value: slog.GroupValue(
+		slog.String("msg_type", MessageType(msg)),
+		slog.Int64("msg_seq", msg.GetMsgSeq()),
+	)}
+}
+
+// PlayerID поле player_id
+func PlayerID(id int32) slog.Attr {
+	return slog.Int("player_id", int(id))
+}
+
+// Addr поле addr с адресом другой стороны
+func Addr(addr net.Addr) slog.Attr {
+	if addr == nil {
+		return slog.String("addr", "")
+	}
+	return slog.String("addr", addr.String())
+}
+
+// Err поле err
+func Err(err error) slog.Attr {
+	return slog.Any("err", err)
+}
@@ -0,0 +1,77 @@
+package logging
+
+import (
+	pb "SnakeGame/model/proto"
+	"bytes"
+	"google.golang.org/protobuf/proto"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// resetLevels после теста все подсистемы снова пишут в stderr с уровнем info
+func resetLevels(t *testing.T) {
+	t.Cleanup(func() {
+		for _, v := range levels {
+			v.Set(slog.LevelInfo)
+		}
+		setOutput(os.Stderr)
+	})
+}
+
+func TestSetLevels(t *testing.T) {
+	resetLevels(t)
+	var out bytes.Buffer
+	setOutput(&out)
+
+	if err := SetLevels("warn, network=debug"); err != nil {
+		t.Fatal(err)
+	}
+	msg := &pb.GameMessage{MsgSeq: proto.Int64(7), Type: &pb.GameMessage_Ping{Ping: &pb.GameMessage_PingMsg{}}}
+	Network.Debug("Resent message", Message(msg), PlayerID(3))
+	Engine.Info("Player joined")
+	UI.Warn("Could not find selected game")
+
+	logged := out.String()
+	for _, want := range []string{
+		"subsystem=network msg_type=ping msg_seq=7 player_id=3",
+		"subsystem=ui",
+	} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("log does not contain %q:\n%s", want, logged)
+		}
+	}
+	if strings.Contains(logged, "Player joined") {
+		t.Errorf("engine logged below its level:\n%s", logged)
+	}
+
+	for _, invalid := range []string{"network=loud", "physics=debug"} {
+		if err := SetLevels(invalid); err == nil {
+			t.Errorf("SetLevels(%q) accepted", invalid)
+		}
+	}
+}
+
+func TestLogFile(t *testing.T) {
+	resetLevels(t)
+	path := filepath.Join(t.TempDir(), "snake.log")
+	t.Setenv(levelsEnv, "engine=debug")
+	t.Setenv(fileEnv, path)
+
+	file, err := SetupFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	Engine.Debug("Becoming new MASTER", PlayerID(2))
+	file.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `msg="Becoming new MASTER" subsystem=engine player_id=2`) {
+		t.Errorf("unexpected log file:\n%s", data)
+	}
+}
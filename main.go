@@ -1,9 +1,24 @@
 package main
 
 import (
+	"SnakeGame/logging"
+	"SnakeGame/metrics"
 	"SnakeGame/ui"
+	"log"
 )
 
 func main() {
+	logFile, err := logging.SetupFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if logFile != nil {
+		defer logFile.Close()
+	}
+	if addr := metrics.Addr(); addr != "" {
+		if _, err := metrics.Serve(addr); err != nil {
+			log.Fatal(err)
+		}
+	}
 	ui.RunApp()
 }
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"SnakeGame/logging"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+)
+
+// переменная окружения с адресом, на котором отдаются метрики, например "127.0.0.1:9195"
+const addrEnv = "SNAKE_METRICS"
+
+// Сетевые метрики, их пишет common.Node, а отброшенные сообщения - ограничение скорости мастера
+var (
+	MessagesSent     = NewCounter("snake_messages_sent_total", "Messages sent, without retransmits.", "type")
+	MessagesReceived = NewCounter("snake_messages_received_total", "Messages received and decoded.", "type")
+	Retransmits      = NewCounter("snake_retransmits_total", "Unacknowledged messages sent again.", "type")
+	Acks             = NewCounter("snake_acks_total", "Acks that confirmed a pending message.")
+	Timeouts         = NewCounter("snake_timeouts_total", "Peers that went silent: players on the master, the master on players.")
+	DroppedMessages  = NewCounter("snake_dropped_messages_total", "Incoming messages dropped by the master's rate limiter.", "type")
+)
+
+// Игровые метрики, их пишет мастер на каждом ходу
+var (
+	Ticks        = NewCounter("snake_ticks_total", "Game ticks played by the master.")
+	TickDuration = NewHistogram("snake_tick_duration_seconds", "Time to compute and send one tick.", []float64{.0001, .0005, .001, .005, .01, .05, .1, .5})
+	Players      = NewGauge("snake_players", "Players in the game by role.", "role")
+	Snakes       = NewGauge("snake_snakes", "Snakes on the field by state.", "state")
+	Food         = NewGauge("snake_food", "Food cells on the field.")
+	StateSize    = NewGauge("snake_state_size_bytes", "Size of the last StateMsg.")
+)
+
+// Addr адрес метрик из SNAKE_METRICS, пустая строка - метрики не отдаются
+func Addr() string {
+	return os.Getenv(addrEnv)
+}
+
+// Serve отдаёт метрики по HTTP на addr по пути /metrics до завершения процесса
+func Serve(addr string) (net.Addr, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("error starting metrics endpoint: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", Handler())
+	go func() {
+		if err := http.Serve(listener, mux); err != nil && !errors.Is(err, net.ErrClosed) {
+			logging.Network.Error("Metrics endpoint stopped", logging.Err(err))
+		}
+	}()
+	logging.Network.Info("Serving metrics", logging.Addr(listener.Addr()))
+	return listener.Addr(), nil
+}
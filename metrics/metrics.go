@@ -0,0 +1,229 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// метрика, которую умеет выводить реестр
+type metric interface {
+	write(w io.Writer)
+}
+
+// реестр метрик процесса в порядке регистрации
+var (
+	registryMu sync.Mutex
+	registry   []metric
+)
+
+func register(m metric) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, m)
+}
+
+// Handler все метрики в текстовом формате Prometheus
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WriteText(w)
+	})
+}
+
+// WriteText записывает все метрики в текстовом формате Prometheus
+func WriteText(w io.Writer) {
+	registryMu.Lock()
+	metrics := append([]metric(nil), registry...)
+	registryMu.Unlock()
+
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+// vec значения метрики по наборам значений меток
+type vec struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func newVec(kind, name, help string, labels []string) *vec {
+	return &vec{name: name, help: help, kind: kind, labels: labels, values: make(map[string]float64)}
+}
+
+// key строка меток вида `type="ack",role="MASTER"`, она же ключ значения
+func (v *vec) key(labelValues []string) string {
+	if len(labelValues) != len(v.labels) {
+		panic(fmt.Sprintf("metric %s: got %d label values for %d labels", v.name, len(labelValues), len(v.labels)))
+	}
+	pairs := make([]string, len(v.labels))
+	for i, label := range v.labels {
+		pairs[i] = label + `="` + escape(labelValues[i]) + `"`
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (v *vec) write(w io.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.name, v.help, v.name, v.kind)
+	keys := make([]string, 0, len(v.values))
+	for key := range v.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s %s\n", series(v.name, key), formatValue(v.values[key]))
+	}
+}
+
+// Counter счётчик, который только растёт
+type Counter struct {
+	v *vec
+}
+
+// NewCounter регистрирует счётчик с метками labels
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{v: newVec("counter", name, help, labels)}
+	if len(labels) == 0 {
+		// счётчик без меток виден с нуля, а не с первого события
+		c.v.values[""] = 0
+	}
+	register(c.v)
+	return c
+}
+
+// Inc увеличивает счётчик с такими значениями меток на 1
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add увеличивает счётчик на delta >= 0
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metric %s: counter cannot decrease", c.v.name))
+	}
+	key := c.v.key(labelValues)
+	c.v.mu.Lock()
+	defer c.v.mu.Unlock()
+	c.v.values[key] += delta
+}
+
+// Value текущее значение счётчика
+func (c *Counter) Value(labelValues ...string) float64 {
+	key := c.v.key(labelValues)
+	c.v.mu.Lock()
+	defer c.v.mu.Unlock()
+	return c.v.values[key]
+}
+
+// Gauge значение, которое может расти и уменьшаться
+type Gauge struct {
+	v *vec
+}
+
+// NewGauge регистрирует показатель с метками labels
+func NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{v: newVec("gauge", name, help, labels)}
+	register(g.v)
+	return g
+}
+
+// Set устанавливает значение показателя с такими значениями меток
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	key := g.v.key(labelValues)
+	g.v.mu.Lock()
+	defer g.v.mu.Unlock()
+	g.v.values[key] = value
+}
+
+// Value текущее значение показателя
+func (g *Gauge) Value(labelValues ...string) float64 {
+	key := g.v.key(labelValues)
+	g.v.mu.Lock()
+	defer g.v.mu.Unlock()
+	return g.v.values[key]
+}
+
+// Histogram распределение значений по корзинам
+type Histogram struct {
+	name    string
+	help    string
+	buckets []float64
+
+	mu     sync.Mutex
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram регистрирует гистограмму с верхними границами корзин buckets по возрастанию
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	h := &Histogram{name: name, help: help, buckets: buckets, counts: make([]uint64, len(buckets))}
+	register(h)
+	return h
+}
+
+// Observe добавляет значение в гистограмму
+func (h *Histogram) Observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range h.buckets {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+// Count сколько значений добавлено
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	for i, bound := range h.buckets {
+		fmt.Fprintf(w, "%s %d\n", series(h.name+"_bucket", `le="`+formatValue(bound)+`"`), h.counts[i])
+	}
+	fmt.Fprintf(w, "%s %d\n", series(h.name+"_bucket", `le="+Inf"`), h.count)
+	fmt.Fprintf(w, "%s_sum %s\n%s_count %d\n", h.name, formatValue(h.sum), h.name, h.count)
+}
+
+func series(name, labels string) string {
+	if labels == "" {
+		return name
+	}
+	return name + "{" + labels + "}"
+}
+
+func formatValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escape(s string) string {
+	return labelEscaper.Replace(s)
+}
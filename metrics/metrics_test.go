@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTextFormat(t *testing.T) {
+	counter := NewCounter("test_messages_total", "Test messages.", "type")
+	counter.Inc("ack")
+	counter.Add(2, `st"ate`)
+	gauge := NewGauge("test_food", "Test food.")
+	gauge.Set(3.5)
+	histogram := NewHistogram("test_tick_seconds", "Test ticks.", []float64{0.01, 0.1})
+	histogram.Observe(0.005)
+	histogram.Observe(0.05)
+	histogram.Observe(1)
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if got := recorder.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain") {
+		t.Errorf("unexpected content type %q", got)
+	}
+
+	body := recorder.Body.String()
+	for _, want := range []string{
+		"# TYPE test_messages_total counter\n",
+		`test_messages_total{type="ack"} 1` + "\n",
+		`test_messages_total{type="st\"ate"} 2` + "\n",
+		"# TYPE test_food gauge\ntest_food 3.5\n",
+		`test_tick_seconds_bucket{le="0.01"} 1` + "\n",
+		`test_tick_seconds_bucket{le="0.1"} 2` + "\n",
+		`test_tick_seconds_bucket{le="+Inf"} 3` + "\n",
+		"test_tick_seconds_sum 1.055\ntest_tick_seconds_count 3\n",
+		"# TYPE snake_ticks_total counter\nsnake_ticks_total 0\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics do not contain %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestWrongLabels(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("counter accepted a missing label value")
+		}
+	}()
+	NewCounter("test_wrong_labels_total", "Test.", "type").Inc()
+}
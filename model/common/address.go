@@ -0,0 +1,43 @@
+package common
+
+import (
+	"SnakeGame/connection"
+	pb "SnakeGame/model/proto"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// JoinHostPort адрес "host:port" из ip_address и port, IPv6 берётся в квадратные скобки
+func JoinHostPort(ip string, port int32) string {
+	return net.JoinHostPort(ip, strconv.Itoa(int(port)))
+}
+
+// IPString ip_address по адресу отправителя, у link-local IPv6 вместе с зоной
+func IPString(addr *net.UDPAddr) string {
+	if addr.Zone != "" {
+		return addr.IP.String() + "%" + addr.Zone
+	}
+	return addr.IP.String()
+}
+
+// SameAddress описывает ли ip_address и port игрока адрес addr
+func SameAddress(player *pb.GamePlayer, addr *net.UDPAddr) bool {
+	host, _, _ := strings.Cut(player.GetIpAddress(), "%")
+	ip := net.ParseIP(host)
+	return ip != nil && ip.Equal(addr.IP) && int(player.GetPort()) == addr.Port
+}
+
+// ResolvePlayerAddr адрес для отправки сообщений игроку.
+// Зона link-local адреса в ip_address - интерфейс узла, записавшего адрес,
+// поэтому при заданном SNAKE_IFACE подставляем свой интерфейс
+func ResolvePlayerAddr(player *pb.GamePlayer) (*net.UDPAddr, error) {
+	addr, err := net.ResolveUDPAddr("udp", JoinHostPort(player.GetIpAddress(), player.GetPort()))
+	if err != nil {
+		return nil, err
+	}
+	if iface := connection.Interface(); iface != "" && addr.IP.IsLinkLocalUnicast() {
+		addr.Zone = iface
+	}
+	return addr, nil
+}
@@ -0,0 +1,49 @@
+package common
+
+import (
+	pb "SnakeGame/model/proto"
+	"google.golang.org/protobuf/proto"
+	"net"
+	"testing"
+)
+
+func TestJoinHostPort(t *testing.T) {
+	tests := []struct {
+		ip   string
+		port int32
+		want string
+	}{
+		{"10.0.0.1", 9192, "10.0.0.1:9192"},
+		{"fd00::1", 9192, "[fd00::1]:9192"},
+		{"fe80::1%eth0", 9192, "[fe80::1%eth0]:9192"},
+	}
+	for _, tt := range tests {
+		if got := JoinHostPort(tt.ip, tt.port); got != tt.want {
+			t.Errorf("JoinHostPort(%q, %d) = %q, want %q", tt.ip, tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestSameAddress(t *testing.T) {
+	player := &pb.GamePlayer{IpAddress: proto.String("fe80::1%eth0"), Port: proto.Int32(5000)}
+	tests := []struct {
+		addr *net.UDPAddr
+		want bool
+	}{
+		{&net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: 5000, Zone: "eth0"}, true},
+		{&net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: 5000}, true},
+		{&net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: 5001}, false},
+		{&net.UDPAddr{IP: net.ParseIP("fe80::2"), Port: 5000}, false},
+	}
+	for _, tt := range tests {
+		if got := SameAddress(player, tt.addr); got != tt.want {
+			t.Errorf("SameAddress(%v) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+
+	// IPv4 в IPv6-записи - тот же адрес
+	player = &pb.GamePlayer{IpAddress: proto.String("10.0.0.1"), Port: proto.Int32(5000)}
+	if !SameAddress(player, &net.UDPAddr{IP: net.ParseIP("::ffff:10.0.0.1"), Port: 5000}) {
+		t.Errorf("IPv4-mapped address is not recognised")
+	}
+}
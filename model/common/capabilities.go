@@ -0,0 +1,74 @@
+package common
+
+import (
+	pb "SnakeGame/model/proto"
+	"crypto/hmac"
+	"crypto/sha256"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtocolVersion версия протокола этого узла. Узлы без поля protocol_version считаются версией 0
+const ProtocolVersion = 1
+
+// SupportedCapabilities возможности, которые поддерживает этот узел
+var SupportedCapabilities = []pb.Capability{pb.Capability_SESSIONS, pb.Capability_SOLID_WALLS, pb.Capability_OBSTACLES, pb.Capability_PORTALS}
+
+// HasCapability есть ли возможность в списке
+func HasCapability(capabilities []pb.Capability, capability pb.Capability) bool {
+	for _, c := range capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// NegotiateCapabilities возможности, которые поддерживают обе стороны
+func NegotiateCapabilities(ours, theirs []pb.Capability) []pb.Capability {
+	common := []pb.Capability{}
+	for _, c := range ours {
+		if c != pb.Capability_UNKNOWN_CAPABILITY && HasCapability(theirs, c) && !HasCapability(common, c) {
+			common = append(common, c)
+		}
+	}
+	return common
+}
+
+// MissingCapabilities обязательные возможности, которых нет среди поддерживаемых
+func MissingCapabilities(required, supported []pb.Capability) []pb.Capability {
+	var missing []pb.Capability
+	for _, c := range required {
+		if !HasCapability(supported, c) {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
+
+// SignMessage подпись сообщения токеном сессии: HMAC-SHA256 от сообщения без подписи
+func SignMessage(msg *pb.GameMessage, token []byte) {
+	msg.SessionTag = nil
+	msg.SessionTag = messageTag(msg, token)
+}
+
+// VerifyMessage проверка подписи сообщения токеном сессии
+func VerifyMessage(msg *pb.GameMessage, token []byte) bool {
+	tag := msg.GetSessionTag()
+	if len(tag) == 0 || len(token) == 0 {
+		return false
+	}
+	unsigned := proto.Clone(msg).(*pb.GameMessage)
+	unsigned.SessionTag = nil
+	expected := messageTag(unsigned, token)
+	return expected != nil && hmac.Equal(tag, expected)
+}
+
+func messageTag(msg *pb.GameMessage, token []byte) []byte {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return nil
+	}
+	mac := hmac.New(sha256.New, token)
+	mac.Write(data)
+	return mac.Sum(nil)[:16]
+}
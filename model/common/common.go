@@ -1,18 +1,18 @@
 package common
 
 import (
+	"SnakeGame/connection"
+	"SnakeGame/logging"
+	"SnakeGame/metrics"
 	pb "SnakeGame/model/proto"
+	"errors"
 	"fmt"
 	"google.golang.org/protobuf/proto"
-	"log"
 	"net"
-	"strconv"
 	"sync"
 	"time"
 )
 
-const MulticastAddr = "239.192.0.4:9192"
-
 // MessageEntry структура для отслеживания неподтвержденных сообщений
 type MessageEntry struct {
 	msg       *pb.GameMessage
@@ -25,58 +25,112 @@ type Node struct {
 	State            *pb.GameState
 	Config           *pb.GameConfig
 	MulticastAddress string
-	MulticastConn    *net.UDPConn
-	UnicastConn      *net.UDPConn
+	MulticastConn    connection.Conn
+	UnicastConn      connection.Conn
 	PlayerInfo       *pb.GamePlayer
-	MsgSeq           int64
 	Role             pb.NodeRole
 
 	MasterAddr *net.UDPAddr
 
 	// время последнего сообщения от игрока [playerId]time
 	LastInteraction map[int32]time.Time
-	// время отправки последнего сообщения игроку отправок сообщений
-	LastSent map[string]time.Time
 
+	// Mu защищает состояние игры, sendMu - счётчик и учёт отправленных сообщений
+	Mu   sync.Mutex
+	Cond *sync.Cond
+
+	sendMu sync.Mutex
+	msgSeq int64
+	// токен сессии у мастера, им подписываются отправляемые сообщения
+	sessionToken []byte
+	// время отправки последнего сообщения игроку отправок сообщений
+	lastSent            map[string]time.Time
 	unconfirmedMessages map[int64]*MessageEntry
-	Mu                  sync.Mutex
-	Cond                *sync.Cond
-	AckChan             chan int64
+	// время от отправки до подтверждения последнего сообщения на адрес
+	rtt map[string]time.Duration
+
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
-func NewNode(state *pb.GameState, config *pb.GameConfig, multicastConn *net.UDPConn,
-	unicastConn *net.UDPConn, playerInfo *pb.GamePlayer) *Node {
+func NewNode(state *pb.GameState, config *pb.GameConfig, multicastConn connection.Conn,
+	unicastConn connection.Conn, playerInfo *pb.GamePlayer) *Node {
 	node := &Node{
 		State:            state,
 		Config:           config,
-		MulticastAddress: MulticastAddr,
+		MulticastAddress: connection.MulticastGroup(),
 		MulticastConn:    multicastConn,
 		UnicastConn:      unicastConn,
 		PlayerInfo:       playerInfo,
-		MsgSeq:           1,
+		msgSeq:           1,
 
 		LastInteraction:     make(map[int32]time.Time),
-		LastSent:            make(map[string]time.Time),
+		lastSent:            make(map[string]time.Time),
 		unconfirmedMessages: make(map[int64]*MessageEntry),
-		AckChan:             make(chan int64),
+		rtt:                 make(map[string]time.Duration),
+		done:                make(chan struct{}),
 	}
 
 	node.Cond = sync.NewCond(&node.Mu)
 
+	if target := connection.MirrorTarget(); target != "" {
+		out, addr, err := connection.DialMirror(target)
+		if err != nil {
+			logging.Network.Error("Error enabling traffic mirroring", logging.Err(err))
+		} else {
+			node.MirrorTo(out, addr)
+		}
+	}
+
 	return node
 }
 
-// GetLocalIP получения реального ip
+// MirrorTo копии всех пакетов unicast-сокета узла отправляются через out инструменту захвата на target.
+// Вызывается до запуска циклов приёма
+func (n *Node) MirrorTo(out connection.Conn, target *net.UDPAddr) {
+	n.UnicastConn = connection.NewMirrorConn(n.UnicastConn, out, target)
+}
+
+// Done закрывается, когда узел остановлен
+func (n *Node) Done() <-chan struct{} {
+	return n.done
+}
+
+// Close останавливает фоновые циклы узла и закрывает его unicast-сокет.
+// Multicast-сокет не закрывается: он общий для всех игр, запущенных из интерфейса
+func (n *Node) Close() {
+	n.closeOnce.Do(func() {
+		close(n.done)
+		n.UnicastConn.Close()
+	})
+}
+
+// Closed проверка, остановлен ли узел
+func (n *Node) Closed() bool {
+	select {
+	case <-n.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// GetLocalIP получения реального ip. В режиме IPv6 предпочитаются глобальные и ULA адреса,
+// затем link-local вместе с зоной (интерфейсом)
 func GetLocalIP() (string, error) {
 	interfaces, err := net.Interfaces()
 	if err != nil {
 		return "", fmt.Errorf("error getting network interfaces: %w", err)
 	}
 
+	linkLocal := ""
 	for _, iface := range interfaces {
 		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
 			continue
 		}
+		if name := connection.Interface(); name != "" && iface.Name != name {
+			continue
+		}
 
 		addrs, err := iface.Addrs()
 		if err != nil {
@@ -92,8 +146,13 @@ func GetLocalIP() (string, error) {
 				ip = v.IP
 			}
 
-			// IPv4
-			if ip == nil || ip.IsLoopback() || ip.To4() == nil {
+			if ip == nil || ip.IsLoopback() || (ip.To4() != nil) == connection.IPv6() {
+				continue
+			}
+			if ip.IsLinkLocalUnicast() {
+				if linkLocal == "" && ip.To4() == nil {
+					linkLocal = ip.String() + "%" + iface.Name
+				}
 				continue
 			}
 
@@ -101,9 +160,30 @@ func GetLocalIP() (string, error) {
 		}
 	}
 
+	if linkLocal != "" {
+		return linkLocal, nil
+	}
 	return "", fmt.Errorf("no connected network interface found")
 }
 
+// LocalAddr адрес, по которому до сокета могут достучаться другие узлы.
+// Для сокета, слушающего на всех интерфейсах, подставляется реальный ip
+func LocalAddr(conn connection.Conn) (string, int, error) {
+	addr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return "", 0, fmt.Errorf("unexpected local address %v", conn.LocalAddr())
+	}
+	if addr.IP != nil && !addr.IP.IsUnspecified() {
+		return IPString(addr), addr.Port, nil
+	}
+
+	ip, err := GetLocalIP()
+	if err != nil {
+		return "", 0, err
+	}
+	return ip, addr.Port, nil
+}
+
 // SendAck любое сообщение подтверждается отправкой в ответ сообщения AckMsg с таким же msg_seq
 func (n *Node) SendAck(msg *pb.GameMessage, addr *net.UDPAddr) {
 	switch msg.Type.(type) {
@@ -123,21 +203,7 @@ func (n *Node) SendAck(msg *pb.GameMessage, addr *net.UDPAddr) {
 	}
 
 	n.SendMessage(ackMsg, addr)
-
-	//switch msg.Type.(type) {
-	//case *pb.GameMessage_Ping:
-	//	log.Printf("Sent AckMsg to %v in response to Ping", id)
-	//case *pb.GameMessage_Steer:
-	//	log.Printf("Sent AckMsg to %v in response to Steer", id)
-	//case *pb.GameMessage_State:
-	//	log.Printf("Sent AckMsg to %v in response to State", id)
-	//case *pb.GameMessage_Join:
-	//	log.Printf("Sent AckMsg to %v in response to Join", id)
-	//case *pb.GameMessage_Error:
-	//	log.Printf("Sent AckMsg to %v in response to Error", id)
-	//case *pb.GameMessage_RoleChange:
-	//	log.Printf("Sent AckMsg to %v in response to RoleChange", id)
-	//}
+	logging.Network.Debug("Sent ack", logging.Message(msg), logging.PlayerID(id), logging.Addr(addr))
 }
 
 // GetPlayerIdByAddress id игрока по адресу
@@ -145,8 +211,8 @@ func (n *Node) GetPlayerIdByAddress(addr *net.UDPAddr) int32 {
 	if n.State == nil {
 		return 1
 	}
-	for _, player := range n.State.Players.Players {
-		if player.GetIpAddress() == addr.IP.String() && int(player.GetPort()) == addr.Port {
+	for _, player := range n.State.GetPlayers().GetPlayers() {
+		if SameAddress(player, addr) {
 			return player.GetId()
 		}
 	}
@@ -156,7 +222,6 @@ func (n *Node) GetPlayerIdByAddress(addr *net.UDPAddr) int32 {
 // SendPing отправка
 func (n *Node) SendPing(addr *net.UDPAddr) {
 	pingMsg := &pb.GameMessage{
-		MsgSeq:   proto.Int64(n.MsgSeq),
 		SenderId: proto.Int32(n.PlayerInfo.GetId()),
 		Type: &pb.GameMessage_Ping{
 			Ping: &pb.GameMessage_PingMsg{},
@@ -168,51 +233,109 @@ func (n *Node) SendPing(addr *net.UDPAddr) {
 
 // SendMessage отправка сообщения и добавление его в неподтверждённые
 func (n *Node) SendMessage(msg *pb.GameMessage, addr *net.UDPAddr) {
+	n.sendMu.Lock()
+	defer n.sendMu.Unlock()
+
 	// увеличиваем порядковый номер сообщения
 	msg.SenderId = proto.Int32(n.PlayerInfo.GetId())
 	switch msg.Type.(type) {
 	case *pb.GameMessage_Ack:
 
 	default:
-		msg.MsgSeq = proto.Int64(n.MsgSeq)
-		n.MsgSeq++
+		msg.MsgSeq = proto.Int64(n.msgSeq)
+		n.msgSeq++
+	}
+	if n.sessionToken != nil {
+		SignMessage(msg, n.sessionToken)
 	}
 
 	// отправляем
 	data, err := proto.Marshal(msg)
 	if err != nil {
-		log.Printf("Error marshalling Message: %v", err)
+		logging.Network.Error("Error marshalling message", logging.Message(msg), logging.Err(err))
 		return
 	}
 
 	_, err = n.UnicastConn.WriteToUDP(data, addr)
+	if errors.Is(err, net.ErrClosed) {
+		return
+	}
 	if err != nil {
-		log.Printf("Error sending Message: %v", err)
+		logging.Network.Error("Error sending message", logging.Message(msg), logging.Addr(addr), logging.Err(err))
 		return
 	}
+	metrics.MessagesSent.Inc(logging.MessageType(msg))
 
 	// добавляем сообщение в неподтверждённые
 	switch msg.Type.(type) {
 	case *pb.GameMessage_Announcement, *pb.GameMessage_Discover, *pb.GameMessage_Ack:
 
 	default:
+		// сообщение копируем: отправитель может переиспользовать его для других адресатов
 		n.unconfirmedMessages[msg.GetMsgSeq()] = &MessageEntry{
-			msg:       msg,
+			msg:       proto.Clone(msg).(*pb.GameMessage),
 			addr:      addr,
 			timestamp: time.Now(),
 		}
 	}
 
-	ip := addr.IP
-	port := addr.Port
-	address := fmt.Sprintf("%s:%d", ip, port)
-	n.LastSent[address] = time.Now()
+	n.lastSent[addr.String()] = time.Now()
+}
+
+// SetSessionToken подписывать отправляемые сообщения токеном сессии, nil - не подписывать
+func (n *Node) SetSessionToken(token []byte) {
+	n.sendMu.Lock()
+	defer n.sendMu.Unlock()
+
+	n.sessionToken = token
 }
 
 // HandleAck обработка полученных AckMsg
 func (n *Node) HandleAck(seq int64) {
-	if _, exists := n.unconfirmedMessages[seq]; exists {
-		delete(n.unconfirmedMessages, seq)
+	n.sendMu.Lock()
+	defer n.sendMu.Unlock()
+
+	if entry, ok := n.unconfirmedMessages[seq]; ok {
+		rtt := time.Since(entry.timestamp)
+		n.rtt[entry.addr.String()] = rtt
+		metrics.Acks.Inc()
+		logging.Network.Debug("Message acknowledged", logging.Message(entry.msg), logging.Addr(entry.addr), "rtt", rtt)
+	}
+	delete(n.unconfirmedMessages, seq)
+}
+
+// RTT время подтверждения последнего сообщения, отправленного на addr; false, если подтверждений ещё не было
+func (n *Node) RTT(addr *net.UDPAddr) (time.Duration, bool) {
+	n.sendMu.Lock()
+	defer n.sendMu.Unlock()
+
+	rtt, ok := n.rtt[addr.String()]
+	return rtt, ok
+}
+
+// ForgetAddress забыть учёт отправок и неподтверждённые сообщения на адрес удалённого игрока
+func (n *Node) ForgetAddress(address string) {
+	n.sendMu.Lock()
+	defer n.sendMu.Unlock()
+
+	delete(n.lastSent, address)
+	delete(n.rtt, address)
+	for seq, entry := range n.unconfirmedMessages {
+		if entry.addr.String() == address {
+			delete(n.unconfirmedMessages, seq)
+		}
+	}
+}
+
+// RedirectUnconfirmed перенаправить неподтверждённые сообщения со старого адреса мастера на новый
+func (n *Node) RedirectUnconfirmed(from, to *net.UDPAddr) {
+	n.sendMu.Lock()
+	defer n.sendMu.Unlock()
+
+	for _, entry := range n.unconfirmedMessages {
+		if entry.addr.IP.Equal(from.IP) && entry.addr.Port == from.Port {
+			entry.addr = to
+		}
 	}
 }
 
@@ -223,31 +346,37 @@ func (n *Node) ResendUnconfirmedMessages(stateDelayMs int32) {
 
 	for {
 		select {
+		case <-n.done:
+			return
 		// ответ не пришел, заново отправляем сообщение
 		case <-ticker.C:
-			now := time.Now()
-			for seq, entry := range n.unconfirmedMessages {
-				if now.Sub(entry.timestamp) > time.Duration(n.Config.GetStateDelayMs()/10)*time.Millisecond {
-					// переотправка сообщения
-					data, err := proto.Marshal(entry.msg)
-					if err != nil {
-						log.Printf("Error marshalling Message: %v", err)
-						continue
-					}
-					_, err = n.UnicastConn.WriteToUDP(data, entry.addr)
-					if err != nil {
-						fmt.Printf("Error sending Message: %v", err)
-						continue
-					}
-
-					entry.timestamp = time.Now()
-					log.Printf("Resent message with Seq: %d to %v from %v", seq, entry.addr, n.PlayerInfo.GetIpAddress()+":"+strconv.Itoa(int(n.PlayerInfo.GetPort())))
-					log.Printf(entry.msg.String())
-				}
+			n.resendUnconfirmed(time.Duration(stateDelayMs/10) * time.Millisecond)
+		}
+	}
+}
+
+func (n *Node) resendUnconfirmed(timeout time.Duration) {
+	n.sendMu.Lock()
+	defer n.sendMu.Unlock()
+
+	now := time.Now()
+	for _, entry := range n.unconfirmedMessages {
+		if now.Sub(entry.timestamp) > timeout {
+			// переотправка сообщения
+			data, err := proto.Marshal(entry.msg)
+			if err != nil {
+				logging.Network.Error("Error marshalling message", logging.Message(entry.msg), logging.Err(err))
+				continue
 			}
-		// ответ пришел, удаляем из мапы
-		case seq := <-n.AckChan:
-			n.HandleAck(seq)
+			_, err = n.UnicastConn.WriteToUDP(data, entry.addr)
+			if err != nil {
+				logging.Network.Error("Error resending message", logging.Message(entry.msg), logging.Addr(entry.addr), logging.Err(err))
+				continue
+			}
+
+			entry.timestamp = time.Now()
+			metrics.Retransmits.Inc(logging.MessageType(entry.msg))
+			logging.Network.Debug("Resent message", logging.Message(entry.msg), logging.Addr(entry.addr))
 		}
 	}
 }
@@ -257,39 +386,56 @@ func (n *Node) SendPings(stateDelayMs int32) {
 	ticker := time.NewTicker(time.Duration(stateDelayMs/10) * time.Millisecond)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		now := time.Now()
-		if n.State == nil {
-			continue
+	for {
+		select {
+		case <-n.done:
+			return
+		case <-ticker.C:
 		}
-		if n.Role == pb.NodeRole_MASTER {
-			// Мастер пингует всех игроков, кроме себя
-			for _, player := range n.State.Players.Players {
-				if player.GetId() == n.PlayerInfo.GetId() {
-					continue
-				}
-				addrKey := fmt.Sprintf("%s:%d", player.GetIpAddress(), player.GetPort())
-				last, exists := n.LastSent[addrKey]
-				if !exists || now.Sub(last) > time.Duration(n.Config.GetStateDelayMs()/10)*time.Millisecond {
-					playerAddr, err := net.ResolveUDPAddr("udp", addrKey)
-					if err != nil {
-						log.Printf("Error resolving address for Ping: %v", err)
-						continue
-					}
-					n.SendPing(playerAddr)
-					n.LastSent[addrKey] = now
-				}
+
+		for _, addr := range n.pingTargets() {
+			if n.idleSince(addr, time.Duration(stateDelayMs/10)*time.Millisecond) {
+				n.SendPing(addr)
 			}
-		} else {
-			// Обычный игрок пингует только мастера, если мастер известен
-			if n.MasterAddr != nil {
-				addrKey := n.MasterAddr.String()
-				last, exists := n.LastSent[addrKey]
-				if !exists || now.Sub(last) > time.Duration(n.Config.GetStateDelayMs()/10)*time.Millisecond {
-					n.SendPing(n.MasterAddr)
-					n.LastSent[addrKey] = now
-				}
+		}
+	}
+}
+
+// pingTargets адреса, с которыми узел поддерживает связь
+func (n *Node) pingTargets() []*net.UDPAddr {
+	n.Mu.Lock()
+	defer n.Mu.Unlock()
+
+	if n.State == nil {
+		return nil
+	}
+
+	var addrs []*net.UDPAddr
+	if n.Role == pb.NodeRole_MASTER {
+		// Мастер пингует всех игроков, кроме себя
+		for _, player := range n.State.GetPlayers().GetPlayers() {
+			if player.GetId() == n.PlayerInfo.GetId() {
+				continue
 			}
+			playerAddr, err := ResolvePlayerAddr(player)
+			if err != nil {
+				logging.Network.Error("Error resolving address for ping", logging.PlayerID(player.GetId()), logging.Err(err))
+				continue
+			}
+			addrs = append(addrs, playerAddr)
 		}
+	} else if n.MasterAddr != nil {
+		// Обычный игрок пингует только мастера, если мастер известен
+		addrs = append(addrs, n.MasterAddr)
 	}
+	return addrs
+}
+
+// idleSince проверка, что на адрес ничего не отправлялось дольше interval
+func (n *Node) idleSince(addr *net.UDPAddr, interval time.Duration) bool {
+	n.sendMu.Lock()
+	defer n.sendMu.Unlock()
+
+	last, exists := n.lastSent[addr.String()]
+	return !exists || time.Since(last) > interval
 }
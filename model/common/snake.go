@@ -0,0 +1,140 @@
+package common
+
+import (
+	pb "SnakeGame/model/proto"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+)
+
+// EncodeSnakes змеи в формате протокола: points каждой змеи - голова и смещения ключевых точек.
+// Внутри узлов змея хранится всеми своими клетками от головы к хвосту, исходные змеи не меняются
+func EncodeSnakes(snakes []*pb.GameState_Snake, config *pb.GameConfig) []*pb.GameState_Snake {
+	encoded := make([]*pb.GameState_Snake, 0, len(snakes))
+	for _, snake := range snakes {
+		encoded = append(encoded, &pb.GameState_Snake{
+			PlayerId:      snake.PlayerId,
+			Points:        encodePoints(snake.GetPoints(), config),
+			State:         snake.State,
+			HeadDirection: snake.HeadDirection,
+		})
+	}
+	return encoded
+}
+
+// encodePoints ключевые точки змеи по её клеткам: подряд идущие шаги в одну сторону
+// складываются в одно смещение, шаг с клетки портала считается от парной клетки
+func encodePoints(cells []*pb.GameState_Coord, config *pb.GameConfig) []*pb.GameState_Coord {
+	if len(cells) == 0 {
+		return nil
+	}
+	points := []*pb.GameState_Coord{proto.Clone(cells[0]).(*pb.GameState_Coord)}
+	var dx, dy int32
+	for i := 1; i < len(cells); i++ {
+		sx, sy := step(cells[i-1], cells[i], config)
+		if (dx != 0 || dy != 0) && sx == sign(dx) && sy == sign(dy) {
+			dx, dy = dx+sx, dy+sy
+			continue
+		}
+		if dx != 0 || dy != 0 {
+			points = append(points, &pb.GameState_Coord{X: proto.Int32(dx), Y: proto.Int32(dy)})
+		}
+		dx, dy = sx, sy
+	}
+	if dx != 0 || dy != 0 {
+		points = append(points, &pb.GameState_Coord{X: proto.Int32(dx), Y: proto.Int32(dy)})
+	}
+	return points
+}
+
+// step смещение от клетки from к соседней клетке to с учётом склейки краёв поля и порталов
+func step(from, to *pb.GameState_Coord, config *pb.GameConfig) (int32, int32) {
+	if exit, ok := portalExit(from, config); ok {
+		from = exit
+	}
+	dx, dy := to.GetX()-from.GetX(), to.GetY()-from.GetY()
+	if width := config.GetWidth(); dx > 1 {
+		dx -= width
+	} else if dx < -1 {
+		dx += width
+	}
+	if height := config.GetHeight(); dy > 1 {
+		dy -= height
+	} else if dy < -1 {
+		dy += height
+	}
+	return dx, dy
+}
+
+// DecodeSnakes заменяет ключевые точки змей состояния, пришедшего по сети, всеми клетками змей.
+// Ошибка, если ключевые точки не описывают змею на поле config
+func DecodeSnakes(snakes []*pb.GameState_Snake, config *pb.GameConfig) error {
+	if config == nil {
+		return fmt.Errorf("snakes cannot be decoded without game config")
+	}
+	for _, snake := range snakes {
+		cells, err := decodePoints(snake.GetPoints(), config)
+		if err != nil {
+			return fmt.Errorf("snake of player ID %d: %w", snake.GetPlayerId(), err)
+		}
+		snake.Points = cells
+	}
+	return nil
+}
+
+func decodePoints(points []*pb.GameState_Coord, config *pb.GameConfig) ([]*pb.GameState_Coord, error) {
+	if len(points) == 0 {
+		return nil, fmt.Errorf("no points")
+	}
+	if !inField(points[0], config) {
+		return nil, fmt.Errorf("head outside the field at %v", points[0])
+	}
+	// змея не длиннее поля, так смещения из сети не заставят выделить лишнюю память
+	limit := int64(config.GetWidth()) * int64(config.GetHeight())
+	cells := []*pb.GameState_Coord{proto.Clone(points[0]).(*pb.GameState_Coord)}
+	for _, point := range points[1:] {
+		dx, dy := point.GetX(), point.GetY()
+		if (dx != 0) == (dy != 0) {
+			return nil, fmt.Errorf("offset %v is not along one axis", point)
+		}
+		length := int64(dx)*int64(sign(dx)) + int64(dy)*int64(sign(dy))
+		if int64(len(cells))+length > limit {
+			return nil, fmt.Errorf("snake is longer than the field")
+		}
+		sx, sy := sign(dx), sign(dy)
+		for ; length > 0; length-- {
+			from := cells[len(cells)-1]
+			if exit, ok := portalExit(from, config); ok {
+				from = exit
+			}
+			cells = append(cells, &pb.GameState_Coord{
+				X: proto.Int32((from.GetX() + sx + config.GetWidth()) % config.GetWidth()),
+				Y: proto.Int32((from.GetY() + sy + config.GetHeight()) % config.GetHeight()),
+			})
+		}
+	}
+	return cells, nil
+}
+
+// portalExit парная клетка портала в клетке coord
+func portalExit(coord *pb.GameState_Coord, config *pb.GameConfig) (*pb.GameState_Coord, bool) {
+	for _, portal := range config.GetGameMap().GetPortals() {
+		a, b := portal.GetA(), portal.GetB()
+		if a.GetX() == coord.GetX() && a.GetY() == coord.GetY() {
+			return b, true
+		}
+		if b.GetX() == coord.GetX() && b.GetY() == coord.GetY() {
+			return a, true
+		}
+	}
+	return nil, false
+}
+
+func sign(v int32) int32 {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,79 @@
+package common
+
+import (
+	pb "SnakeGame/model/proto"
+	"google.golang.org/protobuf/proto"
+	"testing"
+)
+
+func at(x, y int32) *pb.GameState_Coord {
+	return &pb.GameState_Coord{X: proto.Int32(x), Y: proto.Int32(y)}
+}
+
+func TestEncodeSnakes(t *testing.T) {
+	config := &pb.GameConfig{
+		Width:   proto.Int32(10),
+		Height:  proto.Int32(10),
+		GameMap: &pb.GameMap{Portals: []*pb.Portal{{A: at(5, 2), B: at(2, 7)}}},
+	}
+	// змея шла вниз через нижний край, повернула вправо и прошла через портал (5, 2) -> (2, 7)
+	cells := []*pb.GameState_Coord{
+		at(4, 7), at(3, 7), at(2, 7), at(4, 2), at(3, 2), at(2, 2), at(2, 1), at(2, 0), at(2, 9), at(2, 8),
+	}
+	snake := &pb.GameState_Snake{
+		PlayerId:      proto.Int32(1),
+		Points:        cells,
+		State:         pb.GameState_Snake_ALIVE.Enum(),
+		HeadDirection: pb.Direction_RIGHT.Enum(),
+	}
+
+	// прямой путь через портал - одно смещение, поворот - новая ключевая точка
+	encoded := EncodeSnakes([]*pb.GameState_Snake{snake}, config)
+	want := []*pb.GameState_Coord{at(4, 7), at(-5, 0), at(0, -4)}
+	if len(encoded) != 1 || len(encoded[0].GetPoints()) != len(want) {
+		t.Fatalf("encoded %v, want points %v", encoded, want)
+	}
+	for i, point := range encoded[0].GetPoints() {
+		if !proto.Equal(point, want[i]) {
+			t.Fatalf("encoded points %v, want %v", encoded[0].GetPoints(), want)
+		}
+	}
+	if len(snake.GetPoints()) != len(cells) {
+		t.Fatal("encoding changed the snake")
+	}
+
+	if err := DecodeSnakes(encoded, config); err != nil {
+		t.Fatal(err)
+	}
+	for i, cell := range encoded[0].GetPoints() {
+		if !proto.Equal(cell, cells[i]) {
+			t.Fatalf("decoded cells %v, want %v", encoded[0].GetPoints(), cells)
+		}
+	}
+	if encoded[0].GetHeadDirection() != pb.Direction_RIGHT || encoded[0].GetPlayerId() != 1 {
+		t.Errorf("decoded snake %v", encoded[0])
+	}
+}
+
+func TestDecodeSnakesRejects(t *testing.T) {
+	config := &pb.GameConfig{Width: proto.Int32(10), Height: proto.Int32(10)}
+	for _, points := range [][]*pb.GameState_Coord{
+		nil,
+		{at(10, 0)},
+		{at(1, 1), at(1, 1)},
+		{at(1, 1), at(0, 0)},
+		{at(1, 1), at(100, 0)},
+		{at(1, 1), at(-2147483648, 0)},
+		{at(1, 1), at(9, 0), at(0, 9), at(-9, 0), at(0, -9), at(9, 0), at(0, 9), at(-9, 0), at(0, -9), at(9, 0), at(0, 9), at(-9, 0), at(0, -9)},
+	} {
+		snake := &pb.GameState_Snake{PlayerId: proto.Int32(1), Points: points}
+		if err := DecodeSnakes([]*pb.GameState_Snake{snake}, config); err == nil {
+			t.Errorf("points %v accepted", points)
+		}
+	}
+
+	snake := &pb.GameState_Snake{PlayerId: proto.Int32(1), Points: []*pb.GameState_Coord{at(1, 1)}}
+	if err := DecodeSnakes([]*pb.GameState_Snake{snake}, nil); err == nil {
+		t.Error("snake decoded without config")
+	}
+}
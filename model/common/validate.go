@@ -0,0 +1,136 @@
+package common
+
+import (
+	pb "SnakeGame/model/proto"
+	"fmt"
+)
+
+// Допустимые параметры игры из описания GameConfig
+const (
+	MinFieldSize    = 10
+	MaxFieldSize    = 100
+	MinFoodStatic   = 0
+	MaxFoodStatic   = 100
+	MinStateDelayMs = 100
+	MaxStateDelayMs = 3000
+	// MaxPortals столько пар порталов можно записать в файл карты
+	MaxPortals = 36
+)
+
+// ValidateConfig проверка параметров игры: из формы хоста и из анонса чужой игры
+func ValidateConfig(config *pb.GameConfig) error {
+	if config == nil {
+		return fmt.Errorf("missing game config")
+	}
+	for _, field := range []struct {
+		name     string
+		value    int32
+		min, max int32
+	}{
+		{"width", config.GetWidth(), MinFieldSize, MaxFieldSize},
+		{"height", config.GetHeight(), MinFieldSize, MaxFieldSize},
+		{"food_static", config.GetFoodStatic(), MinFoodStatic, MaxFoodStatic},
+		{"state_delay_ms", config.GetStateDelayMs(), MinStateDelayMs, MaxStateDelayMs},
+	} {
+		if field.value < field.min || field.value > field.max {
+			return fmt.Errorf("%s must be between %d and %d, got %d", field.name, field.min, field.max, field.value)
+		}
+	}
+
+	// клетки карты - по биту на клетку поля, пустой набор - клеток нет
+	cellsLen := int(config.GetWidth()*config.GetHeight()+7) / 8
+	for _, cells := range []struct {
+		name string
+		bits []byte
+	}{
+		{"obstacles", config.GetGameMap().GetObstacles()},
+		{"spawn_zones", config.GetGameMap().GetSpawnZones()},
+	} {
+		if len(cells.bits) != 0 && len(cells.bits) != cellsLen {
+			return fmt.Errorf("map %s must be %d bytes for a %dx%d field, got %d",
+				cells.name, cellsLen, config.GetWidth(), config.GetHeight(), len(cells.bits))
+		}
+	}
+
+	// клетка принадлежит не больше чем одному порталу, концы портала разные
+	// и не на препятствиях: иначе змея, вошедшая в портал, сразу погибает
+	portals := config.GetGameMap().GetPortals()
+	if len(portals) > MaxPortals {
+		return fmt.Errorf("map has %d portals, at most %d allowed", len(portals), MaxPortals)
+	}
+	obstacles := config.GetGameMap().GetObstacles()
+	used := make(map[[2]int32]bool)
+	for _, portal := range portals {
+		if portal.GetA().GetX() == portal.GetB().GetX() && portal.GetA().GetY() == portal.GetB().GetY() {
+			return fmt.Errorf("portal leads to its own cell %v", portal.GetA())
+		}
+		for _, end := range []*pb.GameState_Coord{portal.GetA(), portal.GetB()} {
+			if !inField(end, config) {
+				return fmt.Errorf("portal outside the field at %v", end)
+			}
+			if i := end.GetY()*config.GetWidth() + end.GetX(); len(obstacles) != 0 && obstacles[i/8]&(1<<(i%8)) != 0 {
+				return fmt.Errorf("portal on an obstacle at %v", end)
+			}
+			cell := [2]int32{end.GetX(), end.GetY()}
+			if used[cell] {
+				return fmt.Errorf("cell %v belongs to more than one portal", end)
+			}
+			used[cell] = true
+		}
+	}
+	return nil
+}
+
+// ValidateState проверка состояния, полученного по сети, перед тем как его принять:
+// с ним можно продолжить игру и отрисовать поле. config может быть nil, тогда координаты не проверяются
+func ValidateState(state *pb.GameState, config *pb.GameConfig) error {
+	if state == nil {
+		return fmt.Errorf("missing state")
+	}
+	if state.GetPlayers() == nil {
+		return fmt.Errorf("missing player list")
+	}
+
+	ids := make(map[int32]bool)
+	for _, player := range state.GetPlayers().GetPlayers() {
+		if ids[player.GetId()] {
+			return fmt.Errorf("duplicate player ID %d", player.GetId())
+		}
+		ids[player.GetId()] = true
+	}
+
+	snakes := make(map[int32]bool)
+	for _, snake := range state.GetSnakes() {
+		if snakes[snake.GetPlayerId()] {
+			return fmt.Errorf("duplicate snake for player ID %d", snake.GetPlayerId())
+		}
+		snakes[snake.GetPlayerId()] = true
+
+		if len(snake.GetPoints()) == 0 {
+			return fmt.Errorf("snake of player ID %d has no points", snake.GetPlayerId())
+		}
+		if _, ok := pb.Direction_name[int32(snake.GetHeadDirection())]; !ok {
+			return fmt.Errorf("snake of player ID %d has invalid direction %d", snake.GetPlayerId(), snake.GetHeadDirection())
+		}
+		for _, point := range snake.GetPoints() {
+			if !inField(point, config) {
+				return fmt.Errorf("snake of player ID %d is outside the field at %v", snake.GetPlayerId(), point)
+			}
+		}
+	}
+
+	for _, food := range state.GetFoods() {
+		if !inField(food, config) {
+			return fmt.Errorf("food outside the field at %v", food)
+		}
+	}
+	return nil
+}
+
+func inField(point *pb.GameState_Coord, config *pb.GameConfig) bool {
+	if config == nil {
+		return true
+	}
+	return point.GetX() >= 0 && point.GetX() < config.GetWidth() &&
+		point.GetY() >= 0 && point.GetY() < config.GetHeight()
+}
@@ -0,0 +1,113 @@
+package common
+
+import (
+	pb "SnakeGame/model/proto"
+	"google.golang.org/protobuf/proto"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	config := func(width, height, food, delay int32) *pb.GameConfig {
+		return &pb.GameConfig{
+			Width:        proto.Int32(width),
+			Height:       proto.Int32(height),
+			FoodStatic:   proto.Int32(food),
+			StateDelayMs: proto.Int32(delay),
+		}
+	}
+	withMap := func(obstacles, spawnZones int) *pb.GameConfig {
+		c := config(20, 10, 1, 1000)
+		c.GameMap = &pb.GameMap{Obstacles: make([]byte, obstacles), SpawnZones: make([]byte, spawnZones)}
+		return c
+	}
+	portal := func(ax, ay, bx, by int32) *pb.Portal {
+		return &pb.Portal{
+			A: &pb.GameState_Coord{X: proto.Int32(ax), Y: proto.Int32(ay)},
+			B: &pb.GameState_Coord{X: proto.Int32(bx), Y: proto.Int32(by)},
+		}
+	}
+	withPortals := func(portals ...*pb.Portal) *pb.GameConfig {
+		c := config(20, 10, 1, 1000)
+		c.GameMap = &pb.GameMap{Portals: portals}
+		return c
+	}
+	// препятствие в клетке (7, 2)
+	onObstacles := func(portals ...*pb.Portal) *pb.GameConfig {
+		c := withPortals(portals...)
+		c.GameMap.Obstacles = make([]byte, 25)
+		c.GameMap.Obstacles[(2*20+7)/8] = 1 << ((2*20 + 7) % 8)
+		return c
+	}
+	tests := []struct {
+		config *pb.GameConfig
+		valid  bool
+	}{
+		{withMap(25, 25), true},
+		{withMap(0, 25), true},
+		{withMap(24, 0), false},
+		{withMap(25, 26), false},
+		{withPortals(portal(0, 0, 19, 9), portal(5, 5, 6, 5)), true},
+		{withPortals(portal(0, 0, 20, 0)), false},
+		{withPortals(portal(3, 3, 3, 3)), false},
+		{withPortals(portal(1, 1, 2, 2), portal(2, 2, 3, 3)), false},
+		{onObstacles(portal(6, 2, 8, 2)), true},
+		{onObstacles(portal(0, 0, 7, 2)), false},
+		{onObstacles(portal(7, 2, 0, 0)), false},
+		{config(10, 100, 0, 100), true},
+		{config(100, 10, 100, 3000), true},
+		// незаданные поля берут значения по умолчанию из описания
+		{&pb.GameConfig{}, true},
+		{config(9, 20, 1, 1000), false},
+		{config(20, 101, 1, 1000), false},
+		{config(20, 20, -1, 1000), false},
+		{config(20, 20, 101, 1000), false},
+		{config(20, 20, 1, 99), false},
+		{config(20, 20, 1, 3001), false},
+		{config(0, 0, 0, 0), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if err := ValidateConfig(tt.config); (err == nil) != tt.valid {
+			t.Errorf("ValidateConfig(%v) = %v, want valid %v", tt.config, err, tt.valid)
+		}
+	}
+}
+
+func TestValidateState(t *testing.T) {
+	config := &pb.GameConfig{Width: proto.Int32(10), Height: proto.Int32(10)}
+	snake := func(id int32, state pb.GameState_Snake_SnakeState, x int32) *pb.GameState_Snake {
+		return &pb.GameState_Snake{
+			PlayerId:      proto.Int32(id),
+			State:         state.Enum(),
+			HeadDirection: pb.Direction_UP.Enum(),
+			Points:        []*pb.GameState_Coord{{X: proto.Int32(x), Y: proto.Int32(5)}},
+		}
+	}
+	players := &pb.GamePlayers{Players: []*pb.GamePlayer{
+		{Name: proto.String("master"), Id: proto.Int32(1), Role: pb.NodeRole_MASTER.Enum(), Score: proto.Int32(0)},
+		{Name: proto.String("viewer"), Id: proto.Int32(2), Role: pb.NodeRole_VIEWER.Enum(), Score: proto.Int32(0)},
+	}}
+	// змея зрителя и змея ушедшего игрока допустимы: их оставляют игроки,
+	// ставшие зрителями, и мастер, передавший игру, - интерфейс должен их отрисовать
+	state := &pb.GameState{
+		StateOrder: proto.Int32(1),
+		Players:    players,
+		Snakes: []*pb.GameState_Snake{
+			snake(1, pb.GameState_Snake_ALIVE, 1),
+			snake(2, pb.GameState_Snake_ZOMBIE, 3),
+			snake(7, pb.GameState_Snake_ZOMBIE, 5),
+		},
+	}
+	if err := ValidateState(state, config); err != nil {
+		t.Fatalf("state with zombie snakes rejected: %v", err)
+	}
+
+	state.Snakes = append(state.Snakes, snake(2, pb.GameState_Snake_ALIVE, 7))
+	if ValidateState(state, config) == nil {
+		t.Error("duplicate snake accepted")
+	}
+	state.Snakes = []*pb.GameState_Snake{snake(1, pb.GameState_Snake_ALIVE, 10)}
+	if ValidateState(state, config) == nil {
+		t.Error("snake outside the field accepted")
+	}
+}
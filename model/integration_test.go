@@ -0,0 +1,1483 @@
+package model_test
+
+import (
+	"SnakeGame/capture"
+	"SnakeGame/connection"
+	"SnakeGame/metrics"
+	"SnakeGame/model/common"
+	"SnakeGame/model/maps"
+	"SnakeGame/model/master"
+	"SnakeGame/model/player"
+	pb "SnakeGame/model/proto"
+	"SnakeGame/relay"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testStateDelayMs    = 200
+	waitTimeout         = 5 * time.Second
+	testMulticastGroup6 = "[ff02::114]:9192"
+)
+
+// testNet игра целиком в одном процессе поверх сети в памяти
+type testNet struct {
+	t       *testing.T
+	network *connection.MemNetwork
+	ipv6    bool
+}
+
+func newTestNet(t *testing.T) *testNet {
+	t.Parallel()
+	return &testNet{t: t, network: connection.NewMemNetwork()}
+}
+
+// newTestNet6 та же игра, но узлы получают IPv6-адреса и ищут игры на IPv6-группе
+func newTestNet6(t *testing.T) *testNet {
+	n := newTestNet(t)
+	n.ipv6 = true
+	return n
+}
+
+func (n *testNet) group() string {
+	if n.ipv6 {
+		return testMulticastGroup6
+	}
+	return connection.MulticastGroup()
+}
+
+func (n *testNet) newHost() string {
+	if n.ipv6 {
+		return n.network.NewHost6()
+	}
+	return n.network.NewHost()
+}
+
+func testConfig(width, height int32) *pb.GameConfig {
+	return &pb.GameConfig{
+		Width:        proto.Int32(width),
+		Height:       proto.Int32(height),
+		FoodStatic:   proto.Int32(0),
+		StateDelayMs: proto.Int32(testStateDelayMs),
+	}
+}
+
+func (n *testNet) sockets() (connection.Conn, connection.Conn) {
+	n.t.Helper()
+	return n.socketsOn(n.newHost())
+}
+
+// socketsOn сокеты узла с адресом host, например второй клиент на той же машине
+func (n *testNet) socketsOn(host string) (connection.Conn, connection.Conn) {
+	n.t.Helper()
+	multicastConn, err := n.network.ListenMulticast(n.group())
+	if err != nil {
+		n.t.Fatal(err)
+	}
+	unicastConn, err := n.network.ListenUDP(host, 0)
+	if err != nil {
+		n.t.Fatal(err)
+	}
+	n.t.Cleanup(func() { multicastConn.Close() })
+	return multicastConn, unicastConn
+}
+
+func (n *testNet) startMaster(config *pb.GameConfig) *master.Master {
+	n.t.Helper()
+	return n.startMasterWith(config, rows)
+}
+
+// startMasterWith мастер, змеи которого появляются там, куда их ставит spawner
+func (n *testNet) startMasterWith(config *pb.GameConfig, spawner master.Spawner) *master.Master {
+	n.t.Helper()
+	multicastConn, unicastConn := n.sockets()
+	m, err := master.NewMasterWithSpawner(multicastConn, unicastConn, config, spawner)
+	if err != nil {
+		n.t.Fatal(err)
+	}
+	m.Node.MulticastAddress = n.group()
+	m.Start()
+	n.t.Cleanup(m.Stop)
+	return m
+}
+
+// rows ставит змей в строки 1, 4, 7... головой вправо: змеи в разных строках не сталкиваются,
+// пока тест сам их не повернёт. Если строк не хватило, змея встаёт на любое свободное место
+func rows(config *pb.GameConfig, fits func(center *pb.GameState_Coord) bool) (*pb.GameState_Coord, pb.Direction, bool) {
+	for _, step := range []int32{3, 1} {
+		for y := int32(1); y < config.GetHeight(); y += step {
+			for x := int32(0); x < config.GetWidth(); x++ {
+				if center := coord(x, y); fits(center) {
+					return center, pb.Direction_RIGHT, true
+				}
+			}
+		}
+	}
+	return nil, 0, false
+}
+
+// inRow первое свободное место в строке y, начиная со столбца from и дальше вправо по кругу
+func inRow(config *pb.GameConfig, fits func(center *pb.GameState_Coord) bool, from, y int32) (*pb.GameState_Coord, bool) {
+	for i := int32(0); i < config.GetWidth(); i++ {
+		if center := coord((from+i)%config.GetWidth(), y); fits(center) {
+			return center, true
+		}
+	}
+	return nil, false
+}
+
+func (n *testNet) newPlayer() *player.Player {
+	n.t.Helper()
+	return n.newPlayerOn(n.newHost())
+}
+
+func (n *testNet) newPlayerOn(host string) *player.Player {
+	n.t.Helper()
+	multicastConn, unicastConn := n.socketsOn(host)
+	p, err := player.NewPlayer(multicastConn, unicastConn)
+	if err != nil {
+		n.t.Fatal(err)
+	}
+	p.Node.MulticastAddress = n.group()
+	go p.ReceiveMulticastMessages()
+	n.t.Cleanup(p.Stop)
+	return p
+}
+
+// discover ждёт анонса игры и возвращает её
+func (n *testNet) discover(p *player.Player) *player.DiscoveredGame {
+	n.t.Helper()
+	var game *player.DiscoveredGame
+	waitFor(n.t, "game discovery", func() bool {
+		p.Node.Mu.Lock()
+		defer p.Node.Mu.Unlock()
+		if len(p.DiscoveredGames) == 0 {
+			return false
+		}
+		game = &p.DiscoveredGames[0]
+		return true
+	})
+	return game
+}
+
+// join новый игрок находит игру и присоединяется к ней
+func (n *testNet) join(name string) *player.Player {
+	n.t.Helper()
+	p := n.newPlayer()
+	p.JoinGame(name, n.discover(p))
+	waitFor(n.t, name+" joining", func() bool {
+		p.Node.Mu.Lock()
+		defer p.Node.Mu.Unlock()
+		return p.Node.PlayerInfo.GetId() > 0 && p.Node.State != nil
+	})
+	return p
+}
+
+// waitFor опрашивает условие, пока оно не выполнится
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(waitTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// withState выполняет f над состоянием узла под его мьютексом
+func withState(node *common.Node, f func(state *pb.GameState)) {
+	node.Mu.Lock()
+	defer node.Mu.Unlock()
+	f(node.State)
+}
+
+func playerId(p *player.Player) int32 {
+	p.Node.Mu.Lock()
+	defer p.Node.Mu.Unlock()
+	return p.Node.PlayerInfo.GetId()
+}
+
+func findSnake(state *pb.GameState, id int32) *pb.GameState_Snake {
+	for _, snake := range state.GetSnakes() {
+		if snake.GetPlayerId() == id {
+			return snake
+		}
+	}
+	return nil
+}
+
+func findPlayer(state *pb.GameState, id int32) *pb.GamePlayer {
+	for _, gamePlayer := range state.GetPlayers().GetPlayers() {
+		if gamePlayer.GetId() == id {
+			return gamePlayer
+		}
+	}
+	return nil
+}
+
+func coord(x, y int32) *pb.GameState_Coord {
+	return &pb.GameState_Coord{X: proto.Int32(x), Y: proto.Int32(y)}
+}
+
+// receive ждёт от сокета сообщение, для которого match вернёт true
+func receive(t *testing.T, conn connection.Conn, match func(msg *pb.GameMessage) bool) *pb.GameMessage {
+	t.Helper()
+	received := make(chan *pb.GameMessage, 1)
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, _, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			var msg pb.GameMessage
+			if proto.Unmarshal(buf[:n], &msg) == nil && match(&msg) {
+				received <- &msg
+				return
+			}
+		}
+	}()
+	select {
+	case msg := <-received:
+		return msg
+	case <-time.After(waitTimeout):
+		t.Fatal("timed out waiting for message")
+		return nil
+	}
+}
+
+func TestDiscoveryAndJoin(t *testing.T) {
+	n := newTestNet(t)
+	m := n.startMaster(testConfig(20, 20))
+
+	p := n.newPlayer()
+	game := n.discover(p)
+	if game.GameName != "Game1" || game.Config.GetWidth() != 20 || !game.CanJoin {
+		t.Fatalf("unexpected announcement: %+v", game)
+	}
+
+	p.JoinGame("alice", game)
+	waitFor(t, "player in master state", func() bool {
+		found := false
+		withState(m.Node, func(state *pb.GameState) {
+			gamePlayer := findPlayer(state, 2)
+			found = gamePlayer.GetName() == "alice" && findSnake(state, 2) != nil
+		})
+		return found
+	})
+	waitFor(t, "state on player", func() bool {
+		received := false
+		withState(p.Node, func(state *pb.GameState) {
+			received = findSnake(state, 2) != nil
+		})
+		return received
+	})
+	if id := playerId(p); id != 2 {
+		t.Fatalf("player got ID %d, want 2", id)
+	}
+}
+
+func TestIPv6Game(t *testing.T) {
+	n := newTestNet6(t)
+	m := n.startMaster(testConfig(30, 30))
+	alice := n.join("alice")
+	bob := n.join("bob")
+	bobId := playerId(bob)
+
+	withState(m.Node, func(state *pb.GameState) {
+		for _, gamePlayer := range state.GetPlayers().GetPlayers() {
+			if gamePlayer.GetRole() == pb.NodeRole_MASTER {
+				continue
+			}
+			if ip := net.ParseIP(gamePlayer.GetIpAddress()); ip == nil || ip.To4() != nil {
+				t.Errorf("player %d has address %q, want IPv6", gamePlayer.GetId(), gamePlayer.GetIpAddress())
+			}
+		}
+	})
+
+	// смена мастера тоже идёт по IPv6-адресам из списка игроков
+	waitFor(t, "alice to become deputy", func() bool {
+		alice.Node.Mu.Lock()
+		defer alice.Node.Mu.Unlock()
+		return alice.Node.PlayerInfo.GetRole() == pb.NodeRole_DEPUTY
+	})
+	m.Stop()
+	waitFor(t, "bob to follow alice", func() bool {
+		bob.Node.Mu.Lock()
+		defer bob.Node.Mu.Unlock()
+		return bob.MasterAddr.Port == int(alice.Node.PlayerInfo.GetPort())
+	})
+	bob.Steer(pb.Direction_DOWN)
+	waitFor(t, "bob steering through alice", func() bool {
+		turned := false
+		withState(alice.Node, func(state *pb.GameState) {
+			turned = findSnake(state, bobId).GetHeadDirection() == pb.Direction_DOWN
+		})
+		return turned
+	})
+}
+
+func TestCapabilityNegotiation(t *testing.T) {
+	n := newTestNet(t)
+	m := n.startMaster(testConfig(20, 20))
+
+	p := n.newPlayer()
+	game := n.discover(p)
+	if game.ProtocolVersion != common.ProtocolVersion || len(game.MissingCapabilities) != 0 {
+		t.Fatalf("unexpected announcement: version %d, missing %v", game.ProtocolVersion, game.MissingCapabilities)
+	}
+
+	// узел по исходному snakes.proto не знает о версиях и возможностях
+	conn, err := n.network.ListenUDP(n.newHost(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	join := &pb.GameMessage{
+		MsgSeq: proto.Int64(1),
+		Type: &pb.GameMessage_Join{Join: &pb.GameMessage_JoinMsg{
+			PlayerName:    proto.String("legacy"),
+			GameName:      proto.String("Game1"),
+			RequestedRole: pb.NodeRole_NORMAL.Enum(),
+		}},
+	}
+	data, err := proto.Marshal(join)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.WriteToUDP(data, m.Node.UnicastConn.LocalAddr().(*net.UDPAddr)); err != nil {
+		t.Fatal(err)
+	}
+
+	ack := receive(t, conn, func(msg *pb.GameMessage) bool {
+		return msg.GetAck() != nil && msg.GetMsgSeq() == 1
+	})
+	if ack.GetReceiverId() <= 0 {
+		t.Fatalf("legacy join was not accepted: %v", ack)
+	}
+	if ack.GetAck().GetProtocolVersion() != common.ProtocolVersion || len(ack.GetAck().GetCapabilities()) != 0 {
+		t.Fatalf("legacy peer must get no capabilities: %v", ack.GetAck())
+	}
+}
+
+func TestSolidWallsRequireCapability(t *testing.T) {
+	n := newTestNet(t)
+	config := testConfig(20, 20)
+	config.SolidWalls = proto.Bool(true)
+	m := n.startMaster(config)
+
+	p := n.newPlayer()
+	game := n.discover(p)
+	if !game.Config.GetSolidWalls() || len(game.MissingCapabilities) != 0 {
+		t.Fatalf("unexpected walled game: %v, missing %v", game.Config, game.MissingCapabilities)
+	}
+	n.join("alice")
+
+	// узел без SOLID_WALLS считал бы поле склеенным по краям
+	conn, err := n.network.ListenUDP(n.newHost(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	join := &pb.GameMessage{
+		MsgSeq: proto.Int64(1),
+		Type: &pb.GameMessage_Join{Join: &pb.GameMessage_JoinMsg{
+			PlayerName:    proto.String("legacy"),
+			GameName:      proto.String("Game1"),
+			RequestedRole: pb.NodeRole_NORMAL.Enum(),
+			Capabilities:  []pb.Capability{pb.Capability_SESSIONS},
+		}},
+	}
+	data, err := proto.Marshal(join)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.WriteToUDP(data, m.Node.UnicastConn.LocalAddr().(*net.UDPAddr)); err != nil {
+		t.Fatal(err)
+	}
+	receive(t, conn, func(msg *pb.GameMessage) bool {
+		return msg.GetError() != nil
+	})
+}
+
+func TestMapLayoutReachesPlayers(t *testing.T) {
+	n := newTestNet(t)
+	config := testConfig(20, 20)
+	config.GameMap = &pb.GameMap{Name: proto.String("row"), Obstacles: maps.NewCells(20, 20)}
+	// rows ставит змей в строки 1, 4, 7...: препятствия им не мешают
+	for x := int32(5); x < 15; x++ {
+		maps.SetCell(config.GameMap.Obstacles, 20, x, 2)
+	}
+	n.startMaster(config)
+
+	p := n.join("alice")
+	p.Node.Mu.Lock()
+	defer p.Node.Mu.Unlock()
+	if !common.HasCapability(p.Capabilities, pb.Capability_OBSTACLES) {
+		t.Fatalf("OBSTACLES not negotiated: %v", p.Capabilities)
+	}
+	for x := int32(0); x < 20; x++ {
+		if want := x >= 5 && x < 15; maps.Obstacle(p.Node.Config, x, 2) != want {
+			t.Fatalf("player sees obstacle at (%d, 2) = %v", x, !want)
+		}
+	}
+}
+
+func TestSteering(t *testing.T) {
+	n := newTestNet(t)
+	m := n.startMaster(testConfig(20, 20))
+	p := n.join("alice")
+
+	p.Steer(pb.Direction_DOWN)
+	waitFor(t, "snake turning down", func() bool {
+		turned := false
+		withState(m.Node, func(state *pb.GameState) {
+			turned = findSnake(state, playerId(p)).GetHeadDirection() == pb.Direction_DOWN
+		})
+		return turned
+	})
+}
+
+func TestFoodAndScoring(t *testing.T) {
+	n := newTestNet(t)
+	config := testConfig(20, 20)
+	config.FoodStatic = proto.Int32(20)
+	config.StateDelayMs = proto.Int32(100)
+	m := n.startMaster(config)
+	p := n.join("alice")
+	id := playerId(p)
+
+	// змея мастера идёт вправо по строке 1, змея alice - вправо по строке ниже. Над едой alice
+	// поворачивает вниз, а если еды под ней уже нет - снова вправо, так что строку мастера она не пересекает
+	steered := pb.Direction_RIGHT
+	waitFor(t, "score on master", func() bool {
+		scored := false
+		direction := steered
+		withState(m.Node, func(state *pb.GameState) {
+			snake := findSnake(state, id)
+			scored = findPlayer(state, id).GetScore() >= 1 && len(snake.GetPoints()) >= 3
+			head := snake.GetPoints()[0]
+			direction = pb.Direction_RIGHT
+			for _, food := range state.GetFoods() {
+				if food.GetX() == head.GetX() && food.GetY() > head.GetY() {
+					direction = pb.Direction_DOWN
+				}
+			}
+		})
+		if !scored && direction != steered {
+			p.Steer(direction)
+			steered = direction
+		}
+		return scored
+	})
+	waitFor(t, "score on player", func() bool {
+		scored := false
+		withState(p.Node, func(state *pb.GameState) {
+			scored = findPlayer(state, id).GetScore() >= 1
+		})
+		return scored
+	})
+}
+
+func TestHeadOnCollision(t *testing.T) {
+	n := newTestNet(t)
+	// мастер в строке 1, alice и bob в одной строке ниже, bob перед alice головой к ней:
+	// на склеенном поле их головы обязательно встретятся
+	spawned := 0
+	var aliceCenter *pb.GameState_Coord
+	m := n.startMasterWith(testConfig(20, 20), func(config *pb.GameConfig, fits func(center *pb.GameState_Coord) bool) (*pb.GameState_Coord, pb.Direction, bool) {
+		spawned++
+		switch spawned {
+		case 1:
+			return rows(config, fits)
+		case 2:
+			for y := int32(10); y < config.GetHeight(); y++ {
+				if center, ok := inRow(config, fits, 0, y); ok {
+					aliceCenter = center
+					return center, pb.Direction_RIGHT, true
+				}
+			}
+		default:
+			if center, ok := inRow(config, fits, aliceCenter.GetX()+1, aliceCenter.GetY()); ok {
+				return center, pb.Direction_LEFT, true
+			}
+		}
+		return nil, 0, false
+	})
+	alice := n.join("alice")
+	bob := n.join("bob")
+	aliceId, bobId := playerId(alice), playerId(bob)
+
+	waitFor(t, "both snakes to die", func() bool {
+		dead := false
+		withState(m.Node, func(state *pb.GameState) {
+			dead = findSnake(state, aliceId) == nil && findSnake(state, bobId) == nil
+		})
+		return dead
+	})
+}
+
+func TestFullFieldRejectsJoin(t *testing.T) {
+	n := newTestNet(t)
+	config := testConfig(10, 10)
+	config.StateDelayMs = proto.Int32(1000)
+	// препятствия везде, кроме квадрата 5x5 в углу: в нём встаёт змея мастера, и другого места нет.
+	// Упереться в препятствие она успевает только за три хода
+	obstacles := maps.NewCells(10, 10)
+	for x := int32(0); x < 10; x++ {
+		for y := int32(0); y < 10; y++ {
+			if x >= 5 || y >= 5 {
+				maps.SetCell(obstacles, 10, x, y)
+			}
+		}
+	}
+	config.GameMap = &pb.GameMap{Obstacles: obstacles}
+	m := n.startMaster(config)
+
+	p := n.newPlayer()
+	p.JoinGame("alice", n.discover(p))
+	waitFor(t, "join error", func() bool {
+		p.Node.Mu.Lock()
+		defer p.Node.Mu.Unlock()
+		return p.LastError == "Cannot join: no available space"
+	})
+
+	if id := playerId(p); id > 0 {
+		t.Fatalf("rejected player got ID %d", id)
+	}
+	withState(m.Node, func(state *pb.GameState) {
+		if len(state.GetPlayers().GetPlayers()) != 1 {
+			t.Fatalf("rejected player was added: %v", state.GetPlayers())
+		}
+	})
+}
+
+func TestDeputyAssignment(t *testing.T) {
+	n := newTestNet(t)
+	m := n.startMaster(testConfig(30, 30))
+	alice := n.join("alice")
+	bob := n.join("bob")
+
+	waitFor(t, "alice to become deputy", func() bool {
+		alice.Node.Mu.Lock()
+		defer alice.Node.Mu.Unlock()
+		return alice.Node.PlayerInfo.GetRole() == pb.NodeRole_DEPUTY
+	})
+	withState(m.Node, func(state *pb.GameState) {
+		if role := findPlayer(state, playerId(alice)).GetRole(); role != pb.NodeRole_DEPUTY {
+			t.Errorf("alice has role %v on master, want DEPUTY", role)
+		}
+		if role := findPlayer(state, playerId(bob)).GetRole(); role != pb.NodeRole_NORMAL {
+			t.Errorf("bob has role %v on master, want NORMAL", role)
+		}
+	})
+}
+
+func TestMasterCrashDeputyTakeover(t *testing.T) {
+	n := newTestNet(t)
+	m := n.startMaster(testConfig(30, 30))
+	alice := n.join("alice")
+	bob := n.join("bob")
+	aliceId, bobId := playerId(alice), playerId(bob)
+	waitFor(t, "alice to become deputy", func() bool {
+		alice.Node.Mu.Lock()
+		defer alice.Node.Mu.Unlock()
+		return alice.Node.PlayerInfo.GetRole() == pb.NodeRole_DEPUTY
+	})
+
+	m.Stop()
+
+	waitFor(t, "alice to take over", func() bool {
+		alice.Node.Mu.Lock()
+		defer alice.Node.Mu.Unlock()
+		return alice.Node.PlayerInfo.GetRole() == pb.NodeRole_MASTER
+	})
+	waitFor(t, "bob to follow the new master", func() bool {
+		bob.Node.Mu.Lock()
+		defer bob.Node.Mu.Unlock()
+		return bob.MasterAddr.Port == int(alice.Node.PlayerInfo.GetPort())
+	})
+
+	withState(alice.Node, func(state *pb.GameState) {
+		if findPlayer(state, 1) != nil {
+			t.Errorf("crashed master is still in the player list")
+		}
+		if findSnake(state, 1).GetState() != pb.GameState_Snake_ZOMBIE {
+			t.Errorf("crashed master's snake is %v, want ZOMBIE", findSnake(state, 1).GetState())
+		}
+	})
+
+	// игра продолжается: bob получает новые состояния и может рулить
+	var order int32
+	withState(bob.Node, func(state *pb.GameState) { order = state.GetStateOrder() })
+	waitFor(t, "new states from the new master", func() bool {
+		advanced := false
+		withState(bob.Node, func(state *pb.GameState) { advanced = state.GetStateOrder() > order+2 })
+		return advanced
+	})
+	bob.Steer(pb.Direction_DOWN)
+	waitFor(t, "bob steering through the new master", func() bool {
+		turned := false
+		withState(alice.Node, func(state *pb.GameState) {
+			turned = findSnake(state, bobId).GetHeadDirection() == pb.Direction_DOWN
+		})
+		return turned
+	})
+	withState(alice.Node, func(state *pb.GameState) {
+		if findSnake(state, aliceId) == nil {
+			t.Errorf("new master lost its own snake")
+		}
+	})
+}
+
+func TestOldMasterStepsDown(t *testing.T) {
+	n := newTestNet(t)
+	multicastConn, unicastConn := n.sockets()
+	lossy := connection.NewLossyConn(unicastConn, connection.LossProfile{}, connection.LossProfile{})
+	m, err := master.NewMasterWithSpawner(multicastConn, lossy, testConfig(30, 30), rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Node.MulticastAddress = n.group()
+	m.Start()
+	t.Cleanup(m.Stop)
+	alice := n.join("alice")
+	bob := n.join("bob")
+	waitFor(t, "alice to become deputy", func() bool {
+		alice.Node.Mu.Lock()
+		defer alice.Node.Mu.Unlock()
+		return alice.Node.PlayerInfo.GetRole() == pb.NodeRole_DEPUTY
+	})
+
+	// игроки перестают слышать мастера, хотя он жив и слышит их
+	lossy.SetProfile(connection.Outbound, connection.LossProfile{Loss: 1})
+	waitFor(t, "alice to take over", func() bool {
+		alice.Node.Mu.Lock()
+		defer alice.Node.Mu.Unlock()
+		return alice.Node.PlayerInfo.GetRole() == pb.NodeRole_MASTER
+	})
+	lossy.SetProfile(connection.Outbound, connection.LossProfile{})
+
+	// старый мастер узнаёт о новом и уступает игру
+	waitFor(t, "the old master to step down", m.Node.Closed)
+	withState(m.Node, func(*pb.GameState) {
+		if role := m.Node.PlayerInfo.GetRole(); role != pb.NodeRole_VIEWER {
+			t.Errorf("old master is %v, want VIEWER", role)
+		}
+	})
+
+	// и больше не объявляет игру, а новый мастер объявляет
+	listener, err := n.network.ListenMulticast(n.group())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	announcers := make(chan *net.UDPAddr, 100)
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			count, addr, err := listener.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			var msg pb.GameMessage
+			if proto.Unmarshal(buf[:count], &msg) == nil && msg.GetAnnouncement() != nil {
+				announcers <- addr
+			}
+		}
+	}()
+	// мастер объявляет игру раз в секунду
+	time.Sleep(2500 * time.Millisecond)
+	oldAddr := m.Node.UnicastConn.LocalAddr().(*net.UDPAddr)
+	newAnnounced := false
+	for len(announcers) > 0 {
+		addr := <-announcers
+		if addr.IP.Equal(oldAddr.IP) && addr.Port == oldAddr.Port {
+			t.Fatal("old master is still announcing the game")
+		}
+		newAnnounced = newAnnounced || addr.Port == int(alice.Node.PlayerInfo.GetPort())
+	}
+	if !newAnnounced {
+		t.Error("new master does not announce the game")
+	}
+
+	waitFor(t, "bob to follow the new master", func() bool {
+		bob.Node.Mu.Lock()
+		defer bob.Node.Mu.Unlock()
+		return bob.MasterAddr.Port == int(alice.Node.PlayerInfo.GetPort())
+	})
+}
+
+func TestPlayerLeavesToViewer(t *testing.T) {
+	n := newTestNet(t)
+	m := n.startMaster(testConfig(20, 20))
+	p := n.join("alice")
+	id := playerId(p)
+
+	p.BecomeViewer()
+
+	waitFor(t, "player to become viewer", func() bool {
+		left := false
+		withState(m.Node, func(state *pb.GameState) {
+			left = findPlayer(state, id).GetRole() == pb.NodeRole_VIEWER &&
+				findSnake(state, id).GetState() == pb.GameState_Snake_ZOMBIE
+		})
+		return left
+	})
+
+	// наблюдатель по-прежнему получает состояние игры
+	var order int32
+	withState(p.Node, func(state *pb.GameState) { order = state.GetStateOrder() })
+	waitFor(t, "states for the viewer", func() bool {
+		advanced := false
+		withState(p.Node, func(state *pb.GameState) { advanced = state.GetStateOrder() > order })
+		return advanced
+	})
+}
+
+func TestZombieKeepsMoving(t *testing.T) {
+	n := newTestNet(t)
+	m := n.startMaster(testConfig(20, 20))
+	p := n.join("alice")
+	id := playerId(p)
+
+	p.BecomeViewer()
+	var head *pb.GameState_Coord
+	waitFor(t, "snake to become zombie", func() bool {
+		withState(m.Node, func(state *pb.GameState) {
+			snake := findSnake(state, id)
+			if snake.GetState() == pb.GameState_Snake_ZOMBIE {
+				head = proto.Clone(snake.GetPoints()[0]).(*pb.GameState_Coord)
+			}
+		})
+		return head != nil
+	})
+
+	waitFor(t, "zombie to move", func() bool {
+		moved := false
+		withState(m.Node, func(state *pb.GameState) {
+			snake := findSnake(state, id)
+			moved = snake.GetState() == pb.GameState_Snake_ZOMBIE && !proto.Equal(snake.GetPoints()[0], head)
+		})
+		return moved
+	})
+}
+
+func TestReconnectAfterNetworkDrop(t *testing.T) {
+	n := newTestNet(t)
+	m := n.startMaster(testConfig(20, 20))
+	n.join("bob")
+
+	multicastConn, unicastConn := n.sockets()
+	lossy := connection.NewLossyConn(unicastConn, connection.LossProfile{}, connection.LossProfile{})
+	p, err := player.NewPlayer(multicastConn, lossy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go p.ReceiveMulticastMessages()
+	t.Cleanup(p.Stop)
+	p.JoinGame("alice", n.discover(p))
+	waitFor(t, "alice joining", func() bool { return playerId(p) > 0 && p.SessionToken() != nil })
+	id := playerId(p)
+
+	// связь у alice пропадает, мастер убирает её из игры
+	dropAll := connection.LossProfile{Loss: 1}
+	lossy.SetProfile(connection.Inbound, dropAll)
+	lossy.SetProfile(connection.Outbound, dropAll)
+	waitFor(t, "alice timing out", func() bool {
+		dropped := false
+		withState(m.Node, func(state *pb.GameState) {
+			dropped = findPlayer(state, id) == nil && findSnake(state, id).GetState() == pb.GameState_Snake_ZOMBIE
+		})
+		return dropped
+	})
+
+	lossy.SetProfile(connection.Inbound, connection.LossProfile{})
+	lossy.SetProfile(connection.Outbound, connection.LossProfile{})
+	waitFor(t, "alice resuming her snake", func() bool {
+		resumed := false
+		withState(m.Node, func(state *pb.GameState) {
+			resumed = findPlayer(state, id).GetRole() == pb.NodeRole_NORMAL &&
+				findSnake(state, id).GetState() == pb.GameState_Snake_ALIVE
+		})
+		return resumed
+	})
+	if got := playerId(p); got != id {
+		t.Fatalf("alice got ID %d after reconnect, want %d", got, id)
+	}
+}
+
+func TestResumeFromNewPort(t *testing.T) {
+	n := newTestNet(t)
+	m := n.startMaster(testConfig(20, 20))
+	p := n.join("alice")
+	id := playerId(p)
+	token := p.SessionToken()
+	withState(m.Node, func(state *pb.GameState) {
+		findPlayer(state, id).Score = proto.Int32(7)
+	})
+
+	p.Stop()
+	waitFor(t, "alice timing out", func() bool {
+		dropped := false
+		withState(m.Node, func(state *pb.GameState) {
+			dropped = findPlayer(state, id) == nil
+		})
+		return dropped
+	})
+
+	// игрок вернулся с другого порта
+	conn, err := n.network.ListenUDP(n.newHost(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	resume := func(conn connection.Conn, msgSeq int64, token []byte) *pb.GameMessage {
+		join := &pb.GameMessage{
+			MsgSeq: proto.Int64(msgSeq),
+			Type: &pb.GameMessage_Join{Join: &pb.GameMessage_JoinMsg{
+				PlayerName:    proto.String("alice"),
+				GameName:      proto.String("Game1"),
+				RequestedRole: pb.NodeRole_NORMAL.Enum(),
+				Capabilities:  common.SupportedCapabilities,
+				SessionToken:  token,
+			}},
+		}
+		data, err := proto.Marshal(join)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conn.WriteToUDP(data, m.Node.UnicastConn.LocalAddr().(*net.UDPAddr)); err != nil {
+			t.Fatal(err)
+		}
+		return receive(t, conn, func(msg *pb.GameMessage) bool {
+			return msg.GetMsgSeq() == msgSeq && (msg.GetAck() != nil || msg.GetError() != nil)
+		})
+	}
+
+	// номер новее всех сообщений, которые alice успела отправить
+	const resumeSeq = 1 << 20
+	ack := resume(conn, resumeSeq, token)
+	if ack.GetReceiverId() != id {
+		t.Fatalf("resumed with ID %d, want %d", ack.GetReceiverId(), id)
+	}
+	withState(m.Node, func(state *pb.GameState) {
+		gamePlayer := findPlayer(state, id)
+		if gamePlayer.GetScore() < 7 || gamePlayer.GetPort() != int32(conn.LocalAddr().(*net.UDPAddr).Port) {
+			t.Fatalf("unexpected resumed player: %v", gamePlayer)
+		}
+		if snake := findSnake(state, id); snake.GetState() != pb.GameState_Snake_ALIVE {
+			t.Fatalf("resumed snake is not alive: %v", snake)
+		}
+	})
+
+	// чужой токен даёт обычное присоединение под новым ID
+	stranger, err := n.network.ListenUDP(n.newHost(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stranger.Close()
+	if ack := resume(stranger, 1, []byte("forged")); ack.GetReceiverId() == id {
+		t.Fatalf("forged token resumed player %d", id)
+	}
+
+	// повтор перехваченного JoinMsg с токеном не уводит alice на адрес повторяющего
+	replayer, err := n.network.ListenUDP(n.newHost(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replayer.Close()
+	for _, seq := range []int64{resumeSeq, 1} {
+		if ack := resume(replayer, seq, token); ack.GetReceiverId() == id {
+			t.Fatalf("replayed join with msg_seq %d resumed player %d", seq, id)
+		}
+	}
+	withState(m.Node, func(state *pb.GameState) {
+		if port := findPlayer(state, id).GetPort(); port != int32(conn.LocalAddr().(*net.UDPAddr).Port) {
+			t.Fatalf("replayed join moved alice to port %d", port)
+		}
+	})
+}
+
+func TestFloodProtection(t *testing.T) {
+	n := newTestNet(t)
+	m := n.startMaster(testConfig(30, 30))
+	alice := n.join("alice")
+	aliceId := playerId(alice)
+	droppedSteers := metrics.DroppedMessages.Value("steer")
+
+	stranger, err := n.network.ListenUDP(n.newHost(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stranger.Close()
+	masterAddr := m.Node.UnicastConn.LocalAddr().(*net.UDPAddr)
+	flood := func(msg *pb.GameMessage, count int) {
+		for i := 0; i < count; i++ {
+			msg.MsgSeq = proto.Int64(int64(i + 1))
+			data, err := proto.Marshal(msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := stranger.WriteToUDP(data, masterAddr); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	announcements := make(chan struct{}, 100)
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, _, err := stranger.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			var msg pb.GameMessage
+			if proto.Unmarshal(buf[:n], &msg) == nil && msg.GetAnnouncement() != nil {
+				announcements <- struct{}{}
+			}
+		}
+	}()
+
+	// на поток DiscoverMsg мастер отвечает не больше, чем позволяет корзина неизвестного адреса
+	flood(&pb.GameMessage{Type: &pb.GameMessage_Discover{Discover: &pb.GameMessage_DiscoverMsg{}}}, 50)
+	// SteerMsg от неизвестного адреса не обрабатывается вовсе
+	flood(&pb.GameMessage{Type: &pb.GameMessage_Steer{Steer: &pb.GameMessage_SteerMsg{Direction: pb.Direction_UP.Enum()}}}, 50)
+
+	waitFor(t, "flood to be dropped", func() bool {
+		m.Node.Mu.Lock()
+		defer m.Node.Mu.Unlock()
+		dropped := m.DroppedMessages()
+		return dropped["discover"] >= 45 && dropped["steer"] == 50
+	})
+	if got := metrics.DroppedMessages.Value("steer") - droppedSteers; got != 50 {
+		t.Errorf("dropped_messages_total{type=\"steer\"} grew by %v, want 50", got)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if got := len(announcements); got == 0 || got > 5 {
+		t.Fatalf("master answered %d of 50 discovers", got)
+	}
+
+	// игроки флуда не замечают
+	alice.Steer(pb.Direction_DOWN)
+	waitFor(t, "alice steering during the flood", func() bool {
+		turned := false
+		withState(m.Node, func(state *pb.GameState) {
+			turned = findSnake(state, aliceId).GetHeadDirection() == pb.Direction_DOWN
+		})
+		return turned
+	})
+}
+
+func TestAdminAPI(t *testing.T) {
+	n := newTestNet(t)
+	m := n.startMaster(testConfig(30, 30))
+	alice := n.join("alice")
+	aliceId := playerId(alice)
+
+	if _, _, err := m.ServeAdmin("0.0.0.0:0"); err == nil {
+		t.Fatal("admin API accepted a non-loopback address")
+	}
+	addr, token, err := m.ServeAdmin("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := func(method, path, host, auth string) int {
+		t.Helper()
+		req, err := http.NewRequest(method, "http://"+addr.String()+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Host = host
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	// без токена, с чужим токеном или с чужим Host (DNS rebinding) ничего не меняется и не читается
+	_, port, _ := net.SplitHostPort(addr.String())
+	rejected := []struct {
+		host, auth string
+		status     int
+	}{
+		{addr.String(), "", http.StatusUnauthorized},
+		{addr.String(), "Bearer wrong", http.StatusUnauthorized},
+		{addr.String(), token, http.StatusUnauthorized},
+		{"attacker.example:" + port, "Bearer " + token, http.StatusForbidden},
+	}
+	for _, tt := range rejected {
+		for _, path := range []string{"/players", "/stop"} {
+			method := "GET"
+			if path == "/stop" {
+				method = "POST"
+			}
+			if status := request(method, path, tt.host, tt.auth); status != tt.status {
+				t.Fatalf("%s %s with host %q and auth %q returned %d, want %d", method, path, tt.host, tt.auth, status, tt.status)
+			}
+		}
+	}
+	if m.Node.Closed() {
+		t.Fatal("master stopped by a request without a token")
+	}
+	if status := request("GET", "/config", "localhost:"+port, "Bearer "+token); status != http.StatusOK {
+		t.Fatalf("request to localhost returned %d", status)
+	}
+
+	call := func(method, path string, out any) int {
+		t.Helper()
+		req, err := http.NewRequest(method, "http://"+addr.String()+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if out != nil {
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				t.Fatalf("%s %s: %v", method, path, err)
+			}
+		}
+		return resp.StatusCode
+	}
+
+	var players []struct {
+		Id     int32    `json:"id"`
+		Name   string   `json:"name"`
+		Role   string   `json:"role"`
+		PingMs *float64 `json:"ping_ms"`
+	}
+	waitFor(t, "alice's ping", func() bool {
+		call("GET", "/players", &players)
+		for _, p := range players {
+			if p.Id == aliceId && p.PingMs != nil {
+				return true
+			}
+		}
+		return false
+	})
+	if len(players) != 2 {
+		t.Fatalf("GET /players returned %d players", len(players))
+	}
+
+	var config map[string]any
+	call("GET", "/config", &config)
+	if config["width"] != 30.0 || config["state_delay_ms"] != float64(testStateDelayMs) {
+		t.Fatalf("unexpected config %v", config)
+	}
+	var state struct {
+		StateOrder int32 `json:"state_order"`
+		Snakes     []struct {
+			PlayerId int32 `json:"player_id"`
+			Points   []struct {
+				X int32 `json:"x"`
+				Y int32 `json:"y"`
+			} `json:"points"`
+		} `json:"snakes"`
+	}
+	call("GET", "/state", &state)
+	if len(state.Snakes) != 2 {
+		t.Fatalf("GET /state returned %d snakes", len(state.Snakes))
+	}
+
+	// на паузе состояние рассылается, но змеи стоят на месте
+	call("POST", "/pause", nil)
+	var head *pb.GameState_Coord
+	withState(m.Node, func(state *pb.GameState) {
+		head = proto.Clone(findSnake(state, aliceId).GetPoints()[0]).(*pb.GameState_Coord)
+	})
+	order := state.StateOrder
+	waitFor(t, "paused states", func() bool {
+		call("GET", "/state", &state)
+		return state.StateOrder > order+2
+	})
+	withState(m.Node, func(state *pb.GameState) {
+		if moved := findSnake(state, aliceId).GetPoints()[0]; !proto.Equal(moved, head) {
+			t.Fatalf("snake moved on pause from %v to %v", head, moved)
+		}
+	})
+	call("POST", "/resume", nil)
+	waitFor(t, "snake moving after resume", func() bool {
+		moved := false
+		withState(m.Node, func(state *pb.GameState) {
+			moved = !proto.Equal(findSnake(state, aliceId).GetPoints()[0], head)
+		})
+		return moved
+	})
+
+	if status := call("POST", "/players/1000/kick", nil); status != http.StatusNotFound {
+		t.Fatalf("kicking unknown player returned %d", status)
+	}
+	if status := call("POST", fmt.Sprintf("/players/%d/kick", aliceId), nil); status != http.StatusOK {
+		t.Fatalf("kick returned %d", status)
+	}
+	waitFor(t, "alice to be kicked", func() bool {
+		alice.Node.Mu.Lock()
+		defer alice.Node.Mu.Unlock()
+		return alice.Kicked
+	})
+	withState(m.Node, func(state *pb.GameState) {
+		if findPlayer(state, aliceId) != nil {
+			t.Fatal("kicked player is still in the game")
+		}
+	})
+
+	// бан на IP-адрес: с нового порта той же машины не вернуться, другим игрокам он не мешает
+	aliceHost := alice.Node.UnicastConn.LocalAddr().(*net.UDPAddr).IP.String()
+	again := n.newPlayerOn(aliceHost)
+	again.JoinGame("alice", n.discover(again))
+	waitFor(t, "rejoin to be rejected", func() bool {
+		again.Node.Mu.Lock()
+		defer again.Node.Mu.Unlock()
+		return again.LastError == "Cannot join: you have been kicked by the host"
+	})
+	n.join("bob")
+
+	call("POST", "/stop", nil)
+	waitFor(t, "master to stop", m.Node.Closed)
+}
+
+func TestMetrics(t *testing.T) {
+	n := newTestNet(t)
+	m := n.startMaster(testConfig(20, 20))
+	ticks := metrics.Ticks.Value()
+	timeouts := metrics.Timeouts.Value()
+	alice := n.join("alice")
+
+	waitFor(t, "ticks with a player", func() bool {
+		return metrics.Ticks.Value() > ticks+3 && metrics.Acks.Value() > 0
+	})
+	if metrics.MessagesSent.Value("state") == 0 || metrics.MessagesReceived.Value("join") == 0 {
+		t.Fatal("messages are not counted")
+	}
+	if metrics.TickDuration.Count() == 0 || metrics.StateSize.Value() == 0 {
+		t.Fatal("ticks are not measured")
+	}
+
+	var body strings.Builder
+	metrics.WriteText(&body)
+	for _, want := range []string{`snake_players{role="master"}`, `snake_snakes{state="alive"}`, `snake_messages_sent_total{type="state"}`} {
+		if !strings.Contains(body.String(), want) {
+			t.Errorf("metrics do not contain %s:\n%s", want, body.String())
+		}
+	}
+
+	// игрок пропал - мастер считает таймаут
+	aliceId := playerId(alice)
+	alice.Stop()
+	waitFor(t, "alice timing out", func() bool {
+		removed := false
+		withState(m.Node, func(state *pb.GameState) {
+			removed = findPlayer(state, aliceId) == nil
+		})
+		return removed
+	})
+	if metrics.Timeouts.Value() <= timeouts {
+		t.Fatal("timeout is not counted")
+	}
+}
+
+func TestTrafficMirror(t *testing.T) {
+	n := newTestNet(t)
+	captureConn, err := n.network.ListenUDP(n.newHost(), 9194)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer captureConn.Close()
+	out, err := n.network.ListenUDP(n.newHost(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	multicastConn, unicastConn := n.sockets()
+	m, err := master.NewMasterWithSpawner(multicastConn, unicastConn, testConfig(20, 20), rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Node.MirrorTo(out, captureConn.LocalAddr().(*net.UDPAddr))
+	m.Start()
+	t.Cleanup(m.Stop)
+	n.join("alice")
+
+	records := make(chan *pb.CaptureRecord, 100)
+	go func() {
+		buf := make([]byte, 65536)
+		for {
+			n, _, err := captureConn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			var record pb.CaptureRecord
+			if proto.Unmarshal(buf[:n], &record) == nil {
+				records <- &record
+			}
+		}
+	}()
+
+	// лента мастера: присоединение alice и подтверждённые ею состояния
+	var timelineOut strings.Builder
+	timeline := capture.NewTimeline(&timelineOut)
+	deadline := time.After(waitTimeout)
+	for !strings.Contains(timelineOut.String(), "acks join") || !strings.Contains(timelineOut.String(), "acks state") {
+		select {
+		case record := <-records:
+			timeline.Add(record)
+		case <-deadline:
+			t.Fatalf("mirrored traffic is incomplete:\n%s", timelineOut.String())
+		}
+	}
+}
+
+// rebindConn сокет, который можно подменить на ходу, как при смене сети или NAT
+type rebindConn struct {
+	mu    sync.Mutex
+	inner connection.Conn
+}
+
+func (c *rebindConn) current() connection.Conn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.inner
+}
+
+func (c *rebindConn) rebind(inner connection.Conn) {
+	c.mu.Lock()
+	old := c.inner
+	c.inner = inner
+	c.mu.Unlock()
+	old.Close()
+}
+
+func (c *rebindConn) ReadFromUDP(b []byte) (int, *net.UDPAddr, error) {
+	for {
+		inner := c.current()
+		n, addr, err := inner.ReadFromUDP(b)
+		if errors.Is(err, net.ErrClosed) && inner != c.current() {
+			continue
+		}
+		return n, addr, err
+	}
+}
+
+func (c *rebindConn) WriteToUDP(b []byte, addr *net.UDPAddr) (int, error) {
+	return c.current().WriteToUDP(b, addr)
+}
+
+func (c *rebindConn) LocalAddr() net.Addr {
+	return c.current().LocalAddr()
+}
+
+func (c *rebindConn) Close() error {
+	return c.current().Close()
+}
+
+func TestAddressMigration(t *testing.T) {
+	n := newTestNet(t)
+	m := n.startMaster(testConfig(20, 20))
+
+	multicastConn, unicastConn := n.sockets()
+	conn := &rebindConn{inner: unicastConn}
+	p, err := player.NewPlayer(multicastConn, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go p.ReceiveMulticastMessages()
+	t.Cleanup(p.Stop)
+	p.JoinGame("alice", n.discover(p))
+	waitFor(t, "alice joining", func() bool {
+		joined := false
+		withState(p.Node, func(state *pb.GameState) { joined = state != nil })
+		return joined && p.SessionToken() != nil
+	})
+	id := playerId(p)
+
+	// NAT выдал alice новый порт
+	host := unicastConn.LocalAddr().(*net.UDPAddr).IP.String()
+	newConn, err := n.network.ListenUDP(host, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.rebind(newConn)
+	newPort := int32(newConn.LocalAddr().(*net.UDPAddr).Port)
+
+	waitFor(t, "alice moving to the new port", func() bool {
+		moved := false
+		withState(m.Node, func(state *pb.GameState) {
+			moved = findPlayer(state, id).GetPort() == newPort
+		})
+		return moved
+	})
+	p.Steer(pb.Direction_DOWN)
+	waitFor(t, "steer from the new port", func() bool {
+		turned := false
+		withState(m.Node, func(state *pb.GameState) {
+			turned = findSnake(state, id).GetHeadDirection() == pb.Direction_DOWN
+		})
+		return turned
+	})
+
+	// старое подписанное сообщение с чужого адреса игрока не переносит
+	stranger, err := n.network.ListenUDP(n.newHost(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stranger.Close()
+	replay := &pb.GameMessage{
+		MsgSeq:   proto.Int64(1),
+		SenderId: proto.Int32(id),
+		Type:     &pb.GameMessage_Ping{Ping: &pb.GameMessage_PingMsg{}},
+	}
+	common.SignMessage(replay, p.SessionToken())
+	data, err := proto.Marshal(replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stranger.WriteToUDP(data, m.Node.UnicastConn.LocalAddr().(*net.UDPAddr)); err != nil {
+		t.Fatal(err)
+	}
+	receive(t, stranger, func(msg *pb.GameMessage) bool { return msg.GetAck() != nil })
+	withState(m.Node, func(state *pb.GameState) {
+		if port := findPlayer(state, id).GetPort(); port != newPort {
+			t.Fatalf("replayed message moved alice to port %d", port)
+		}
+	})
+}
+
+func TestElectionAfterMasterAndDeputyLoss(t *testing.T) {
+	n := newTestNet(t)
+	m := n.startMaster(testConfig(30, 30))
+	deputy := n.join("alice")
+	waitFor(t, "alice becoming deputy", func() bool {
+		isDeputy := false
+		withState(m.Node, func(state *pb.GameState) {
+			isDeputy = findPlayer(state, playerId(deputy)).GetRole() == pb.NodeRole_DEPUTY
+		})
+		return isDeputy
+	})
+	bob := n.join("bob")
+	carol := n.join("carol")
+	bobId, carolId := playerId(bob), playerId(carol)
+	waitFor(t, "carol seeing bob", func() bool {
+		seen := false
+		withState(carol.Node, func(state *pb.GameState) {
+			seen = findPlayer(state, bobId) != nil && findPlayer(state, carolId) != nil
+		})
+		return seen
+	})
+
+	// мастер и заместитель пропадают одновременно
+	m.Stop()
+	deputy.Stop()
+
+	waitFor(t, "bob winning the election", func() bool {
+		won := false
+		withState(bob.Node, func(*pb.GameState) {
+			won = bob.Node.Role == pb.NodeRole_MASTER
+		})
+		return won
+	})
+	waitFor(t, "carol following bob", func() bool {
+		following := false
+		withState(carol.Node, func(state *pb.GameState) {
+			following = findPlayer(state, bobId).GetRole() == pb.NodeRole_MASTER &&
+				findPlayer(state, playerId(deputy)) == nil
+		})
+		return following
+	})
+
+	carol.Steer(pb.Direction_DOWN)
+	waitFor(t, "carol steering under bob", func() bool {
+		turned := false
+		withState(bob.Node, func(state *pb.GameState) {
+			turned = findSnake(state, carolId).GetHeadDirection() == pb.Direction_DOWN
+		})
+		return turned
+	})
+}
+
+// relaySockets сокеты узла, которые ходят только через ретранслятор
+func (n *testNet) relaySockets(relayAddr *net.UDPAddr) (connection.Conn, connection.Conn) {
+	n.t.Helper()
+	host := n.newHost()
+	var conns []connection.Conn
+	for _, multicast := range []bool{true, false} {
+		inner, err := n.network.ListenUDP(host, 0)
+		if err != nil {
+			n.t.Fatal(err)
+		}
+		conn, err := connection.NewRelayConn(inner, relayAddr, multicast)
+		if err != nil {
+			n.t.Fatal(err)
+		}
+		n.t.Cleanup(func() { conn.Close() })
+		conns = append(conns, conn)
+	}
+	return conns[0], conns[1]
+}
+
+func TestRelay(t *testing.T) {
+	n := newTestNet(t)
+	relayConn, err := n.network.ListenUDP(n.newHost(), 9193)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := relay.NewServer(relayConn)
+	go server.Serve()
+	t.Cleanup(server.Close)
+	relayAddr := relayConn.LocalAddr().(*net.UDPAddr)
+
+	multicastConn, unicastConn := n.relaySockets(relayAddr)
+	m, err := master.NewMasterWithSpawner(multicastConn, unicastConn, testConfig(20, 20), rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Start()
+	t.Cleanup(m.Stop)
+
+	// у игрока нет ни multicast, ни прямой связи с мастером
+	multicastConn, unicastConn = n.relaySockets(relayAddr)
+	p, err := player.NewPlayer(multicastConn, unicastConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go p.ReceiveMulticastMessages()
+	t.Cleanup(p.Stop)
+
+	game := n.discover(p)
+	if game.MasterAddr.String() != m.Node.UnicastConn.LocalAddr().String() {
+		t.Fatalf("game announced from %v, want master at %v", game.MasterAddr, m.Node.UnicastConn.LocalAddr())
+	}
+	p.JoinGame("alice", game)
+	waitFor(t, "alice joining via relay", func() bool {
+		joined := false
+		withState(p.Node, func(state *pb.GameState) { joined = state != nil })
+		return joined && playerId(p) > 0
+	})
+	id := playerId(p)
+
+	p.Steer(pb.Direction_DOWN)
+	waitFor(t, "steer via relay", func() bool {
+		turned := false
+		withState(m.Node, func(state *pb.GameState) {
+			turned = findSnake(state, id).GetHeadDirection() == pb.Direction_DOWN
+		})
+		return turned
+	})
+}
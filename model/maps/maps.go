@@ -0,0 +1,216 @@
+package maps
+
+import (
+	"SnakeGame/model/common"
+	pb "SnakeGame/model/proto"
+	"encoding/json"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// переменная окружения с папкой карт
+const dirEnv = "SNAKE_MAPS"
+
+// Клетки поля в файле карты
+const (
+	emptyCell    = '.'
+	obstacleCell = '#'
+	spawnCell    = 'S'
+)
+
+// portalCells символы порталов: клетки с одинаковым символом - пара порталов
+const portalCells = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// file карта в JSON: поле задаётся строками одинаковой длины, по символу на клетку:
+// '.' - пустая клетка, '#' - препятствие, 'S' - место появления змеи, цифра или строчная
+// латинская буква - портал, каждый такой символ встречается ровно два раза
+type file struct {
+	Name       string   `json:"name"`
+	SolidWalls bool     `json:"solid_walls"`
+	Rows       []string `json:"rows"`
+}
+
+// Dir папка с картами из SNAKE_MAPS, по умолчанию maps в текущей папке
+func Dir() string {
+	if dir := os.Getenv(dirEnv); dir != "" {
+		return dir
+	}
+	return "maps"
+}
+
+// List файлы карт *.json в папке dir по алфавиту
+func List(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing maps: %w", err)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Load читает карту из файла. Карта без названия называется по имени файла
+func Load(path string) (*pb.GameConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading map: %w", err)
+	}
+	config, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("map %s: %w", filepath.Base(path), err)
+	}
+	if config.GameMap.GetName() == "" {
+		config.GameMap.Name = proto.String(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	}
+	return config, nil
+}
+
+// Parse параметры игры по карте в JSON: размер поля, стены и сама карта. Еду и скорость задаёт хост
+func Parse(data []byte) (*pb.GameConfig, error) {
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid map file: %w", err)
+	}
+
+	height := int32(len(f.Rows))
+	if height == 0 {
+		return nil, fmt.Errorf("map has no rows")
+	}
+	width := int32(len(f.Rows[0]))
+	if width < common.MinFieldSize || width > common.MaxFieldSize || height < common.MinFieldSize || height > common.MaxFieldSize {
+		return nil, fmt.Errorf("map size %dx%d is out of range %d..%d", width, height, common.MinFieldSize, common.MaxFieldSize)
+	}
+
+	obstacles := NewCells(width, height)
+	spawnZones := NewCells(width, height)
+	hasObstacles, hasSpawnZones := false, false
+	portalEnds := make(map[byte][]*pb.GameState_Coord)
+	for y, row := range f.Rows {
+		if int32(len(row)) != width {
+			return nil, fmt.Errorf("row %d has %d cells, want %d", y, len(row), width)
+		}
+		for x := 0; x < len(row); x++ {
+			switch row[x] {
+			case emptyCell:
+			case obstacleCell:
+				SetCell(obstacles, width, int32(x), int32(y))
+				hasObstacles = true
+			case spawnCell:
+				SetCell(spawnZones, width, int32(x), int32(y))
+				hasSpawnZones = true
+			default:
+				if strings.IndexByte(portalCells, row[x]) < 0 {
+					return nil, fmt.Errorf("unknown cell %q at (%d, %d)", row[x], x, y)
+				}
+				portalEnds[row[x]] = append(portalEnds[row[x]], &pb.GameState_Coord{X: proto.Int32(int32(x)), Y: proto.Int32(int32(y))})
+			}
+		}
+	}
+
+	gameMap := &pb.GameMap{}
+	if f.Name != "" {
+		gameMap.Name = proto.String(f.Name)
+	}
+	if hasObstacles {
+		gameMap.Obstacles = obstacles
+	}
+	if hasSpawnZones {
+		gameMap.SpawnZones = spawnZones
+	}
+	for i := 0; i < len(portalCells); i++ {
+		ends, ok := portalEnds[portalCells[i]]
+		if !ok {
+			continue
+		}
+		if len(ends) != 2 {
+			return nil, fmt.Errorf("portal %q has %d cells, want 2", portalCells[i], len(ends))
+		}
+		gameMap.Portals = append(gameMap.Portals, &pb.Portal{A: ends[0], B: ends[1]})
+	}
+	return &pb.GameConfig{
+		Width:      proto.Int32(width),
+		Height:     proto.Int32(height),
+		SolidWalls: proto.Bool(f.SolidWalls),
+		GameMap:    gameMap,
+	}, nil
+}
+
+// NewCells пустой набор клеток поля width×height в формате GameMap
+func NewCells(width, height int32) []byte {
+	return make([]byte, (width*height+7)/8)
+}
+
+// SetCell добавляет клетку (x, y) в набор
+func SetCell(cells []byte, width, x, y int32) {
+	i := y*width + x
+	cells[i/8] |= 1 << (i % 8)
+}
+
+// HasCell есть ли клетка (x, y) в наборе. Клеток за пределами набора в нём нет
+func HasCell(cells []byte, width, x, y int32) bool {
+	if x < 0 || x >= width || y < 0 {
+		return false
+	}
+	i := y*width + x
+	return int(i/8) < len(cells) && cells[i/8]&(1<<(i%8)) != 0
+}
+
+// Obstacle стоит ли в клетке (x, y) препятствие
+func Obstacle(config *pb.GameConfig, x, y int32) bool {
+	return HasCell(config.GetGameMap().GetObstacles(), config.GetWidth(), x, y)
+}
+
+// SpawnZone может ли в клетке (x, y) появиться голова новой змеи
+func SpawnZone(config *pb.GameConfig, x, y int32) bool {
+	zones := config.GetGameMap().GetSpawnZones()
+	return len(zones) == 0 || HasCell(zones, config.GetWidth(), x, y)
+}
+
+// Encode карта в JSON по параметрам игры, по строке поля на строку файла.
+// Порталов в карте не больше common.MaxPortals
+func Encode(config *pb.GameConfig) []byte {
+	var b strings.Builder
+	name, _ := json.Marshal(config.GetGameMap().GetName())
+	fmt.Fprintf(&b, "{\n  \"name\": %s,\n  \"solid_walls\": %t,\n  \"rows\": [\n", name, config.GetSolidWalls())
+	zones := config.GetGameMap().GetSpawnZones()
+	portals := make(map[[2]int32]byte)
+	for i, portal := range config.GetGameMap().GetPortals() {
+		for _, end := range []*pb.GameState_Coord{portal.GetA(), portal.GetB()} {
+			portals[[2]int32{end.GetX(), end.GetY()}] = portalCells[i]
+		}
+	}
+	for y := int32(0); y < config.GetHeight(); y++ {
+		row := make([]byte, config.GetWidth())
+		for x := range row {
+			symbol, portal := portals[[2]int32{int32(x), y}]
+			switch {
+			case portal:
+				row[x] = symbol
+			case Obstacle(config, int32(x), y):
+				row[x] = obstacleCell
+			case HasCell(zones, config.GetWidth(), int32(x), y):
+				row[x] = spawnCell
+			default:
+				row[x] = emptyCell
+			}
+		}
+		separator := ","
+		if y == config.GetHeight()-1 {
+			separator = ""
+		}
+		fmt.Fprintf(&b, "    \"%s\"%s\n", row, separator)
+	}
+	b.WriteString("  ]\n}\n")
+	return []byte(b.String())
+}
+
+// Save записывает карту в файл
+func Save(path string, config *pb.GameConfig) error {
+	if err := os.WriteFile(path, Encode(config), 0o644); err != nil {
+		return fmt.Errorf("error saving map: %w", err)
+	}
+	return nil
+}
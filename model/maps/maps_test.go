@@ -0,0 +1,180 @@
+package maps
+
+import (
+	"SnakeGame/model/common"
+	"bytes"
+	"google.golang.org/protobuf/proto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// field JSON карты из строк поля
+func field(rows ...string) []byte {
+	return []byte(`{"solid_walls": true, "rows": ["` + strings.Join(rows, `", "`) + `"]}`)
+}
+
+func TestParse(t *testing.T) {
+	rows := make([]string, 10)
+	for y := range rows {
+		rows[y] = strings.Repeat(".", 12)
+	}
+	rows[3] = "...##......."
+	rows[7] = ".S.........."
+	config, err := Parse(field(rows...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.GetWidth() != 12 || config.GetHeight() != 10 || !config.GetSolidWalls() {
+		t.Fatalf("unexpected config %v", config)
+	}
+	if err := common.ValidateConfig(config); err != nil {
+		t.Fatalf("parsed map is invalid: %v", err)
+	}
+	for y := int32(0); y < 10; y++ {
+		for x := int32(0); x < 12; x++ {
+			if want := y == 3 && (x == 3 || x == 4); Obstacle(config, x, y) != want {
+				t.Errorf("Obstacle(%d, %d) = %v", x, y, !want)
+			}
+			if want := y == 7 && x == 1; SpawnZone(config, x, y) != want {
+				t.Errorf("SpawnZone(%d, %d) = %v", x, y, !want)
+			}
+		}
+	}
+	if Obstacle(config, -1, 3) || Obstacle(config, 3, 10) {
+		t.Error("obstacle outside the field")
+	}
+
+	// без мест появления змея может появиться где угодно
+	rows[7] = strings.Repeat(".", 12)
+	config, err = Parse(field(rows...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.GetGameMap().GetSpawnZones() != nil || !SpawnZone(config, 5, 5) {
+		t.Error("map without spawn zones limits spawns")
+	}
+}
+
+func TestParsePortals(t *testing.T) {
+	rows := make([]string, 10)
+	for y := range rows {
+		rows[y] = strings.Repeat(".", 10)
+	}
+	rows[0] = "0........a"
+	rows[9] = "a........0"
+	config, err := Parse(field(rows...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	portals := config.GetGameMap().GetPortals()
+	if len(portals) != 2 {
+		t.Fatalf("got portals %v", portals)
+	}
+	// пары идут в порядке символов, концы пары - в порядке строк
+	for i, want := range [][4]int32{{0, 0, 9, 9}, {9, 0, 0, 9}} {
+		a, b := portals[i].GetA(), portals[i].GetB()
+		if got := [4]int32{a.GetX(), a.GetY(), b.GetX(), b.GetY()}; got != want {
+			t.Errorf("portal %d is %v, want %v", i, got, want)
+		}
+	}
+	if err := common.ValidateConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	// при записи пары получают символы по порядку
+	if !bytes.Contains(Encode(config), []byte(`"1........0"`)) {
+		t.Errorf("portals are not encoded:\n%s", Encode(config))
+	}
+
+	// у портала без пары или с тремя концами выхода нет
+	rows[9] = "a........."
+	if _, err := Parse(field(rows...)); err == nil {
+		t.Error("map with a single portal cell accepted")
+	}
+	rows[9] = "a.......a0"
+	if _, err := Parse(field(rows...)); err == nil {
+		t.Error("map with a three-cell portal accepted")
+	}
+	// символов порталов хватает на все допустимые порталы
+	if len(portalCells) != common.MaxPortals {
+		t.Errorf("%d portal symbols for %d portals", len(portalCells), common.MaxPortals)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	row := strings.Repeat(".", 10)
+	tests := map[string][]byte{
+		"not json":    []byte("#####"),
+		"no rows":     field(),
+		"too small":   field(row[:9], row[:9], row[:9], row[:9], row[:9], row[:9], row[:9], row[:9], row[:9], row[:9]),
+		"ragged":      field(row, row, row, row, row, row, row, row, row, row+"."),
+		"unknown":     field(row, row, row, row, row, row, row, row, row, "....x....."),
+		"short field": field(row, row, row),
+	}
+	for name, data := range tests {
+		if _, err := Parse(data); err == nil {
+			t.Errorf("%s: map accepted", name)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	paths, err := List(filepath.Join("..", "..", "maps"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		config, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// карта проекта записана так же, как её записывает редактор
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encoded := Encode(config); !bytes.Equal(encoded, data) {
+			t.Errorf("%s encodes differently:\n%s", path, encoded)
+		}
+	}
+
+	saved := filepath.Join(t.TempDir(), "map.json")
+	config, err := Load(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(saved, config); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(loaded, config) {
+		t.Fatalf("saved map loads as %v, want %v", loaded, config)
+	}
+}
+
+// карты из папки проекта загружаются и годятся для игры
+func TestBundledMaps(t *testing.T) {
+	paths, err := List(filepath.Join("..", "..", "maps"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no bundled maps")
+	}
+	for _, path := range paths {
+		config, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.GetGameMap().GetName() == "" {
+			t.Errorf("%s has no name", path)
+		}
+		if err := common.ValidateConfig(config); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+	}
+}
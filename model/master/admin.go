@@ -0,0 +1,256 @@
+package master
+
+import (
+	"SnakeGame/logging"
+	"SnakeGame/model/common"
+	pb "SnakeGame/model/proto"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// переменная окружения с адресом HTTP API управления игрой, например "127.0.0.1:8080"
+	adminEnv = "SNAKE_ADMIN"
+	// сколько с IP-адреса выгнанного игрока нельзя присоединиться к игре
+	kickBan = time.Minute
+)
+
+// AdminAddr адрес API управления из SNAKE_ADMIN, пустая строка - API выключен
+func AdminAddr() string {
+	return os.Getenv(adminEnv)
+}
+
+// ServeAdmin запуск HTTP API управления игрой на addr, только на loopback.
+// Каждый запрос должен нести выданный на этот запуск токен в заголовке Authorization: Bearer,
+// так что страницы в браузере хоста не могут управлять игрой. Токен не пишется в лог, вызывающий
+// сам показывает его хозяину игры. Сервер останавливается вместе с мастером
+func (m *Master) ServeAdmin(addr string) (net.Addr, string, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid admin address: %w", err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, "", fmt.Errorf("admin API must listen on localhost, not %q", host)
+	}
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("error generating admin token: %w", err)
+	}
+	token := hex.EncodeToString(secret)
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, "", fmt.Errorf("error starting admin API: %w", err)
+	}
+	server := &http.Server{Handler: adminGuard(m.adminHandler(), listener.Addr(), token)}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Network.Error("Admin API stopped", logging.Err(err))
+		}
+	}()
+	go func() {
+		<-m.Node.Done()
+		server.Close()
+	}()
+
+	logging.Engine.Info("Admin API listening", logging.Addr(listener.Addr()))
+	return listener.Addr(), token, nil
+}
+
+// adminGuard пропускает только запросы с токеном и с Host, равным адресу API.
+// Проверка Host закрывает API от DNS rebinding: чужое доменное имя, указывающее на 127.0.0.1, не подойдёт
+func adminGuard(next http.Handler, addr net.Addr, token string) http.Handler {
+	_, port, _ := net.SplitHostPort(addr.String())
+	hosts := map[string]bool{
+		addr.String():                       true,
+		net.JoinHostPort("localhost", port): true,
+	}
+	bearer := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hosts[r.Host] {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "unexpected host"})
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), bearer) != 1 {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "missing or invalid token"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// игрок в ответе GET /players
+type adminPlayer struct {
+	Id      int32  `json:"id"`
+	Name    string `json:"name"`
+	Role    string `json:"role"`
+	Type    string `json:"type"`
+	Score   int32  `json:"score"`
+	Address string `json:"address"`
+	// время подтверждения последнего сообщения, у мастера и у игроков без подтверждений отсутствует
+	PingMs *float64 `json:"ping_ms,omitempty"`
+	// сколько назад от игрока что-то приходило
+	LastSeenMs *int64 `json:"last_seen_ms,omitempty"`
+}
+
+func (m *Master) adminHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /state", func(w http.ResponseWriter, r *http.Request) {
+		m.Node.Mu.Lock()
+		defer m.Node.Mu.Unlock()
+		writeProto(w, m.Node.State)
+	})
+	mux.HandleFunc("GET /config", func(w http.ResponseWriter, r *http.Request) {
+		m.Node.Mu.Lock()
+		defer m.Node.Mu.Unlock()
+		writeProto(w, m.Node.Config)
+	})
+	mux.HandleFunc("GET /players", func(w http.ResponseWriter, r *http.Request) {
+		m.Node.Mu.Lock()
+		defer m.Node.Mu.Unlock()
+		writeJSON(w, http.StatusOK, m.adminPlayers())
+	})
+
+	mux.HandleFunc("POST /players/{id}/kick", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 32)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid player id"})
+			return
+		}
+		m.Node.Mu.Lock()
+		defer m.Node.Mu.Unlock()
+		if err := m.Kick(int32(id)); err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]int32{"kicked": int32(id)})
+	})
+
+	mux.HandleFunc("POST /pause", func(w http.ResponseWriter, r *http.Request) {
+		m.setPaused(true)
+		writeJSON(w, http.StatusOK, map[string]bool{"paused": true})
+	})
+	mux.HandleFunc("POST /resume", func(w http.ResponseWriter, r *http.Request) {
+		m.setPaused(false)
+		writeJSON(w, http.StatusOK, map[string]bool{"paused": false})
+	})
+	mux.HandleFunc("POST /stop", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]bool{"stopped": true})
+		logging.Engine.Info("Master stopped through admin API")
+		// сервер закрывается вместе с мастером, поэтому сначала отвечаем
+		go m.Stop()
+	})
+
+	return mux
+}
+
+func (m *Master) adminPlayers() []adminPlayer {
+	now := time.Now()
+	players := make([]adminPlayer, 0, len(m.players.Players))
+	for _, player := range m.players.Players {
+		p := adminPlayer{
+			Id:      player.GetId(),
+			Name:    player.GetName(),
+			Role:    player.GetRole().String(),
+			Type:    player.GetType().String(),
+			Score:   player.GetScore(),
+			Address: common.JoinHostPort(player.GetIpAddress(), player.GetPort()),
+		}
+		if addr, err := common.ResolvePlayerAddr(player); err == nil && player.GetId() != m.Node.PlayerInfo.GetId() {
+			if rtt, ok := m.Node.RTT(addr); ok {
+				ping := float64(rtt.Microseconds()) / 1000
+				p.PingMs = &ping
+			}
+		}
+		if last, ok := m.Node.LastInteraction[player.GetId()]; ok {
+			lastSeen := now.Sub(last).Milliseconds()
+			p.LastSeenMs = &lastSeen
+		}
+		players = append(players, p)
+	}
+	return players
+}
+
+func (m *Master) setPaused(paused bool) {
+	m.Node.Mu.Lock()
+	defer m.Node.Mu.Unlock()
+	if m.paused != paused {
+		logging.Engine.Info("Game pause changed", "paused", paused)
+	}
+	m.paused = paused
+}
+
+// Kick выгнать игрока: змея становится зомби, сессия забывается, а с его IP-адреса
+// kickBan нельзя присоединиться, с какого бы порта ни пришёл JoinMsg. Вызывается под Node.Mu
+func (m *Master) Kick(playerId int32) error {
+	if playerId == m.Node.PlayerInfo.GetId() {
+		return fmt.Errorf("cannot kick the master")
+	}
+	var kicked *pb.GamePlayer
+	for _, player := range m.players.Players {
+		if player.GetId() == playerId {
+			kicked = player
+			break
+		}
+	}
+	if kicked == nil {
+		return fmt.Errorf("player %d not found", playerId)
+	}
+	addr, err := common.ResolvePlayerAddr(kicked)
+	if err != nil {
+		return fmt.Errorf("error resolving player address: %w", err)
+	}
+
+	// без сессии игрок не вернётся по токену
+	if token := m.sessionToken(playerId); token != nil {
+		delete(m.sessions, string(token))
+	}
+	delete(m.capabilities, playerId)
+	m.removePlayer(playerId)
+	m.kicked[addr.IP.String()] = time.Now()
+	m.handleErrorMsg(addr, "You have been kicked by the host")
+
+	logging.Engine.Info("Player kicked", logging.PlayerID(playerId), logging.Addr(addr))
+	return nil
+}
+
+// выгнан ли недавно игрок с этого IP-адреса
+func (m *Master) isKicked(addr *net.UDPAddr) bool {
+	ip := addr.IP.String()
+	since, ok := m.kicked[ip]
+	if ok && time.Since(since) > kickBan {
+		delete(m.kicked, ip)
+		return false
+	}
+	return ok
+}
+
+func writeProto(w http.ResponseWriter, msg proto.Message) {
+	data, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(msg)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logging.Network.Error("Error writing admin API response", logging.Err(err))
+	}
+}
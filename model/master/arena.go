@@ -0,0 +1,269 @@
+package master
+
+import (
+	"SnakeGame/model/maps"
+	pb "SnakeGame/model/proto"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"math/rand"
+)
+
+// ArenaStyle вид случайной арены
+type ArenaStyle int
+
+const (
+	// ArenaMaze коридоры лабиринта, часть стен которого снесена
+	ArenaMaze ArenaStyle = iota
+	// ArenaCaves пещеры из сглаженных случайных пятен
+	ArenaCaves
+	// ArenaPillars разбросанные колонны
+	ArenaPillars
+)
+
+const (
+	// mazeStep шаг лабиринта: коридор шириной mazeStep-1 и стена в одну клетку
+	mazeStep = 4
+	// caveSmoothing сколько раз сглаживаются пятна пещер
+	caveSmoothing = 4
+	// сколько раз уменьшать плотность, если змеям не хватает места
+	arenaAttempts = 8
+)
+
+// Arena параметры случайной арены. Одинаковые параметры дают одинаковую арену,
+// поэтому понравившуюся арену можно повторить по сиду
+type Arena struct {
+	Style         ArenaStyle
+	Width, Height int32
+	SolidWalls    bool
+	// доля препятствий от 0 до 1
+	Density float64
+	Seed    int64
+	// сколько змей должно помещаться на арене
+	Players int
+}
+
+// GenerateArena параметры игры со случайной ареной. Все свободные клетки арены связаны между собой,
+// а змей помещается не меньше arena.Players: если места не хватает, плотность уменьшается
+func GenerateArena(arena Arena, name string) (*pb.GameConfig, error) {
+	config := &pb.GameConfig{
+		Width:      proto.Int32(arena.Width),
+		Height:     proto.Int32(arena.Height),
+		SolidWalls: proto.Bool(arena.SolidWalls),
+	}
+	density := arena.Density
+	for attempt := 0; attempt <= arenaAttempts; attempt++ {
+		// последняя попытка - пустое поле
+		if attempt == arenaAttempts {
+			density = 0
+		}
+		obstacles := arena.obstacles(density)
+		arena.connect(obstacles)
+
+		config.GameMap = &pb.GameMap{Name: proto.String(name)}
+		cells := maps.NewCells(arena.Width, arena.Height)
+		for i, obstacle := range obstacles {
+			if obstacle {
+				maps.SetCell(cells, arena.Width, int32(i)%arena.Width, int32(i)/arena.Width)
+				config.GameMap.Obstacles = cells
+			}
+		}
+		if SpawnAreas(config) >= arena.Players {
+			return config, nil
+		}
+		density *= 0.7
+	}
+	return nil, fmt.Errorf("field %dx%d has no room for %d snakes", arena.Width, arena.Height, arena.Players)
+}
+
+// obstacles клетки-препятствия арены выбранного вида
+func (a Arena) obstacles(density float64) []bool {
+	rng := rand.New(rand.NewSource(a.Seed))
+	obstacles := make([]bool, a.Width*a.Height)
+	if density <= 0 {
+		return obstacles
+	}
+	switch a.Style {
+	case ArenaMaze:
+		a.maze(obstacles, density, rng)
+	case ArenaCaves:
+		a.caves(obstacles, density, rng)
+	case ArenaPillars:
+		a.pillars(obstacles, density, rng)
+	}
+	return obstacles
+}
+
+// maze лабиринт на сетке клеток mazeStep×mazeStep: у каждой клетки стена справа и снизу.
+// Обход в глубину прокладывает проходы, потом остальные стены сносятся с вероятностью 1-density
+func (a Arena) maze(obstacles []bool, density float64, rng *rand.Rand) {
+	cols, rows := int(a.Width/mazeStep), int(a.Height/mazeStep)
+	if cols == 0 || rows == 0 {
+		return
+	}
+	right := make([]bool, cols*rows)
+	bottom := make([]bool, cols*rows)
+	for i := range right {
+		right[i], bottom[i] = true, true
+	}
+
+	visited := make([]bool, cols*rows)
+	stack := []int{0}
+	visited[0] = true
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		cx, cy := current%cols, current/cols
+		var next []int
+		for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			nx, ny := cx+d[0], cy+d[1]
+			if nx >= 0 && nx < cols && ny >= 0 && ny < rows && !visited[ny*cols+nx] {
+				next = append(next, ny*cols+nx)
+			}
+		}
+		if len(next) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		n := next[rng.Intn(len(next))]
+		// стена между клетками принадлежит левой или верхней из них
+		switch nx, ny := n%cols, n/cols; {
+		case nx > cx:
+			right[current] = false
+		case nx < cx:
+			right[n] = false
+		case ny > cy:
+			bottom[current] = false
+		default:
+			bottom[n] = false
+		}
+		visited[n] = true
+		stack = append(stack, n)
+	}
+
+	for i := range right {
+		right[i] = right[i] && rng.Float64() < density
+		bottom[i] = bottom[i] && rng.Float64() < density
+	}
+
+	wall := func(x, y int) {
+		obstacles[int32(y)*a.Width+int32(x)] = true
+	}
+	for cy := 0; cy < rows; cy++ {
+		for cx := 0; cx < cols; cx++ {
+			i := cy*cols + cx
+			x0, y0 := cx*mazeStep, cy*mazeStep
+			for k := 0; k < mazeStep-1; k++ {
+				if right[i] {
+					wall(x0+mazeStep-1, y0+k)
+				}
+				if bottom[i] {
+					wall(x0+k, y0+mazeStep-1)
+				}
+			}
+			// угол между четырьмя клетками остаётся, если к нему подходит хоть одна стена
+			post := right[i] || bottom[i] ||
+				(cy+1 < rows && right[i+cols]) || (cx+1 < cols && bottom[i+1])
+			if post {
+				wall(x0+mazeStep-1, y0+mazeStep-1)
+			}
+		}
+	}
+}
+
+// caves случайные пятна, сглаженные клеточным автоматом: клетка становится стеной,
+// если вокруг неё не меньше пяти стен из восьми
+func (a Arena) caves(obstacles []bool, density float64, rng *rand.Rand) {
+	result := obstacles
+	for i := range obstacles {
+		obstacles[i] = rng.Float64() < 0.3+0.25*density
+	}
+	next := make([]bool, len(obstacles))
+	for step := 0; step < caveSmoothing; step++ {
+		for y := int32(0); y < a.Height; y++ {
+			for x := int32(0); x < a.Width; x++ {
+				walls := 0
+				for dx := int32(-1); dx <= 1; dx++ {
+					for dy := int32(-1); dy <= 1; dy++ {
+						if i, ok := a.neighbour(x+dx, y+dy); ok && (dx != 0 || dy != 0) && obstacles[i] {
+							walls++
+						}
+					}
+				}
+				i := y*a.Width + x
+				next[i] = walls >= 5 || (obstacles[i] && walls >= 4)
+			}
+		}
+		obstacles, next = next, obstacles
+	}
+	copy(result, obstacles)
+}
+
+// pillars колонны от 1x1 до 2x2 в случайных местах
+func (a Arena) pillars(obstacles []bool, density float64, rng *rand.Rand) {
+	count := int(density * float64(len(obstacles)) / 10)
+	for n := 0; n < count; n++ {
+		x, y := rng.Int31n(a.Width), rng.Int31n(a.Height)
+		w, h := rng.Int31n(2)+1, rng.Int31n(2)+1
+		for dx := int32(0); dx < w; dx++ {
+			for dy := int32(0); dy < h; dy++ {
+				if i, ok := a.neighbour(x+dx, y+dy); ok {
+					obstacles[i] = true
+				}
+			}
+		}
+	}
+}
+
+// neighbour номер клетки с учётом склейки краёв поля; за стеной клеток нет
+func (a Arena) neighbour(x, y int32) (int32, bool) {
+	if a.SolidWalls {
+		if x < 0 || x >= a.Width || y < 0 || y >= a.Height {
+			return 0, false
+		}
+	} else {
+		x, y = (x+a.Width)%a.Width, (y+a.Height)%a.Height
+	}
+	return y*a.Width + x, true
+}
+
+// connect оставляет самую большую связную область свободных клеток, остальные заполняет препятствиями
+func (a Arena) connect(obstacles []bool) {
+	component := make([]int, len(obstacles))
+	var sizes []int
+	for start := range obstacles {
+		if obstacles[start] || component[start] != 0 {
+			continue
+		}
+		sizes = append(sizes, 0)
+		id := len(sizes)
+		component[start] = id
+		queue := []int32{int32(start)}
+		for len(queue) > 0 {
+			i := queue[0]
+			queue = queue[1:]
+			sizes[id-1]++
+			x, y := i%a.Width, i/a.Width
+			for _, d := range [][2]int32{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+				if n, ok := a.neighbour(x+d[0], y+d[1]); ok && !obstacles[n] && component[n] == 0 {
+					component[n] = id
+					queue = append(queue, n)
+				}
+			}
+		}
+	}
+	// свободных клеток нет совсем
+	if len(sizes) == 0 {
+		return
+	}
+
+	largest := 0
+	for id, size := range sizes {
+		if size > sizes[largest] {
+			largest = id
+		}
+	}
+	for i := range obstacles {
+		if !obstacles[i] && component[i] != largest+1 {
+			obstacles[i] = true
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package master
+
+import (
+	"SnakeGame/model/common"
+	"SnakeGame/model/maps"
+	pb "SnakeGame/model/proto"
+	"google.golang.org/protobuf/proto"
+	"testing"
+)
+
+// freeCellsConnected все клетки без препятствий достижимы друг из друга
+func freeCellsConnected(config *pb.GameConfig) bool {
+	arena := Arena{Width: config.GetWidth(), Height: config.GetHeight(), SolidWalls: config.GetSolidWalls()}
+	obstacles := make([]bool, arena.Width*arena.Height)
+	free := 0
+	for i := range obstacles {
+		obstacles[i] = maps.Obstacle(config, int32(i)%arena.Width, int32(i)/arena.Width)
+		if !obstacles[i] {
+			free++
+		}
+	}
+	// после connect свободных клеток столько же, только если область была одна
+	arena.connect(obstacles)
+	for _, obstacle := range obstacles {
+		if !obstacle {
+			free--
+		}
+	}
+	return free == 0
+}
+
+func TestGenerateArena(t *testing.T) {
+	for _, style := range []ArenaStyle{ArenaMaze, ArenaCaves, ArenaPillars} {
+		for _, walls := range []bool{false, true} {
+			for seed := int64(1); seed <= 20; seed++ {
+				arena := Arena{Style: style, Width: 40, Height: 30, SolidWalls: walls, Density: 0.6, Seed: seed, Players: 6}
+				config, err := GenerateArena(arena, "arena")
+				if err != nil {
+					t.Fatalf("%+v: %v", arena, err)
+				}
+				if err := common.ValidateConfig(config); err != nil {
+					t.Fatalf("%+v: invalid config: %v", arena, err)
+				}
+				if len(config.GetGameMap().GetObstacles()) == 0 {
+					t.Errorf("%+v: arena has no obstacles", arena)
+				}
+				if areas := SpawnAreas(config); areas < arena.Players {
+					t.Errorf("%+v: room for %d snakes", arena, areas)
+				}
+				if !freeCellsConnected(config) {
+					t.Errorf("%+v: free cells are not connected", arena)
+				}
+
+				// тот же сид - та же арена
+				again, err := GenerateArena(arena, "arena")
+				if err != nil || !proto.Equal(config, again) {
+					t.Fatalf("%+v: seed does not replay the arena", arena)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateArenaLowersDensity(t *testing.T) {
+	// на плотной арене 10x10 четырём змеям места нет, пока препятствия не уберутся совсем
+	arena := Arena{Style: ArenaCaves, Width: 10, Height: 10, Density: 1, Seed: 7, Players: 4}
+	config, err := GenerateArena(arena, "arena")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if areas := SpawnAreas(config); areas < 4 {
+		t.Fatalf("room for %d snakes", areas)
+	}
+
+	arena.Players = 5
+	if _, err := GenerateArena(arena, "arena"); err == nil {
+		t.Fatal("generated an arena without room for the players")
+	}
+}
+
+func TestConnectFullArena(t *testing.T) {
+	arena := Arena{Width: 10, Height: 10}
+	obstacles := make([]bool, arena.Width*arena.Height)
+	for i := range obstacles {
+		obstacles[i] = true
+	}
+	arena.connect(obstacles)
+	for i, obstacle := range obstacles {
+		if !obstacle {
+			t.Fatalf("cell %d freed on a full arena", i)
+		}
+	}
+}
+
+func BenchmarkGenerateArena(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		arena := Arena{Style: ArenaStyle(i % 3), Width: 100, Height: 100, Density: 0.5, Seed: int64(i), Players: 10}
+		if _, err := GenerateArena(arena, "arena"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package master
+
+import (
+	"SnakeGame/connection"
+	"SnakeGame/model/common"
+	pb "SnakeGame/model/proto"
+	"google.golang.org/protobuf/proto"
+	"net"
+	"testing"
+)
+
+// примеры корректных сообщений каждого типа, от них fuzzer строит свои варианты
+func seedMessages() []*pb.GameMessage {
+	return []*pb.GameMessage{
+		{MsgSeq: proto.Int64(1), Type: &pb.GameMessage_Ping{Ping: &pb.GameMessage_PingMsg{}}},
+		{MsgSeq: proto.Int64(2), Type: &pb.GameMessage_Steer{Steer: &pb.GameMessage_SteerMsg{Direction: pb.Direction_UP.Enum()}}},
+		{MsgSeq: proto.Int64(3), Type: &pb.GameMessage_Ack{Ack: &pb.GameMessage_AckMsg{}}},
+		{MsgSeq: proto.Int64(4), Type: &pb.GameMessage_Discover{Discover: &pb.GameMessage_DiscoverMsg{}}},
+		{MsgSeq: proto.Int64(5), Type: &pb.GameMessage_Join{Join: &pb.GameMessage_JoinMsg{
+			PlayerType:    pb.PlayerType_HUMAN.Enum(),
+			PlayerName:    proto.String("fuzz"),
+			GameName:      proto.String("Game1"),
+			RequestedRole: pb.NodeRole_NORMAL.Enum(),
+		}}},
+		{MsgSeq: proto.Int64(6), Type: &pb.GameMessage_Join{Join: &pb.GameMessage_JoinMsg{
+			PlayerName:    proto.String("viewer"),
+			GameName:      proto.String("Game1"),
+			RequestedRole: pb.NodeRole_VIEWER.Enum(),
+		}}},
+		{MsgSeq: proto.Int64(7), Type: &pb.GameMessage_RoleChange{RoleChange: &pb.GameMessage_RoleChangeMsg{
+			SenderRole:   pb.NodeRole_DEPUTY.Enum(),
+			ReceiverRole: pb.NodeRole_MASTER.Enum(),
+		}}},
+		{MsgSeq: proto.Int64(8), Type: &pb.GameMessage_RoleChange{RoleChange: &pb.GameMessage_RoleChangeMsg{
+			ReceiverRole: pb.NodeRole_VIEWER.Enum(),
+		}}},
+		{MsgSeq: proto.Int64(9), Type: &pb.GameMessage_State{State: &pb.GameMessage_StateMsg{State: &pb.GameState{
+			StateOrder: proto.Int32(100),
+			Players:    &pb.GamePlayers{},
+		}}}},
+		{MsgSeq: proto.Int64(10), Type: &pb.GameMessage_Error{Error: &pb.GameMessage_ErrorMsg{ErrorMessage: proto.String("x")}}},
+	}
+}
+
+// fuzzMaster мастер с одним присоединившимся игроком, фоновые циклы не запускаются
+func fuzzMaster(t testing.TB) (*Master, *net.UDPAddr) {
+	network := connection.NewMemNetwork()
+	multicastConn, err := network.ListenMulticast(connection.MulticastGroup())
+	if err != nil {
+		t.Fatal(err)
+	}
+	unicastConn, err := network.ListenUDP(network.NewHost(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { multicastConn.Close() })
+
+	config := &pb.GameConfig{
+		Width:        proto.Int32(20),
+		Height:       proto.Int32(15),
+		FoodStatic:   proto.Int32(2),
+		StateDelayMs: proto.Int32(1000),
+	}
+	m, err := NewMaster(multicastConn, unicastConn, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(m.Stop)
+
+	playerAddr := &net.UDPAddr{IP: net.ParseIP(network.NewHost()), Port: 40500}
+	m.Node.Mu.Lock()
+	m.handleMessage(seedMessages()[4], playerAddr)
+	m.Node.Mu.Unlock()
+	return m, playerAddr
+}
+
+func checkMasterInvariants(t *testing.T, m *Master) {
+	if err := common.ValidateState(m.Node.State, m.Node.Config); err != nil {
+		t.Fatalf("invalid state: %v", err)
+	}
+	roles := make(map[pb.NodeRole]int)
+	for _, player := range m.Node.State.GetPlayers().GetPlayers() {
+		roles[player.GetRole()]++
+	}
+	if roles[pb.NodeRole_MASTER] > 1 || roles[pb.NodeRole_DEPUTY] > 1 {
+		t.Fatalf("too many MASTER or DEPUTY players: %v", roles)
+	}
+	checkGrid(t, m)
+}
+
+func FuzzHandleMessage(f *testing.F) {
+	for _, msg := range seedMessages() {
+		data, err := proto.Marshal(msg)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data, true)
+		f.Add(data, false)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte, known bool) {
+		var msg pb.GameMessage
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return
+		}
+
+		m, addr := fuzzMaster(t)
+		if !known {
+			addr = &net.UDPAddr{IP: net.ParseIP("10.9.9.9"), Port: 1}
+		}
+
+		m.Node.Mu.Lock()
+		defer m.Node.Mu.Unlock()
+		m.handleMessage(&msg, addr)
+		m.handleMulticastMessage(&msg, addr)
+		checkMasterInvariants(t, m)
+
+		m.GenerateFood()
+		m.UpdateGameState()
+		checkMasterInvariants(t, m)
+	})
+}
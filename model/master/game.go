@@ -1,14 +1,49 @@
 package master
 
 import (
+	"SnakeGame/logging"
+	"SnakeGame/model/common"
 	pb "SnakeGame/model/proto"
-	"fmt"
 	"google.golang.org/protobuf/proto"
-	"log"
 	"math/rand"
-	"net"
 )
 
+const (
+	// spawnSize сторона свободного квадрата, в центре которого появляется новая змея
+	spawnSize = 5
+	// steerBuffer сколько поворотов игрока может ждать своего хода
+	steerBuffer = 3
+)
+
+// MinSpawnAreas сколько змей должно помещаться на пустом поле карты
+const MinSpawnAreas = 4
+
+// SpawnAreas сколько змей можно поставить на пустое поле с такими параметрами: считаются
+// непересекающиеся свободные квадраты spawnSize×spawnSize, центр которых в месте появления змей
+func SpawnAreas(config *pb.GameConfig) int {
+	g := newGrid(config)
+	areas := 0
+	for g.freeSquares.len() > 0 {
+		square := g.freeSquares.items[0]
+		for dx := int32(0); dx < spawnSize; dx++ {
+			for dy := int32(0); dy < spawnSize; dy++ {
+				g.addSnakeCell(&pb.GameState_Coord{
+					X: proto.Int32((square%g.width + dx) % g.width),
+					Y: proto.Int32((square/g.width + dy) % g.height),
+				})
+			}
+		}
+		areas++
+	}
+	return areas
+}
+
+// rebuildGrid пересчитать занятость клеток по Node.State. Нужен, если змей или еду
+// поменяли в обход мастера, например в тестах. Вызывается под Node.Mu
+func (m *Master) rebuildGrid() {
+	m.grid = newGridFromState(m.Node.State, m.Node.Config)
+}
+
 // GenerateFood генерация еды
 func (m *Master) GenerateFood() {
 	requireFood := m.Node.Config.GetFoodStatic() + int32(len(m.Node.State.Snakes))
@@ -17,262 +52,312 @@ func (m *Master) GenerateFood() {
 	if currentFood < requireFood {
 		needNum := requireFood - currentFood
 		for i := int32(0); i < needNum; i++ {
-			coord := m.findEmptyCell()
-			if coord != nil {
+			coord, ok := m.grid.randomFreeCell()
+			if ok {
 				m.Node.State.Foods = append(m.Node.State.Foods, coord)
+				m.grid.addFood(coord)
 			} else {
-				log.Println("No empty cells available for new food.")
+				logging.Engine.Debug("No empty cells available for new food")
 				break
 			}
 		}
 	}
 }
 
-func (m *Master) findEmptyCell() *pb.GameState_Coord {
-	numCells := m.Node.Config.GetWidth() * m.Node.Config.GetHeight()
-	for attempts := int32(0); attempts < numCells; attempts++ {
-		x := rand.Int31n(m.Node.Config.GetWidth())
-		y := rand.Int31n(m.Node.Config.GetHeight())
-		if m.isCellEmpty(x, y) {
-			return &pb.GameState_Coord{X: proto.Int32(x), Y: proto.Int32(y)}
-		}
-	}
-	return nil
-}
-
-func (m *Master) isCellEmpty(x, y int32) bool {
-	for _, snake := range m.Node.State.Snakes {
-		for _, point := range snake.Points {
-			if point.GetX() == x && point.GetY() == y {
-				return false
-			}
-		}
-	}
-
-	for _, food := range m.Node.State.Foods {
-		if food.GetX() == x && food.GetY() == y {
-			return false
-		}
-	}
-
-	return true
-}
-
-// UpdateGameState обновление состояния игры
+// UpdateGameState ход игры: змеи поворачивают, двигаются, потом съедается еда,
+// потом погибают змеи, головы которых попали на клетки змей
 func (m *Master) UpdateGameState() {
+	m.applyTurns()
 	for _, snake := range m.Node.State.Snakes {
 		m.moveSnake(snake)
 	}
-
+	m.eatFood()
 	m.checkCollisions()
 }
 
+// moveSnake новая голова змеи в направлении движения, хвост убирает eatFood.
+// Голова, вошедшая в портал, оказывается в парной клетке, а тело идёт за ней следом
 func (m *Master) moveSnake(snake *pb.GameState_Snake) {
+	if len(snake.Points) == 0 {
+		return
+	}
 	head := snake.Points[0]
+	dx, dy := offset(snake.GetHeadDirection())
 	newHead := &pb.GameState_Coord{
-		X: proto.Int32(head.GetX()),
-		Y: proto.Int32(head.GetY()),
+		X: proto.Int32(head.GetX() + dx),
+		Y: proto.Int32(head.GetY() + dy),
 	}
 
-	// изменение координат
-	switch snake.GetHeadDirection() {
-	case pb.Direction_UP:
-		newHead.Y = proto.Int32(newHead.GetY() - 1)
-	case pb.Direction_DOWN:
-		newHead.Y = proto.Int32(newHead.GetY() + 1)
-	case pb.Direction_LEFT:
-		newHead.X = proto.Int32(newHead.GetX() - 1)
-	case pb.Direction_RIGHT:
-		newHead.X = proto.Int32(newHead.GetX() + 1)
+	// у поля со стенами голова уходит за край, и змею убивает checkCollisions,
+	// иначе поле склеено по краям
+	if !m.Node.Config.GetSolidWalls() {
+		if newHead.GetX() < 0 {
+			newHead.X = proto.Int32(m.Node.Config.GetWidth() - 1)
+		} else if newHead.GetX() >= m.Node.Config.GetWidth() {
+			newHead.X = proto.Int32(0)
+		}
+		if newHead.GetY() < 0 {
+			newHead.Y = proto.Int32(m.Node.Config.GetHeight() - 1)
+		} else if newHead.GetY() >= m.Node.Config.GetHeight() {
+			newHead.Y = proto.Int32(0)
+		}
 	}
 
-	// поведение при столкновении со стеной
-	if newHead.GetX() < 0 {
-		newHead.X = proto.Int32(m.Node.Config.GetWidth() - 1)
-	} else if newHead.GetX() >= m.Node.Config.GetWidth() {
-		newHead.X = proto.Int32(0)
-	}
-	if newHead.GetY() < 0 {
-		newHead.Y = proto.Int32(m.Node.Config.GetHeight() - 1)
-	} else if newHead.GetY() >= m.Node.Config.GetHeight() {
-		newHead.Y = proto.Int32(0)
+	// на выходе из портала голова может врезаться, как и в любой другой клетке
+	if exit, ok := m.grid.portalExit(newHead); ok {
+		newHead = exit
 	}
 
-	// добавляем новую голову
 	snake.Points = append([]*pb.GameState_Coord{newHead}, snake.Points...)
-	if !m.isFoodEaten(newHead) {
-		snake.Points = snake.Points[:len(snake.Points)-1]
-	} else {
+	m.grid.addSnakeCell(newHead)
+}
+
+// eatFood змея, голова которой попала на еду, съедает её и остаётся длиннее на клетку,
+// у остальных змей хвост сдвигается вслед за головой
+func (m *Master) eatFood() {
+	eaten := false
+	for _, snake := range m.Node.State.Snakes {
+		if len(snake.Points) == 0 {
+			continue
+		}
+		if !m.grid.isFood(snake.Points[0]) {
+			m.grid.removeSnakeCell(snake.Points[len(snake.Points)-1])
+			snake.Points = snake.Points[:len(snake.Points)-1]
+			continue
+		}
+		eaten = true
 		// игрок заработал +1 балл
-		snakeId := snake.GetPlayerId()
-		for _, player := range m.players.GetPlayers() {
-			if player.GetId() == snakeId {
-				player.Score = proto.Int32(player.GetScore() + 1)
-				break
-			}
+		m.addScore(snake.GetPlayerId())
+	}
+
+	if !eaten {
+		return
+	}
+	// еду под головами убираем после того, как поели все змеи: одну клетку могут съесть двое
+	foods := m.Node.State.Foods[:0]
+	for _, food := range m.Node.State.Foods {
+		if m.grid.snakeCells(food) > 0 {
+			m.grid.removeFood(food)
+		} else {
+			foods = append(foods, food)
 		}
 	}
+	m.Node.State.Foods = foods
 }
 
-func (m *Master) isFoodEaten(head *pb.GameState_Coord) bool {
-	for i, food := range m.Node.State.Foods {
-		if head.GetX() == food.GetX() && head.GetY() == food.GetY() {
-			m.Node.State.Foods = append(m.Node.State.Foods[:i], m.Node.State.Foods[i+1:]...)
-			return true
+// addScore +1 балл игроку, у зомби очков нет
+func (m *Master) addScore(playerId int32) {
+	for _, player := range m.players.GetPlayers() {
+		if player.GetId() == playerId {
+			player.Score = proto.Int32(player.GetScore() + 1)
+			return
 		}
 	}
-	return false
 }
 
-// Проверяем столкновения с другими змеями
-func (m *Master) checkCollisions() {
-	heads := make(map[string]int32)
+// cell клетка поля, ключ для поиска по координатам
+type cell struct {
+	x, y int32
+}
+
+func cellOf(coord *pb.GameState_Coord) cell {
+	return cell{coord.GetX(), coord.GetY()}
+}
 
+// checkCollisions змея погибает, если её голова попала на клетку любой змеи, в том числе
+// на голову другой змеи или на своё тело. Хозяин клетки получает +1, если это не сама змея.
+// Змея, врезавшаяся в стену или препятствие, тоже погибает, но очков за неё никто не получает.
+// Погибшие змеи убираются с поля все вместе, уже после проверки
+func (m *Master) checkCollisions() {
+	dead := make(map[int32]bool)
+	// голова столкнулась, если в её клетке есть ещё клетки змей
+	crashed := make(map[cell]bool)
 	for _, snake := range m.Node.State.Snakes {
-		head := snake.Points[0]
-		point := fmt.Sprintf("%d,%d", head.GetX(), head.GetY())
-		heads[point] = snake.GetPlayerId()
+		if len(snake.Points) == 0 {
+			continue
+		}
+		if m.grid.deadly(snake.Points[0]) {
+			dead[snake.GetPlayerId()] = true
+		} else if m.grid.snakeCells(snake.Points[0]) > 1 {
+			crashed[cellOf(snake.Points[0])] = true
+		}
+	}
+	if len(crashed) == 0 && len(dead) == 0 {
+		return
 	}
 
-	// проверяем, есть ли клетки с более чем одной головой
-	for key := range heads {
-		count := 0
-		var crashedPlayers []int32
-		for k, pid := range heads {
-			if k == key {
-				count++
-				crashedPlayers = append(crashedPlayers, pid)
-			}
-		}
-		// несколько голов на одной клетке -- все погибают
-		if count > 1 {
-			for _, pid := range crashedPlayers {
-				m.killSnake(pid, pid)
+	// змеи, занимающие клетки столкновений, по одному разу на каждую свою клетку в ней
+	occupants := make(map[cell][]int32)
+	for _, snake := range m.Node.State.Snakes {
+		for _, point := range snake.Points {
+			if c := cellOf(point); crashed[c] {
+				occupants[c] = append(occupants[c], snake.GetPlayerId())
 			}
 		}
 	}
 
-	// проверяем столкновения головы змейки с телом других змей
 	for _, snake := range m.Node.State.Snakes {
-		head := snake.Points[0]
-		headX, headY := head.GetX(), head.GetY()
-		for _, otherSnake := range m.Node.State.Snakes {
-			for i, point := range otherSnake.Points {
-				// если это собственная змейка и это голова, пропускаем
-				if otherSnake.GetPlayerId() == snake.GetPlayerId() && i == 0 {
-					continue
-				}
-				if point.GetX() == headX && point.GetY() == headY {
-					m.killSnake(snake.GetPlayerId(), otherSnake.GetPlayerId())
-				}
+		if len(snake.Points) == 0 {
+			continue
+		}
+		// своя голова на клетке головы есть всегда, её пропускаем
+		skippedHead := false
+		scored := make(map[int32]bool)
+		for _, killer := range occupants[cellOf(snake.Points[0])] {
+			if killer == snake.GetPlayerId() && !skippedHead {
+				skippedHead = true
+				continue
+			}
+			dead[snake.GetPlayerId()] = true
+			if killer != snake.GetPlayerId() && !scored[killer] {
+				scored[killer] = true
+				m.addScore(killer)
 			}
 		}
 	}
+
+	if len(dead) > 0 {
+		m.removeDeadSnakes(dead)
+	}
 }
 
-// убираем умершую змею
-func (m *Master) killSnake(crashedPlayerId, killer int32) {
-	m.Node.Mu.Lock()
-	defer m.Node.Mu.Unlock()
-
-	var indexToRemove int
-	var snakeToRemove *pb.GameState_Snake
-	for index, snake := range m.Node.State.Snakes {
-		if snake.GetPlayerId() == crashedPlayerId {
-			indexToRemove = index
-			snakeToRemove = snake
-			break
+// removeDeadSnakes убирает погибших змей, каждая их клетка с вероятностью 0.5 становится едой.
+// Погибшие игроки, кроме самого мастера, выбывают из игры
+func (m *Master) removeDeadSnakes(dead map[int32]bool) {
+	var alive []*pb.GameState_Snake
+	var corpses []*pb.GameState_Snake
+	for _, snake := range m.Node.State.Snakes {
+		if dead[snake.GetPlayerId()] {
+			corpses = append(corpses, snake)
+			delete(m.turns, snake.GetPlayerId())
+			m.grid.removeSnake(snake)
+		} else {
+			alive = append(alive, snake)
 		}
 	}
+	m.Node.State.Snakes = alive
 
-	if snakeToRemove != nil {
-		for _, point := range snakeToRemove.Points {
-			if rand.Float32() < 0.5 {
-				// заменяем на еду
-				newFood := &pb.GameState_Coord{
-					X: proto.Int32(point.GetX()),
-					Y: proto.Int32(point.GetY()),
-				}
-				m.Node.State.Foods = append(m.Node.State.Foods, newFood)
+	// еда не появляется под выжившими змеями и поверх другой еды
+	for _, snake := range corpses {
+		for _, point := range snake.Points {
+			if !m.grid.isFree(point) || rand.Float32() >= 0.5 {
+				continue
 			}
+			food := &pb.GameState_Coord{
+				X: proto.Int32(point.GetX()),
+				Y: proto.Int32(point.GetY()),
+			}
+			m.Node.State.Foods = append(m.Node.State.Foods, food)
+			m.grid.addFood(food)
 		}
-		m.Node.State.Snakes = append(m.Node.State.Snakes[:indexToRemove], m.Node.State.Snakes[indexToRemove+1:]...)
 	}
 
-	if crashedPlayerId != killer {
-		for _, player := range m.players.Players {
-			if player.GetId() == killer {
-				player.Score = proto.Int32(player.GetScore() + 1)
-				break
-			}
+	for _, snake := range corpses {
+		if snake.GetPlayerId() != m.Node.PlayerInfo.GetId() {
+			m.crashPlayer(snake.GetPlayerId())
 		}
 	}
+}
 
-	if crashedPlayerId != m.Node.PlayerInfo.GetId() {
-		// Сохраняем адрес игрока, чтобы отправить ему ErrorMsg
-		var crashedPlayerAddr *net.UDPAddr
-		for _, player := range m.players.Players {
-			if player.GetId() == crashedPlayerId {
-				addrStr := fmt.Sprintf("%s:%d", player.GetIpAddress(), player.GetPort())
-				addr, err := net.ResolveUDPAddr("udp", addrStr)
-				if err == nil {
-					crashedPlayerAddr = addr
-				}
-				break
-			}
+// crashPlayer игрок, чья змея погибла, выбывает из игры и узнаёт об этом из ErrorMsg
+func (m *Master) crashPlayer(crashedPlayerId int32) {
+	var crashedPlayer *pb.GamePlayer
+	for _, player := range m.players.Players {
+		if player.GetId() == crashedPlayerId {
+			crashedPlayer = player
+			break
 		}
+	}
+	// у змеи зомби игрока нет
+	if crashedPlayer == nil {
+		return
+	}
+	// Сохраняем адрес игрока, чтобы отправить ему ErrorMsg
+	crashedPlayerAddr, err := common.ResolvePlayerAddr(crashedPlayer)
 
-		m.removePlayer(crashedPlayerId)
-		log.Printf("Player ID: %d has crashed and been removed.", crashedPlayerId)
-
-		// Отправляем ErrorMsg упавшему игроку, чтобы он у себя вызвал os.Exit(0)
-		if crashedPlayerAddr != nil {
-			errorMsg := &pb.GameMessage{
-				MsgSeq: proto.Int64(m.Node.MsgSeq),
-				Type: &pb.GameMessage_Error{
-					Error: &pb.GameMessage_ErrorMsg{
-						ErrorMessage: proto.String("You have crashed and been removed from the game. Exiting..."),
-					},
+	// погибшую змею не вернуть, поэтому сессию забываем: по токену игрок в игру не вернётся
+	if token := m.sessionToken(crashedPlayerId); token != nil {
+		delete(m.sessions, string(token))
+	}
+	m.removePlayer(crashedPlayerId)
+	logging.Engine.Info("Player crashed and was removed", logging.PlayerID(crashedPlayerId))
+
+	// Отправляем ErrorMsg упавшему игроку, после него игрок только наблюдает за игрой
+	if err == nil {
+		errorMsg := &pb.GameMessage{
+			Type: &pb.GameMessage_Error{
+				Error: &pb.GameMessage_ErrorMsg{
+					ErrorMessage: proto.String("You have crashed and been removed from the game. Exiting..."),
 				},
-			}
-			m.Node.SendMessage(errorMsg, crashedPlayerAddr)
+			},
 		}
+		m.Node.SendMessage(errorMsg, crashedPlayerAddr)
 	}
 }
 
-func (m *Master) hasFreeSquare(state *pb.GameState, config *pb.GameConfig, squareSize int32) (bool, *pb.GameState_Coord) {
-	occupied := make([][]bool, config.GetWidth())
-	for i := range occupied {
-		occupied[i] = make([]bool, config.GetHeight())
-	}
+// Spawner выбирает место новой змеи вместо случайного: центр квадрата spawnSize×spawnSize
+// и направление головы. fits сообщает, свободен ли квадрат с таким центром; ok == false - места нет.
+// Нужен тестам, которым важно, где стоят змеи
+type Spawner func(config *pb.GameConfig, fits func(center *pb.GameState_Coord) bool) (center *pb.GameState_Coord, direction pb.Direction, ok bool)
 
-	for _, snake := range state.Snakes {
-		for _, point := range snake.Points {
-			x, y := point.GetX(), point.GetY()
-			if x >= 0 && x < config.GetWidth() && y >= 0 && y < config.GetHeight() {
-				occupied[x][y] = true
-			}
+// spawn место и направление новой змеи: от Spawner мастера или случайные. Вызывается под Node.Mu
+func (m *Master) spawn() (*pb.GameState_Coord, pb.Direction, bool) {
+	if m.spawner != nil {
+		center, direction, ok := m.spawner(m.Node.Config, m.grid.spawnFits)
+		// на занятое место змею не ставим, даже если так решил Spawner
+		if !ok || !m.grid.spawnFits(center) {
+			return nil, 0, false
 		}
+		return center, direction, true
 	}
+	center, ok := m.grid.randomSpawn()
+	return center, randomDirection(), ok
+}
 
-	for startX := int32(0); startX <= config.GetWidth()-squareSize; startX++ {
-		for startY := int32(0); startY <= config.GetHeight()-squareSize; startY++ {
-			if isSquareFree(occupied, startX, startY, squareSize) {
-				return true, &pb.GameState_Coord{X: proto.Int32(startX), Y: proto.Int32(startY)}
-			}
-		}
+func randomDirection() pb.Direction {
+	return pb.Direction(rand.Int31n(4) + 1)
+}
+
+// newSnake змея из двух клеток: голова в center смотрит в direction, хвост позади неё
+func newSnake(playerId int32, center *pb.GameState_Coord, direction pb.Direction, config *pb.GameConfig) *pb.GameState_Snake {
+	dx, dy := offset(oppositeDirection(direction))
+	tail := &pb.GameState_Coord{
+		X: proto.Int32((center.GetX() + dx + config.GetWidth()) % config.GetWidth()),
+		Y: proto.Int32((center.GetY() + dy + config.GetHeight()) % config.GetHeight()),
+	}
+	return &pb.GameState_Snake{
+		PlayerId:      proto.Int32(playerId),
+		Points:        []*pb.GameState_Coord{proto.Clone(center).(*pb.GameState_Coord), tail},
+		State:         pb.GameState_Snake_ALIVE.Enum(),
+		HeadDirection: direction.Enum(),
 	}
+}
 
-	return false, nil
+// offset сдвиг на одну клетку в направлении direction
+func offset(direction pb.Direction) (int32, int32) {
+	switch direction {
+	case pb.Direction_UP:
+		return 0, -1
+	case pb.Direction_DOWN:
+		return 0, 1
+	case pb.Direction_LEFT:
+		return -1, 0
+	case pb.Direction_RIGHT:
+		return 1, 0
+	}
+	return 0, 0
 }
 
-func isSquareFree(occupied [][]bool, startX, startY, squareSize int32) bool {
-	for x := startX; x < startX+squareSize; x++ {
-		for y := startY; y < startY+squareSize; y++ {
-			if occupied[x][y] {
-				return false
-			}
-		}
+func oppositeDirection(direction pb.Direction) pb.Direction {
+	switch direction {
+	case pb.Direction_UP:
+		return pb.Direction_DOWN
+	case pb.Direction_DOWN:
+		return pb.Direction_UP
+	case pb.Direction_LEFT:
+		return pb.Direction_RIGHT
+	case pb.Direction_RIGHT:
+		return pb.Direction_LEFT
 	}
-	return true
+	return direction
 }
@@ -0,0 +1,462 @@
+package master
+
+import (
+	"SnakeGame/model/common"
+	"SnakeGame/model/maps"
+	pb "SnakeGame/model/proto"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"path/filepath"
+	"testing"
+)
+
+func spawnConfig(width, height int32) *pb.GameConfig {
+	return &pb.GameConfig{Width: proto.Int32(width), Height: proto.Int32(height)}
+}
+
+func at(x, y int32) *pb.GameState_Coord {
+	return &pb.GameState_Coord{X: proto.Int32(x), Y: proto.Int32(y)}
+}
+
+func TestRandomSpawn(t *testing.T) {
+	config := spawnConfig(10, 10)
+	// свободен только квадрат 5x5 с углом в (3, 3): остальное занято змеёй и едой
+	state := &pb.GameState{Snakes: []*pb.GameState_Snake{{PlayerId: proto.Int32(1)}}}
+	for x := int32(0); x < 10; x++ {
+		for y := int32(0); y < 10; y++ {
+			if x >= 3 && x < 8 && y >= 3 && y < 8 {
+				continue
+			}
+			if x%2 == 0 {
+				state.Snakes[0].Points = append(state.Snakes[0].Points, at(x, y))
+			} else {
+				state.Foods = append(state.Foods, at(x, y))
+			}
+		}
+	}
+	center, ok := newGridFromState(state, config).randomSpawn()
+	if !ok || center.GetX() != 5 || center.GetY() != 5 {
+		t.Fatalf("randomSpawn = %v, %v; want center (5, 5)", center, ok)
+	}
+
+	// еда в свободном квадрате тоже мешает
+	state.Foods = append(state.Foods, at(7, 7))
+	if center, ok := newGridFromState(state, config).randomSpawn(); ok {
+		t.Fatalf("spawned at %v next to food", center)
+	}
+}
+
+func TestRandomSpawnUniform(t *testing.T) {
+	config := spawnConfig(10, 10)
+	state := &pb.GameState{}
+	centers := make(map[[2]int32]bool)
+	for i := 0; i < 200; i++ {
+		center, ok := newGridFromState(state, config).randomSpawn()
+		if !ok {
+			t.Fatal("no spawn on an empty field")
+		}
+		centers[[2]int32{center.GetX(), center.GetY()}] = true
+	}
+	// на пустом поле с учётом склейки краёв подходит любая клетка
+	if len(centers) < 50 {
+		t.Fatalf("only %d different spawn points in 200 spawns", len(centers))
+	}
+}
+
+func TestNewSnake(t *testing.T) {
+	config := spawnConfig(10, 10)
+	for _, direction := range []pb.Direction{pb.Direction_UP, pb.Direction_DOWN, pb.Direction_LEFT, pb.Direction_RIGHT} {
+		snake := newSnake(2, at(0, 9), direction, config)
+		if len(snake.GetPoints()) != 2 || snake.GetState() != pb.GameState_Snake_ALIVE {
+			t.Fatalf("unexpected snake %v", snake)
+		}
+		head, tail := snake.GetPoints()[0], snake.GetPoints()[1]
+		if head.GetX() != 0 || head.GetY() != 9 {
+			t.Fatalf("head %v is not in the center", head)
+		}
+		// голова смотрит от хвоста: шаг назад от головы попадает в хвост
+		dx, dy := offset(oppositeDirection(snake.GetHeadDirection()))
+		if tail.GetX() != (10+dx)%10 || tail.GetY() != (19+dy)%10 {
+			t.Fatalf("tail %v is not behind the head facing %v", tail, snake.GetHeadDirection())
+		}
+		if snake.GetHeadDirection() != direction {
+			t.Fatalf("snake faces %v, want %v", snake.GetHeadDirection(), direction)
+		}
+	}
+
+	directions := make(map[pb.Direction]bool)
+	for i := 0; i < 100; i++ {
+		directions[randomDirection()] = true
+	}
+	if len(directions) != 4 {
+		t.Fatalf("random snakes faced only %v", directions)
+	}
+}
+
+func TestSpawner(t *testing.T) {
+	config := spawnConfig(10, 10)
+	var place *pb.GameState_Coord
+	m := &Master{
+		Node: &common.Node{Config: config},
+		grid: newGrid(config),
+		spawner: func(config *pb.GameConfig, fits func(*pb.GameState_Coord) bool) (*pb.GameState_Coord, pb.Direction, bool) {
+			return place, pb.Direction_LEFT, true
+		},
+	}
+
+	place = at(5, 5)
+	center, direction, ok := m.spawn()
+	if !ok || center.GetX() != 5 || center.GetY() != 5 || direction != pb.Direction_LEFT {
+		t.Fatalf("spawn = %v, %v, %v; want (5, 5) facing LEFT", center, direction, ok)
+	}
+
+	// на занятое место и за край поля змея не встаёт, что бы ни выбрал Spawner
+	m.grid.addSnake(newSnake(1, at(5, 5), pb.Direction_RIGHT, config))
+	for _, place = range []*pb.GameState_Coord{at(5, 5), at(7, 3), at(10, 5), at(-1, 0)} {
+		if center, _, ok := m.spawn(); ok {
+			t.Errorf("snake spawned at %v", center)
+		}
+	}
+}
+
+func snakeAt(id int32, direction pb.Direction, points ...*pb.GameState_Coord) *pb.GameState_Snake {
+	return &pb.GameState_Snake{
+		PlayerId:      proto.Int32(id),
+		Points:        points,
+		State:         pb.GameState_Snake_ALIVE.Enum(),
+		HeadDirection: direction.Enum(),
+	}
+}
+
+func findPlayer(m *Master, id int32) *pb.GamePlayer {
+	for _, player := range m.players.GetPlayers() {
+		if player.GetId() == id {
+			return player
+		}
+	}
+	return nil
+}
+
+func findSnake(m *Master, id int32) *pb.GameState_Snake {
+	for _, snake := range m.Node.State.GetSnakes() {
+		if snake.GetPlayerId() == id {
+			return snake
+		}
+	}
+	return nil
+}
+
+func TestCollisionScoring(t *testing.T) {
+	m, _ := fuzzMaster(t)
+	m.Node.Mu.Lock()
+	defer m.Node.Mu.Unlock()
+
+	m.Node.State.Foods = nil
+	m.Node.State.Snakes = []*pb.GameState_Snake{
+		// мастер врезается в тело игрока
+		snakeAt(1, pb.Direction_RIGHT, at(5, 5), at(4, 5)),
+		snakeAt(2, pb.Direction_UP, at(6, 4), at(6, 5), at(6, 6)),
+		// зомби в тот же ход врезается в тело мастера: погибшие убираются только после проверки всех
+		snakeAt(98, pb.Direction_UP, at(5, 6), at(5, 7)),
+		// зомби врезается в своё тело
+		snakeAt(99, pb.Direction_RIGHT, at(10, 2), at(10, 3), at(11, 3), at(11, 2), at(12, 2)),
+		// зомби догоняет свой хвост, который в этот же ход уходит
+		snakeAt(97, pb.Direction_RIGHT, at(2, 10), at(2, 11), at(3, 11), at(3, 10)),
+	}
+	m.rebuildGrid()
+	m.UpdateGameState()
+
+	for _, id := range []int32{1, 98, 99} {
+		if snake := findSnake(m, id); snake != nil {
+			t.Errorf("snake %d survived: %v", id, snake)
+		}
+	}
+	if findSnake(m, 2) == nil || findSnake(m, 97) == nil {
+		t.Fatalf("wrong snakes died: %v", m.Node.State.GetSnakes())
+	}
+	if score := findPlayer(m, 2).GetScore(); score != 1 {
+		t.Errorf("player who killed the master has score %d", score)
+	}
+	// мастер убил зомби, хоть и погиб сам; за себя очков не дают
+	if score := findPlayer(m, 1).GetScore(); score != 1 {
+		t.Errorf("master has score %d", score)
+	}
+	// хозяин выбывает из игры только если это не мастер
+	if findPlayer(m, 1) == nil {
+		t.Error("master left the game after its snake died")
+	}
+}
+
+func TestSolidWalls(t *testing.T) {
+	m, _ := fuzzMaster(t)
+	m.Node.Mu.Lock()
+	defer m.Node.Mu.Unlock()
+
+	m.Node.Config.SolidWalls = proto.Bool(true)
+	m.Node.State.Foods = nil
+	m.Node.State.Snakes = []*pb.GameState_Snake{
+		snakeAt(1, pb.Direction_LEFT, at(0, 5), at(1, 5)),
+		// зомби идёт вдоль стены и остаётся жив
+		snakeAt(99, pb.Direction_DOWN, at(19, 2), at(19, 1)),
+		snakeAt(98, pb.Direction_UP, at(7, 0), at(7, 1)),
+	}
+	m.rebuildGrid()
+	m.UpdateGameState()
+	checkGrid(t, m)
+
+	if findSnake(m, 1) != nil || findSnake(m, 98) != nil {
+		t.Fatalf("snakes went through the wall: %v", m.Node.State.GetSnakes())
+	}
+	if findSnake(m, 99) == nil {
+		t.Fatal("snake next to the wall died")
+	}
+	// за стену очков никто не получает, клетки за краем едой не становятся
+	if score := findPlayer(m, 1).GetScore(); score != 0 {
+		t.Errorf("master has score %d", score)
+	}
+	for _, food := range m.Node.State.GetFoods() {
+		if m.grid.index(food) < 0 {
+			t.Errorf("food outside the field at %v", food)
+		}
+	}
+}
+
+func TestSolidWallsSpawn(t *testing.T) {
+	config := spawnConfig(10, 10)
+	config.SolidWalls = proto.Bool(true)
+	for i := 0; i < 200; i++ {
+		center, ok := newGridFromState(&pb.GameState{}, config).randomSpawn()
+		// квадрат 5x5 целиком внутри стен: центр не ближе двух клеток к краю
+		if !ok || center.GetX() < 2 || center.GetX() > 7 || center.GetY() < 2 || center.GetY() > 7 {
+			t.Fatalf("randomSpawn = %v, %v; want a square inside the walls", center, ok)
+		}
+	}
+}
+
+// obstacleConfig поле 10x10 со стеной препятствий в столбце 4 и местом появления змей в клетке (7, 7)
+func obstacleConfig() *pb.GameConfig {
+	config := spawnConfig(10, 10)
+	config.GameMap = &pb.GameMap{Obstacles: maps.NewCells(10, 10), SpawnZones: maps.NewCells(10, 10)}
+	for y := int32(0); y < 10; y++ {
+		maps.SetCell(config.GameMap.Obstacles, 10, 4, y)
+	}
+	maps.SetCell(config.GameMap.SpawnZones, 10, 7, 7)
+	return config
+}
+
+func TestObstacles(t *testing.T) {
+	m, _ := fuzzMaster(t)
+	m.Node.Mu.Lock()
+	defer m.Node.Mu.Unlock()
+
+	m.Node.Config = obstacleConfig()
+	m.Node.Config.FoodStatic = proto.Int32(60)
+	m.Node.State.Foods = nil
+	m.Node.State.Snakes = []*pb.GameState_Snake{
+		snakeAt(1, pb.Direction_RIGHT, at(3, 5), at(2, 5)),
+		snakeAt(99, pb.Direction_UP, at(5, 5), at(5, 6)),
+	}
+	m.rebuildGrid()
+	m.UpdateGameState()
+	checkGrid(t, m)
+
+	if findSnake(m, 1) != nil || findSnake(m, 99) == nil {
+		t.Fatalf("wrong snakes died: %v", m.Node.State.GetSnakes())
+	}
+	// еда и змеи не появляются на препятствиях
+	m.GenerateFood()
+	for _, food := range m.Node.State.GetFoods() {
+		if food.GetX() == 4 {
+			t.Fatalf("food on an obstacle at %v", food)
+		}
+	}
+	if len(m.Node.State.GetFoods()) != 61 {
+		t.Fatalf("%d food cells, want 61", len(m.Node.State.GetFoods()))
+	}
+}
+
+func TestSpawnZones(t *testing.T) {
+	config := obstacleConfig()
+	for i := 0; i < 50; i++ {
+		center, ok := newGridFromState(&pb.GameState{}, config).randomSpawn()
+		if !ok || center.GetX() != 7 || center.GetY() != 7 {
+			t.Fatalf("randomSpawn = %v, %v; want the spawn zone (7, 7)", center, ok)
+		}
+	}
+	// квадрат вокруг места появления занят
+	state := &pb.GameState{Foods: []*pb.GameState_Coord{at(9, 9)}}
+	if center, ok := newGridFromState(state, config).randomSpawn(); ok {
+		t.Fatalf("spawned at %v outside the spawn zone", center)
+	}
+}
+
+func TestPortals(t *testing.T) {
+	m, _ := fuzzMaster(t)
+	m.Node.Mu.Lock()
+	defer m.Node.Mu.Unlock()
+
+	m.Node.Config.GameMap = &pb.GameMap{Portals: []*pb.Portal{
+		{A: at(6, 5), B: at(12, 2)},
+		{A: at(6, 9), B: at(12, 11)},
+	}}
+	m.Node.State.Foods = nil
+	m.Node.State.Snakes = []*pb.GameState_Snake{
+		snakeAt(1, pb.Direction_RIGHT, at(5, 5), at(4, 5), at(3, 5)),
+		// зомби входит во второй портал, а на выходе его ждёт тело другой змеи
+		snakeAt(99, pb.Direction_DOWN, at(6, 8), at(6, 7)),
+		snakeAt(98, pb.Direction_RIGHT, at(13, 11), at(12, 11), at(11, 11)),
+	}
+	m.rebuildGrid()
+
+	m.UpdateGameState()
+	checkGrid(t, m)
+	// голова вышла из парного портала, направление то же
+	if got := cellsOf(findSnake(m, 1)); got != "[{12 2} {5 5} {4 5}]" {
+		t.Fatalf("snake after the portal: %s", got)
+	}
+	if findSnake(m, 99) != nil || findSnake(m, 98) == nil {
+		t.Fatalf("wrong snakes died at the portal exit: %v", m.Node.State.GetSnakes())
+	}
+	if score := findPlayer(m, 1).GetScore(); score != 0 {
+		t.Errorf("master has score %d", score)
+	}
+
+	// тело проходит через портал вслед за головой
+	m.UpdateGameState()
+	m.UpdateGameState()
+	checkGrid(t, m)
+	if got := cellsOf(findSnake(m, 1)); got != "[{14 2} {13 2} {12 2}]" {
+		t.Fatalf("snake after the portal: %s", got)
+	}
+
+	// еда на порталах не появляется
+	m.Node.Config.FoodStatic = proto.Int32(250)
+	m.GenerateFood()
+	for _, food := range m.Node.State.GetFoods() {
+		if _, ok := m.grid.portalExit(food); ok {
+			t.Fatalf("food on a portal at %v", food)
+		}
+	}
+}
+
+func cellsOf(snake *pb.GameState_Snake) string {
+	cells := make([]cell, len(snake.GetPoints()))
+	for i, point := range snake.GetPoints() {
+		cells[i] = cellOf(point)
+	}
+	return fmt.Sprint(cells)
+}
+
+func TestSpawnAreas(t *testing.T) {
+	// на поле 10x10 помещаются четыре непересекающихся квадрата 5x5
+	if areas := SpawnAreas(spawnConfig(10, 10)); areas != 4 {
+		t.Errorf("empty field has %d spawn areas, want 4", areas)
+	}
+	// стена препятствий оставляет по одному квадрату в высоту с каждой стороны, а место появления одно
+	if areas := SpawnAreas(obstacleConfig()); areas != 1 {
+		t.Errorf("obstacle map has %d spawn areas, want 1", areas)
+	}
+
+	// на картах проекта хватает места для игры
+	paths, err := maps.List(filepath.Join("..", "..", "maps"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		config, err := maps.Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if areas := SpawnAreas(config); areas < MinSpawnAreas {
+			t.Errorf("%s has room for %d snakes", path, areas)
+		}
+	}
+}
+
+func TestHeadOnCollisionAndFood(t *testing.T) {
+	m, _ := fuzzMaster(t)
+	m.Node.Mu.Lock()
+	defer m.Node.Mu.Unlock()
+
+	m.Node.State.Foods = []*pb.GameState_Coord{at(3, 10)}
+	m.Node.State.Snakes = []*pb.GameState_Snake{
+		snakeAt(1, pb.Direction_RIGHT, at(5, 5), at(4, 5)),
+		snakeAt(2, pb.Direction_LEFT, at(7, 5), at(8, 5)),
+		snakeAt(99, pb.Direction_RIGHT, at(2, 10), at(1, 10)),
+	}
+	token := m.newSession(2)
+	m.rebuildGrid()
+	m.UpdateGameState()
+
+	if len(m.Node.State.GetSnakes()) != 1 {
+		t.Fatalf("head-on collision left snakes %v", m.Node.State.GetSnakes())
+	}
+	// оба убили друг друга, игрок выбыл
+	if score := findPlayer(m, 1).GetScore(); score != 1 {
+		t.Errorf("master has score %d", score)
+	}
+	if findPlayer(m, 2) != nil {
+		t.Error("crashed player is still in the game")
+	}
+	if _, ok := m.sessions[string(token)]; ok {
+		t.Error("crashed player can resume the session")
+	}
+
+	zombie := findSnake(m, 99)
+	if len(zombie.GetPoints()) != 3 {
+		t.Errorf("snake did not grow after eating: %v", zombie.GetPoints())
+	}
+	// еда появляется только на клетках погибших змей и не повторяется
+	corpses := map[cell]bool{{6, 5}: true, {5, 5}: true, {7, 5}: true}
+	seen := make(map[cell]bool)
+	for _, food := range m.Node.State.GetFoods() {
+		if !corpses[cellOf(food)] || seen[cellOf(food)] {
+			t.Errorf("unexpected food at %v", food)
+		}
+		seen[cellOf(food)] = true
+	}
+}
+
+func TestSteeringBuffer(t *testing.T) {
+	m, _ := fuzzMaster(t)
+	m.Node.Mu.Lock()
+	defer m.Node.Mu.Unlock()
+
+	m.Node.State.Foods = nil
+	m.Node.State.Snakes = []*pb.GameState_Snake{snakeAt(2, pb.Direction_RIGHT, at(5, 5), at(4, 5))}
+	m.rebuildGrid()
+	snake := m.Node.State.Snakes[0]
+	tick := func(want pb.Direction, head *pb.GameState_Coord) {
+		t.Helper()
+		m.UpdateGameState()
+		if snake.GetHeadDirection() != want || !proto.Equal(snake.GetPoints()[0], head) {
+			t.Fatalf("snake faces %v at %v, want %v at %v", snake.GetHeadDirection(), snake.GetPoints()[0], want, head)
+		}
+	}
+
+	// два нажатия за один ход: поворот вверх, а налево только на следующем ходу
+	m.Steer(2, pb.Direction_UP)
+	m.Steer(2, pb.Direction_LEFT)
+	tick(pb.Direction_UP, at(5, 4))
+	tick(pb.Direction_LEFT, at(4, 4))
+
+	// разворот в шею пропускается, следующий поворот из очереди применяется в тот же ход
+	m.Steer(2, pb.Direction_RIGHT)
+	m.Steer(2, pb.Direction_DOWN)
+	tick(pb.Direction_DOWN, at(4, 5))
+
+	// в очереди не больше steerBuffer поворотов, повторы не занимают места
+	for _, direction := range []pb.Direction{pb.Direction_LEFT, pb.Direction_LEFT, pb.Direction_UP, pb.Direction_RIGHT, pb.Direction_DOWN} {
+		m.Steer(2, direction)
+	}
+	if turns := m.turns[2]; len(turns) != steerBuffer {
+		t.Fatalf("queued turns %v", turns)
+	}
+	tick(pb.Direction_LEFT, at(3, 5))
+	tick(pb.Direction_UP, at(3, 4))
+	tick(pb.Direction_RIGHT, at(4, 4))
+	tick(pb.Direction_RIGHT, at(5, 4))
+	if _, ok := m.turns[2]; ok {
+		t.Fatal("empty turn queue was kept")
+	}
+}
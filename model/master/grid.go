@@ -0,0 +1,300 @@
+package master
+
+import (
+	"SnakeGame/model/maps"
+	pb "SnakeGame/model/proto"
+	"google.golang.org/protobuf/proto"
+	"math/rand"
+)
+
+// grid занятость клеток поля змеями и едой. Обновляется вместе с состоянием игры и держит
+// свободные клетки для еды и свободные квадраты spawnSize×spawnSize для новых змей,
+// поэтому и клетка, и квадрат находятся за O(1). Поле склеено по краям, как и движение змей,
+// а если края - стены, квадраты через край не подходят для новых змей.
+// Препятствия и порталы карты заняты всегда: на них не появляются ни еда, ни новые змеи
+type grid struct {
+	width, height int32
+	walls         bool
+
+	// сколько клеток змей в клетке поля: при встрече голов или укусе их больше одной
+	snakes    []int32
+	food      []bool
+	obstacles []bool
+	// клетки, где может появиться голова новой змеи; nil - любые
+	spawnZones []bool
+	// парная клетка портала, -1 - в клетке портала нет; nil - порталов нет
+	portals []int32
+	// клетки без змей, еды, препятствий и порталов
+	freeCells indexSet
+
+	// занятых клеток в квадрате с левым верхним углом в клетке; пусто, если поле меньше квадрата
+	squareUsed  []int32
+	freeSquares indexSet
+}
+
+// newGrid пустое поле по параметрам игры: стены и препятствия карты
+func newGrid(config *pb.GameConfig) *grid {
+	width, height := config.GetWidth(), config.GetHeight()
+	cells := int(max(width, 0) * max(height, 0))
+	g := &grid{
+		width:     width,
+		height:    height,
+		walls:     config.GetSolidWalls(),
+		snakes:    make([]int32, cells),
+		food:      make([]bool, cells),
+		obstacles: make([]bool, cells),
+		freeCells: newFullIndexSet(cells),
+	}
+	if len(config.GetGameMap().GetSpawnZones()) > 0 {
+		g.spawnZones = make([]bool, cells)
+		for i := range g.spawnZones {
+			g.spawnZones[i] = maps.SpawnZone(config, int32(i)%width, int32(i)/width)
+		}
+	}
+	if width >= spawnSize && height >= spawnSize {
+		g.squareUsed = make([]int32, cells)
+		g.freeSquares = newFullIndexSet(cells)
+		for square := int32(0); square < int32(cells); square++ {
+			if !g.squareFits(square) {
+				g.freeSquares.remove(square)
+			}
+		}
+	}
+	for i := int32(0); i < int32(cells); i++ {
+		if maps.Obstacle(config, i%width, i/width) {
+			g.update(g.coord(i), func(i int32) { g.obstacles[i] = true })
+		}
+	}
+	if portals := config.GetGameMap().GetPortals(); len(portals) > 0 {
+		g.portals = make([]int32, cells)
+		for i := range g.portals {
+			g.portals[i] = -1
+		}
+		for _, portal := range portals {
+			a, b := g.index(portal.GetA()), g.index(portal.GetB())
+			if a < 0 || b < 0 {
+				continue
+			}
+			g.update(portal.GetA(), func(i int32) { g.portals[i] = b })
+			g.update(portal.GetB(), func(i int32) { g.portals[i] = a })
+		}
+	}
+	return g
+}
+
+// newGridFromState сетка по змеям и еде в state
+func newGridFromState(state *pb.GameState, config *pb.GameConfig) *grid {
+	g := newGrid(config)
+	for _, snake := range state.GetSnakes() {
+		g.addSnake(snake)
+	}
+	for _, food := range state.GetFoods() {
+		g.addFood(food)
+	}
+	return g
+}
+
+// index номер клетки, -1 для координат за пределами поля
+func (g *grid) index(coord *pb.GameState_Coord) int32 {
+	x, y := coord.GetX(), coord.GetY()
+	if x < 0 || x >= g.width || y < 0 || y >= g.height {
+		return -1
+	}
+	return y*g.width + x
+}
+
+func (g *grid) coord(index int32) *pb.GameState_Coord {
+	return &pb.GameState_Coord{X: proto.Int32(index % g.width), Y: proto.Int32(index / g.width)}
+}
+
+func (g *grid) occupied(index int32) bool {
+	return g.snakes[index] > 0 || g.food[index] || g.obstacles[index] || (g.portals != nil && g.portals[index] >= 0)
+}
+
+func (g *grid) addSnake(snake *pb.GameState_Snake) {
+	for _, point := range snake.GetPoints() {
+		g.addSnakeCell(point)
+	}
+}
+
+func (g *grid) removeSnake(snake *pb.GameState_Snake) {
+	for _, point := range snake.GetPoints() {
+		g.removeSnakeCell(point)
+	}
+}
+
+func (g *grid) addSnakeCell(coord *pb.GameState_Coord) {
+	g.update(coord, func(i int32) { g.snakes[i]++ })
+}
+
+func (g *grid) removeSnakeCell(coord *pb.GameState_Coord) {
+	g.update(coord, func(i int32) { g.snakes[i] = max(g.snakes[i]-1, 0) })
+}
+
+func (g *grid) addFood(coord *pb.GameState_Coord) {
+	g.update(coord, func(i int32) { g.food[i] = true })
+}
+
+func (g *grid) removeFood(coord *pb.GameState_Coord) {
+	g.update(coord, func(i int32) { g.food[i] = false })
+}
+
+// snakeCells сколько клеток змей в клетке поля
+func (g *grid) snakeCells(coord *pb.GameState_Coord) int32 {
+	if i := g.index(coord); i >= 0 {
+		return g.snakes[i]
+	}
+	return 0
+}
+
+func (g *grid) isFood(coord *pb.GameState_Coord) bool {
+	i := g.index(coord)
+	return i >= 0 && g.food[i]
+}
+
+// portalExit парная клетка портала в клетке coord
+func (g *grid) portalExit(coord *pb.GameState_Coord) (*pb.GameState_Coord, bool) {
+	i := g.index(coord)
+	if i < 0 || g.portals == nil || g.portals[i] < 0 {
+		return nil, false
+	}
+	return g.coord(g.portals[i]), true
+}
+
+// deadly убивает ли клетка змею, чья голова в неё попала: за стеной или на препятствии
+func (g *grid) deadly(coord *pb.GameState_Coord) bool {
+	i := g.index(coord)
+	return i < 0 || g.obstacles[i]
+}
+
+// isFree нет ли в клетке ни змеи, ни еды, ни препятствия, ни портала
+func (g *grid) isFree(coord *pb.GameState_Coord) bool {
+	i := g.index(coord)
+	return i >= 0 && !g.occupied(i)
+}
+
+// update меняет клетку и, если она стала занятой или свободной, списки свободных клеток и квадратов
+func (g *grid) update(coord *pb.GameState_Coord, change func(i int32)) {
+	i := g.index(coord)
+	if i < 0 {
+		return
+	}
+	before := g.occupied(i)
+	change(i)
+	after := g.occupied(i)
+	if before == after {
+		return
+	}
+
+	delta := int32(1)
+	if after {
+		g.freeCells.remove(i)
+	} else {
+		g.freeCells.add(i)
+		delta = -1
+	}
+	if g.squareUsed == nil {
+		return
+	}
+	// клетка входит в квадраты с углами на spawnSize-1 клеток левее и выше
+	x, y := i%g.width, i/g.width
+	for dx := int32(0); dx < spawnSize; dx++ {
+		for dy := int32(0); dy < spawnSize; dy++ {
+			square := ((y-dy+g.height)%g.height)*g.width + (x-dx+g.width)%g.width
+			g.squareUsed[square] += delta
+			if g.squareUsed[square] == 0 && g.squareFits(square) {
+				g.freeSquares.add(square)
+			} else {
+				g.freeSquares.remove(square)
+			}
+		}
+	}
+}
+
+// squareFits квадрат с углом в клетке square не переходит через стену, а его центр в месте появления змей
+func (g *grid) squareFits(square int32) bool {
+	x, y := square%g.width, square/g.width
+	if g.walls && (x > g.width-spawnSize || y > g.height-spawnSize) {
+		return false
+	}
+	return g.spawnZones == nil || g.spawnZones[((y+spawnSize/2)%g.height)*g.width+(x+spawnSize/2)%g.width]
+}
+
+// randomFreeCell случайная клетка без змей, еды, препятствий и порталов
+func (g *grid) randomFreeCell() (*pb.GameState_Coord, bool) {
+	i, ok := g.freeCells.random()
+	if !ok {
+		return nil, false
+	}
+	return g.coord(i), true
+}
+
+// randomSpawn центр случайного свободного квадрата spawnSize×spawnSize
+func (g *grid) randomSpawn() (*pb.GameState_Coord, bool) {
+	i, ok := g.freeSquares.random()
+	if !ok {
+		return nil, false
+	}
+	return &pb.GameState_Coord{
+		X: proto.Int32((i%g.width + spawnSize/2) % g.width),
+		Y: proto.Int32((i/g.width + spawnSize/2) % g.height),
+	}, true
+}
+
+// spawnFits свободен ли квадрат spawnSize×spawnSize с центром center
+func (g *grid) spawnFits(center *pb.GameState_Coord) bool {
+	x, y := center.GetX(), center.GetY()
+	if x < 0 || x >= g.width || y < 0 || y >= g.height {
+		return false
+	}
+	square := ((y-spawnSize/2+g.height)%g.height)*g.width + (x-spawnSize/2+g.width)%g.width
+	return g.freeSquares.pos[square] >= 0
+}
+
+// indexSet множество номеров клеток с добавлением, удалением и случайным выбором за O(1)
+type indexSet struct {
+	items []int32
+	// место номера в items, -1 - номера в множестве нет
+	pos []int32
+}
+
+// newFullIndexSet множество всех номеров от 0 до size-1
+func newFullIndexSet(size int) indexSet {
+	s := indexSet{items: make([]int32, size), pos: make([]int32, size)}
+	for i := range s.items {
+		s.items[i] = int32(i)
+		s.pos[i] = int32(i)
+	}
+	return s
+}
+
+func (s *indexSet) add(i int32) {
+	if s.pos[i] >= 0 {
+		return
+	}
+	s.pos[i] = int32(len(s.items))
+	s.items = append(s.items, i)
+}
+
+func (s *indexSet) remove(i int32) {
+	p := s.pos[i]
+	if p < 0 {
+		return
+	}
+	last := s.items[len(s.items)-1]
+	s.items[p] = last
+	s.pos[last] = p
+	s.items = s.items[:len(s.items)-1]
+	s.pos[i] = -1
+}
+
+func (s *indexSet) random() (int32, bool) {
+	if len(s.items) == 0 {
+		return 0, false
+	}
+	return s.items[rand.Intn(len(s.items))], true
+}
+
+func (s *indexSet) len() int {
+	return len(s.items)
+}
@@ -0,0 +1,179 @@
+package master
+
+import (
+	pb "SnakeGame/model/proto"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// checkGrid сетка, которую мастер обновлял по ходу игры, совпадает с построенной заново
+func checkGrid(t testing.TB, m *Master) {
+	t.Helper()
+	want := newGridFromState(m.Node.State, m.Node.Config)
+	got := m.grid
+	if !slices.Equal(got.snakes, want.snakes) || !slices.Equal(got.food, want.food) {
+		t.Fatal("grid cells differ from the state")
+	}
+	if !slices.Equal(got.squareUsed, want.squareUsed) {
+		t.Fatal("grid squares differ from the state")
+	}
+	for _, set := range []struct {
+		name      string
+		got, want indexSet
+	}{{"cells", got.freeCells, want.freeCells}, {"squares", got.freeSquares, want.freeSquares}} {
+		if set.got.len() != set.want.len() {
+			t.Fatalf("%d free %s, want %d", set.got.len(), set.name, set.want.len())
+		}
+		for _, i := range set.got.items {
+			if set.want.pos[i] < 0 || set.got.items[set.got.pos[i]] != i {
+				t.Fatalf("free %s index is broken at %d", set.name, i)
+			}
+		}
+	}
+}
+
+// fillMaster заменяет поле мастера на width×height со snakes змеями-зомби в случайных местах
+func fillMaster(m *Master, width, height int32, snakes int) *Master {
+	m.Node.Config.Width = &width
+	m.Node.Config.Height = &height
+	m.Node.State.Foods = nil
+	m.Node.State.Snakes = nil
+	m.rebuildGrid()
+	for i := 0; i < snakes; i++ {
+		center, ok := m.grid.randomSpawn()
+		if !ok {
+			break
+		}
+		snake := newSnake(int32(100+i), center, randomDirection(), m.Node.Config)
+		snake.State = pb.GameState_Snake_ZOMBIE.Enum()
+		m.Node.State.Snakes = append(m.Node.State.Snakes, snake)
+		m.grid.addSnake(snake)
+	}
+	return m
+}
+
+func TestGridFollowsGame(t *testing.T) {
+	m, _ := fuzzMaster(t)
+	m.Node.Mu.Lock()
+	defer m.Node.Mu.Unlock()
+	fillMaster(m, 30, 20, 15)
+	checkGrid(t, m)
+
+	for tick := 0; tick < 300; tick++ {
+		for _, snake := range m.Node.State.Snakes {
+			if rand.Intn(3) == 0 {
+				m.handleSteerMessage(&pb.GameMessage_SteerMsg{Direction: pb.Direction(rand.Intn(4) + 1).Enum()}, snake.GetPlayerId())
+			}
+		}
+		m.GenerateFood()
+		m.UpdateGameState()
+		checkGrid(t, m)
+
+		// на место погибших появляются новые змеи
+		if center, ok := m.grid.randomSpawn(); ok && len(m.Node.State.Snakes) < 10 {
+			snake := newSnake(int32(1000+tick), center, randomDirection(), m.Node.Config)
+			m.Node.State.Snakes = append(m.Node.State.Snakes, snake)
+			m.grid.addSnake(snake)
+		}
+	}
+}
+
+func TestGridFreeCells(t *testing.T) {
+	g := newGrid(spawnConfig(6, 5))
+	if g.freeCells.len() != 30 || g.freeSquares.len() != 30 {
+		t.Fatalf("empty grid has %d free cells and %d free squares", g.freeCells.len(), g.freeSquares.len())
+	}
+	// по клетке в каждом столбце: квадратов 5x5 не остаётся
+	for x := int32(0); x < 6; x++ {
+		g.addSnakeCell(at(x, x%5))
+	}
+	if _, ok := g.randomSpawn(); ok {
+		t.Fatal("spawn found on a blocked field")
+	}
+	// клетка (5, 0) свободна, и квадраты, которые через неё идут, всё равно заняты
+	g.removeSnakeCell(at(5, 0))
+	g.addFood(at(5, 0))
+	for i := 0; i < 100; i++ {
+		c, ok := g.randomFreeCell()
+		if !ok || !g.isFree(c) {
+			t.Fatalf("randomFreeCell = %v, %v", c, ok)
+		}
+	}
+	if g.freeCells.len() != 24 {
+		t.Fatalf("%d free cells, want 24", g.freeCells.len())
+	}
+
+	// две змеи в одной клетке: клетка свободна, только когда уйдут обе
+	g.addSnakeCell(at(0, 4))
+	g.addSnakeCell(at(0, 4))
+	g.removeSnakeCell(at(0, 4))
+	if g.isFree(at(0, 4)) {
+		t.Fatal("cell is free while a snake is still in it")
+	}
+}
+
+func BenchmarkGenerateFood(b *testing.B) {
+	for _, snakes := range []int{10, 100} {
+		b.Run(fmt.Sprintf("snakes=%d", snakes), func(b *testing.B) {
+			m := benchMaster(b, snakes)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// убираем всю еду, чтобы GenerateFood каждый раз раскладывал её заново
+				for _, food := range m.Node.State.Foods {
+					m.grid.removeFood(food)
+				}
+				m.Node.State.Foods = m.Node.State.Foods[:0]
+				m.GenerateFood()
+			}
+		})
+	}
+}
+
+func BenchmarkSpawn(b *testing.B) {
+	for _, snakes := range []int{10, 100} {
+		b.Run(fmt.Sprintf("snakes=%d", snakes), func(b *testing.B) {
+			m := benchMaster(b, snakes)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, ok := m.grid.randomSpawn(); !ok {
+					b.Fatal("no room for a snake")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkTick(b *testing.B) {
+	for _, snakes := range []int{10, 100} {
+		b.Run(fmt.Sprintf("snakes=%d", snakes), func(b *testing.B) {
+			m := benchMaster(b, snakes)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// змеи гибнут и растут, поэтому время от времени начинаем заново
+				if i%500 == 499 {
+					b.StopTimer()
+					benchFill(m, snakes)
+					b.StartTimer()
+				}
+				m.GenerateFood()
+				m.UpdateGameState()
+			}
+		})
+	}
+}
+
+// benchMaster мастер на поле 100x100 со змеями и едой
+func benchMaster(b *testing.B, snakes int) *Master {
+	m, _ := fuzzMaster(b)
+	m.Node.Config.FoodStatic = proto.Int32(100)
+	benchFill(m, snakes)
+	return m
+}
+
+func benchFill(m *Master, snakes int) {
+	fillMaster(m, 100, 100, snakes)
+	m.GenerateFood()
+}
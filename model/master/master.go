@@ -1,40 +1,70 @@
 package master
 
 import (
+	"SnakeGame/connection"
+	"SnakeGame/logging"
+	"SnakeGame/metrics"
 	"SnakeGame/model/common"
 	pb "SnakeGame/model/proto"
+	"errors"
 	"fmt"
 	"google.golang.org/protobuf/proto"
-	"log"
 	"net"
+	"strings"
 	"time"
 )
 
+// сколько новый мастер повторяет смещённому мастеру, что его заменили, прежде чем забыть его адрес
+const deposedTimeout = 5 * time.Second
+
 type Master struct {
 	Node *common.Node
 
 	announcement *pb.GameAnnouncement
 	players      *pb.GamePlayers
 	lastStateMsg int32
+	// наибольший выданный ID игрока
+	lastPlayerId int32
+	// возможности, о которых договорились с каждым игроком при присоединении
+	capabilities map[int32][]pb.Capability
+	// сессии игроков по токену
+	sessions map[string]*session
+	// ограничение входящих сообщений
+	limiter *rateLimiter
+	// игра на паузе: состояния рассылаются, но змеи стоят
+	paused bool
+	// IP-адреса выгнанных игроков и время, когда их выгнали
+	kicked map[string]time.Time
+	// занятость клеток поля, меняется вместе с Node.State
+	grid *grid
+	// повороты игроков, которые ещё не применены, по одному на ход
+	turns map[int32][]pb.Direction
+	// где появляются новые змеи, nil - в случайном месте
+	spawner Spawner
+	// адреса мастеров, место которых занял этот мастер: если они живы, то должны уступить игру
+	deposed []*net.UDPAddr
+	// когда этот мастер занял их место
+	deposedAt time.Time
+
+	// SessionGrace сколько ждём возвращения отвалившегося игрока с токеном сессии
+	SessionGrace time.Duration
 }
 
-// NewMaster создает нового мастера
-func NewMaster(multicastConn *net.UDPConn, config *pb.GameConfig) *Master {
-	localAddr, err := net.ResolveUDPAddr("udp4", ":0")
-	if err != nil {
-		log.Fatalf("Error resolving local UDP address: %v", err)
-	}
-	unicastConn, err := net.ListenUDP("udp4", localAddr)
-	if err != nil {
-		log.Fatalf("Error creating unicast socket: %v", err)
-	}
+// NewMaster создает нового мастера, unicastConn - сокет для общения с игроками
+func NewMaster(multicastConn connection.Conn, unicastConn connection.Conn, config *pb.GameConfig) (*Master, error) {
+	return NewMasterWithSpawner(multicastConn, unicastConn, config, nil)
+}
 
-	masterIP, err := common.GetLocalIP()
+// NewMasterWithSpawner мастер, у которого змеи, включая змею хоста, появляются там, куда их ставит spawner
+func NewMasterWithSpawner(multicastConn connection.Conn, unicastConn connection.Conn, config *pb.GameConfig, spawner Spawner) (*Master, error) {
+	if err := common.ValidateConfig(config); err != nil {
+		return nil, fmt.Errorf("invalid game config: %w", err)
+	}
+	masterIP, masterPort, err := common.LocalAddr(unicastConn)
 	if err != nil {
-		log.Fatalf("Error getting local IP: %v", err)
+		return nil, fmt.Errorf("error getting local address: %w", err)
 	}
-	masterPort := unicastConn.LocalAddr().(*net.UDPAddr).Port
-	log.Printf("Выделенный локальный адрес: %s:%v\n", masterIP, masterPort)
+	logging.Network.Info("Master socket bound", "ip", masterIP, "port", masterPort)
 
 	masterPlayer := &pb.GamePlayer{
 		Name:      proto.String("Master"),
@@ -57,64 +87,131 @@ func NewMaster(multicastConn *net.UDPConn, config *pb.GameConfig) *Master {
 		Players:    players,
 	}
 
-	masterSnake := &pb.GameState_Snake{
-		PlayerId: proto.Int32(masterPlayer.GetId()),
-		Points: []*pb.GameState_Coord{
-			{
-				X: proto.Int32(config.GetWidth() / 2),
-				Y: proto.Int32(config.GetHeight() / 2),
-			},
-		},
-		State:         pb.GameState_Snake_ALIVE.Enum(),
-		HeadDirection: pb.Direction_RIGHT.Enum(),
+	announcement := newAnnouncement(players, config, "Game1")
+
+	node := common.NewNode(state, config, multicastConn, unicastConn, masterPlayer)
+	node.Role = pb.NodeRole_MASTER
+
+	m := &Master{
+		Node:         node,
+		announcement: announcement,
+		players:      players,
+		lastStateMsg: 0,
+		capabilities: make(map[int32][]pb.Capability),
+		sessions:     make(map[string]*session),
+		limiter:      newRateLimiter(config.GetStateDelayMs()),
+		kicked:       make(map[string]time.Time),
+		turns:        make(map[int32][]pb.Direction),
+		grid:         newGrid(config),
+		spawner:      spawner,
+		SessionGrace: DefaultSessionGrace,
 	}
 
+	// змея хоста появляется по тем же правилам, что и змеи присоединившихся игроков
+	center, direction, ok := m.spawn()
+	if !ok {
+		return nil, fmt.Errorf("field %dx%d has no room for a snake", config.GetWidth(), config.GetHeight())
+	}
+	masterSnake := newSnake(masterPlayer.GetId(), center, direction, config)
 	state.Snakes = append(state.Snakes, masterSnake)
+	m.grid.addSnake(masterSnake)
+	return m, nil
+}
 
-	announcement := &pb.GameAnnouncement{
-		Players:  players,
-		Config:   config,
-		CanJoin:  proto.Bool(true),
-		GameName: proto.String("Game1"),
+func newAnnouncement(players *pb.GamePlayers, config *pb.GameConfig, gameName string) *pb.GameAnnouncement {
+	return &pb.GameAnnouncement{
+		Players:              players,
+		Config:               config,
+		CanJoin:              proto.Bool(true),
+		GameName:             proto.String(gameName),
+		ProtocolVersion:      proto.Int32(common.ProtocolVersion),
+		Capabilities:         common.SupportedCapabilities,
+		RequiredCapabilities: requiredCapabilities(config),
 	}
+}
 
-	node := common.NewNode(state, config, multicastConn, unicastConn, masterPlayer)
+// возможности, без которых нельзя играть с такими параметрами игры
+func requiredCapabilities(config *pb.GameConfig) []pb.Capability {
+	required := []pb.Capability{}
+	// старый узел не знает о стенах и считал бы поле склеенным по краям
+	if config.GetSolidWalls() {
+		required = append(required, pb.Capability_SOLID_WALLS)
+	}
+	// и не увидел бы препятствий
+	if config.GetGameMap() != nil {
+		required = append(required, pb.Capability_OBSTACLES)
+	}
+	// а змеи, прошедшие через портал, показывал бы разорванными без самих порталов
+	if len(config.GetGameMap().GetPortals()) > 0 {
+		required = append(required, pb.Capability_PORTALS)
+	}
+	return required
+}
+
+// NewDeputyMaster создает мастера на узле заместителя, который заменяет отвалившегося мастера,
+// или на узле, выбранном новым мастером, когда пропали и мастер, и заместитель.
+// Состояние, сокеты и счётчики сообщений остаются от узла игрока
+func NewDeputyMaster(node *common.Node, gameName string, lastStateMsg int32) *Master {
+	node.Role = pb.NodeRole_MASTER
+	node.PlayerInfo.Role = pb.NodeRole_MASTER.Enum()
+
+	players := node.State.GetPlayers()
+	if players == nil {
+		players = &pb.GamePlayers{}
+		node.State.Players = players
+	}
 
-	return &Master{
+	// возможности игроков новому мастеру неизвестны, до переподключения с ними говорим на базовом протоколе
+	announcement := newAnnouncement(players, node.Config, gameName)
+
+	m := &Master{
 		Node:         node,
 		announcement: announcement,
 		players:      players,
-		lastStateMsg: 0,
+		lastStateMsg: lastStateMsg,
+		capabilities: make(map[int32][]pb.Capability),
+		sessions:     make(map[string]*session),
+		limiter:      newRateLimiter(node.Config.GetStateDelayMs()),
+		kicked:       make(map[string]time.Time),
+		turns:        make(map[int32][]pb.Direction),
+		grid:         newGridFromState(node.State, node.Config),
+		SessionGrace: DefaultSessionGrace,
 	}
-}
 
-//func NewDeputyMaster(node *common.Node, newMaster *pb.GamePlayer, lastStateMsg int64) *Master {
-//	newMaster.Role = pb.NodeRole_MASTER.Enum()
-//
-//	config := node.Config
-//
-//	unicastConn := node.UnicastConn
-//
-//	state := node.State
-//
-//	announcement := &pb.GameAnnouncement{
-//		Players:  state.Players,
-//		Config:   config,
-//		CanJoin:  proto.Bool(true),
-//		GameName: proto.String("Game"),
-//	}
-//
-//	multicastConn := node.MulticastConn
-//
-//	newNode := common.NewNode(state, config, multicastConn, unicastConn, newMaster)
-//
-//	return &Master{
-//		Node:         newNode,
-//		announcement: announcement,
-//		players:      state.Players,
-//		lastStateMsg: lastStateMsg,
-//	}
-//}
+	// при выборах нового мастера вместе со старым пропал и заместитель
+	var lostIds []int32
+	now := time.Now()
+	for _, player := range players.Players {
+		switch {
+		case player.GetId() == node.PlayerInfo.GetId():
+			player.Role = pb.NodeRole_MASTER.Enum()
+		case player.GetRole() == pb.NodeRole_MASTER || player.GetRole() == pb.NodeRole_DEPUTY:
+			lostIds = append(lostIds, player.GetId())
+		default:
+			// даём всем игрокам время заметить нового мастера
+			node.LastInteraction[player.GetId()] = now
+		}
+	}
+
+	// змеи пропавших узлов становятся зомби, сами они выбывают из игры
+	for _, lostId := range lostIds {
+		delete(node.LastInteraction, lostId)
+		m.makeSnakeZombie(lostId)
+		for i, player := range players.Players {
+			if player.GetId() == lostId {
+				if player.GetRole() == pb.NodeRole_MASTER {
+					if addr, err := common.ResolvePlayerAddr(player); err == nil {
+						m.deposed = append(m.deposed, addr)
+					}
+				}
+				players.Players = append(players.Players[:i], players.Players[i+1:]...)
+				break
+			}
+		}
+	}
+
+	return m
+}
 
 // Start запуск мастера
 func (m *Master) Start() {
@@ -127,12 +224,58 @@ func (m *Master) Start() {
 	go m.Node.SendPings(m.Node.Config.GetStateDelayMs())
 }
 
+// Takeover запуск мастера на узле бывшего заместителя: игроки и смещённый мастер получают RoleChangeMsg
+// о новом мастере, назначается новый заместитель. Сокеты по-прежнему читает игрок,
+// который передаёт сообщения в HandleMessage. Вызывается под Node.Mu
+func (m *Master) Takeover() {
+	// мастер мог не упасть, а потерять связь с игроками: узнав о новом мастере, он уступит игру
+	m.deposedAt = time.Now()
+	for _, addr := range append(m.getAllPlayersUDPAddrs(), m.deposed...) {
+		roleChangeMsg := &pb.GameMessage{
+			Type: &pb.GameMessage_RoleChange{
+				RoleChange: &pb.GameMessage_RoleChangeMsg{
+					SenderRole: pb.NodeRole_MASTER.Enum(),
+				},
+			},
+		}
+		m.Node.SendMessage(roleChangeMsg, addr)
+	}
+	m.checkAndAssignDeputy()
+	logging.Engine.Info("Took over as MASTER", logging.PlayerID(m.Node.PlayerInfo.GetId()))
+
+	go m.sendAnnouncementMessage()
+	go m.checkTimeouts()
+	go m.sendStateMessage()
+}
+
+// HandleMessage обработка юникаст сообщения, полученного узлом после Takeover. Вызывается под Node.Mu
+func (m *Master) HandleMessage(msg *pb.GameMessage, addr *net.UDPAddr) {
+	m.handleMessage(msg, addr)
+}
+
+// HandleMulticastMessage обработка мультикаст сообщения, полученного узлом после Takeover. Вызывается под Node.Mu
+func (m *Master) HandleMulticastMessage(msg *pb.GameMessage, addr *net.UDPAddr) {
+	m.handleMulticastMessage(msg, addr)
+}
+
+// Stop остановка мастера: фоновые циклы завершаются, unicast-сокет закрывается
+func (m *Master) Stop() {
+	m.Node.Close()
+}
+
 // отправка AnnouncementMsg
 func (m *Master) sendAnnouncementMessage() {
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-m.Node.Done():
+			return
+		case <-ticker.C:
+		}
+
+		m.Node.Mu.Lock()
 		announcementMsg := &pb.GameMessage{
 			MsgSeq: proto.Int64(1),
 			Type: &pb.GameMessage_Announcement{
@@ -143,40 +286,51 @@ func (m *Master) sendAnnouncementMessage() {
 		}
 		multicastAddr, err := net.ResolveUDPAddr("udp", m.Node.MulticastAddress)
 		if err != nil {
-			log.Fatalf("Error resolving multicast address: %v", err)
+			logging.Network.Error("Error resolving multicast address", logging.Err(err))
+		} else {
+			m.Node.SendMessage(announcementMsg, multicastAddr)
 		}
-		m.Node.SendMessage(announcementMsg, multicastAddr)
+		m.Node.Mu.Unlock()
 	}
 }
 
 // получение мультикаст сообщений
 func (m *Master) receiveMulticastMessages() {
+	// буфер общий: proto.Unmarshal копирует из него всё, что нужно сообщению
+	buf := make([]byte, 4096)
 	for {
-		buf := make([]byte, 4096)
 		n, addr, err := m.Node.MulticastConn.ReadFromUDP(buf)
+		if m.Node.Closed() || errors.Is(err, net.ErrClosed) {
+			return
+		}
 		if err != nil {
-			log.Printf("Error receiving multicast message: %v", err)
+			logging.Network.Error("Error receiving multicast message", logging.Err(err))
 			continue
 		}
 
 		var msg pb.GameMessage
 		err = proto.Unmarshal(buf[:n], &msg)
 		if err != nil {
-			log.Printf("Error unmarshalling multicast message: %v", err)
+			logging.Network.Warn("Error unmarshalling multicast message", logging.Addr(addr), logging.Err(err))
 			continue
 		}
+		metrics.MessagesReceived.Inc(logging.MessageType(&msg))
 
+		m.Node.Mu.Lock()
 		m.handleMulticastMessage(&msg, addr)
+		m.Node.Mu.Unlock()
 	}
 }
 
 // обработка мультикаст сообщений
 func (m *Master) handleMulticastMessage(msg *pb.GameMessage, addr *net.UDPAddr) {
+	if !m.limiter.allow(msg, addr, m.Node.GetPlayerIdByAddress(addr) > 0, time.Now()) {
+		return
+	}
 	switch /*t :=*/ msg.Type.(type) {
 	case *pb.GameMessage_Discover:
 		// пришел DiscoverMsg отправляем AnnouncementMsg
 		announcementMsg := &pb.GameMessage{
-			MsgSeq: proto.Int64(m.Node.MsgSeq),
 			Type: &pb.GameMessage_Announcement{
 				Announcement: &pb.GameMessage_AnnouncementMsg{
 					Games: []*pb.GameAnnouncement{m.announcement},
@@ -190,74 +344,80 @@ func (m *Master) handleMulticastMessage(msg *pb.GameMessage, addr *net.UDPAddr)
 
 // получение юникаст сообщений
 func (m *Master) receiveMessages() {
+	buf := make([]byte, 4096)
 	for {
-		buf := make([]byte, 4096)
 		n, addr, err := m.Node.UnicastConn.ReadFromUDP(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
 		if err != nil {
-			log.Printf("Error receiving message: %v", err)
+			logging.Network.Error("Error receiving message", logging.Err(err))
 			continue
 		}
 
 		var msg pb.GameMessage
 		err = proto.Unmarshal(buf[:n], &msg)
 		if err != nil {
-			log.Printf("Error unmarshalling message: %v", err)
+			logging.Network.Warn("Error unmarshalling message", logging.Addr(addr), logging.Err(err))
 			continue
 		}
+		metrics.MessagesReceived.Inc(logging.MessageType(&msg))
 
-		if m.Node.PlayerInfo.GetIpAddress() == addr.IP.String() && m.Node.PlayerInfo.GetPort() == int32(addr.Port) {
-			log.Printf("Get msg from itself")
+		if common.SameAddress(m.Node.PlayerInfo, addr) {
+			logging.Network.Debug("Ignoring message from itself", logging.Message(&msg))
 			continue
 		}
+		m.Node.Mu.Lock()
 		m.handleMessage(&msg, addr)
+		m.Node.Mu.Unlock()
 	}
 }
 
 // обработка юникаст сообщения
 func (m *Master) handleMessage(msg *pb.GameMessage, addr *net.UDPAddr) {
-	if msg.GetSenderId() > 0 {
-		m.Node.LastInteraction[msg.GetSenderId()] = time.Now()
+	// отправителя определяем по адресу: sender_id в сообщении ничем не подтверждён
+	senderId := m.Node.GetPlayerIdByAddress(addr)
+	if !m.limiter.allow(msg, addr, senderId > 0, time.Now()) {
+		return
+	}
+	logging.Network.Debug("Received message", logging.Message(msg), logging.PlayerID(senderId), logging.Addr(addr))
+	if senderId <= 0 && msg.GetAck() == nil && msg.GetSessionTag() != nil {
+		// подпись сессии позволяет узнать игрока, сменившего адрес
+		senderId = m.migratePlayer(msg, addr)
+	}
+	if senderId > 0 {
+		m.Node.LastInteraction[senderId] = time.Now()
+		m.noteMsgSeq(senderId, msg)
 	}
 	switch t := msg.Type.(type) {
 	case *pb.GameMessage_Join:
-		// проверяем есть ли место 5*5 для новой змеи
-		hasSquare, coord := m.hasFreeSquare(m.Node.State, m.Node.Config, 5)
-
-		if !hasSquare {
-			m.announcement.CanJoin = proto.Bool(false)
-			m.handleErrorMsg(addr)
-			log.Printf("Player cannot join: no available space")
-			m.Node.SendAck(msg, addr)
-		} else {
-			// обрабатываем joinMsg
-			joinMsg := t.Join
-			m.handleJoinMessage(msg.GetMsgSeq(), joinMsg, addr, coord)
-		}
+		m.handleJoin(msg, t.Join, senderId, addr)
 
 	case *pb.GameMessage_Discover:
 		m.handleDiscoverMessage(addr)
 
 	case *pb.GameMessage_Steer:
-		playerId := msg.GetSenderId()
-		if playerId == 0 {
-			playerId = m.Node.GetPlayerIdByAddress(addr)
-		}
-		if playerId != 0 {
-			m.handleSteerMessage(t.Steer, playerId)
+		if senderId > 0 {
+			m.handleSteerMessage(t.Steer, senderId)
 			m.Node.SendAck(msg, addr)
 		} else {
-			log.Printf("SteerMsg received from unknown address: %v", addr)
+			logging.Engine.Warn("Steer from unknown address", logging.Message(msg), logging.Addr(addr))
 		}
 
 	case *pb.GameMessage_RoleChange:
-		m.handleRoleChangeMessage(msg, addr)
-		m.Node.SendAck(msg, addr)
+		if senderId > 0 {
+			// подтверждаем до обработки: уступив игру, мастер закрывает сокет
+			m.Node.SendAck(msg, addr)
+			m.handleRoleChangeMessage(msg, senderId)
+		} else {
+			logging.Engine.Warn("Role change from unknown address", logging.Message(msg), logging.Addr(addr))
+		}
 
 	case *pb.GameMessage_Ping:
 		m.Node.SendAck(msg, addr)
 
 	case *pb.GameMessage_Ack:
-		m.Node.AckChan <- msg.GetMsgSeq()
+		m.Node.HandleAck(msg.GetMsgSeq())
 
 	case *pb.GameMessage_State:
 		if t.State.GetState().GetStateOrder() <= m.lastStateMsg {
@@ -268,7 +428,51 @@ func (m *Master) handleMessage(msg *pb.GameMessage, addr *net.UDPAddr) {
 		m.Node.SendAck(msg, addr)
 
 	default:
-		log.Printf("Received unknown message type from %v", addr)
+		logging.Network.Warn("Received unknown message type", logging.Message(msg), logging.Addr(addr))
+	}
+}
+
+// обработка JoinMsg
+func (m *Master) handleJoin(msg *pb.GameMessage, joinMsg *pb.GameMessage_JoinMsg, senderId int32, addr *net.UDPAddr) {
+	switch {
+	case m.resumeSession(msg.GetMsgSeq(), joinMsg.GetSessionToken(), addr):
+		// игрок вернулся по токену сессии
+
+	case senderId > 0:
+		// повторный JoinMsg, например потерялся наш Ack: подтверждаем с тем же ID
+		m.sendJoinAck(msg.GetMsgSeq(), senderId, addr)
+
+	case m.isKicked(addr):
+		m.handleErrorMsg(addr, "Cannot join: you have been kicked by the host")
+
+	case m.Node.Config == nil || m.Node.State == nil:
+		m.handleErrorMsg(addr, "Cannot join: game is not configured yet")
+
+	case len(common.MissingCapabilities(m.announcement.GetRequiredCapabilities(), joinMsg.GetCapabilities())) > 0:
+		// старый или урезанный клиент не сможет правильно показать такую игру
+		missing := common.MissingCapabilities(m.announcement.GetRequiredCapabilities(), joinMsg.GetCapabilities())
+		m.handleErrorMsg(addr, fmt.Sprintf("Cannot join: client does not support %v", missing))
+		logging.Engine.Info("Player cannot join: missing capabilities", logging.Addr(addr), "missing", missing)
+
+	case joinMsg.GetRequestedRole() == pb.NodeRole_VIEWER:
+		// наблюдателю змея не нужна
+		m.handleJoinMessage(msg.GetMsgSeq(), joinMsg, addr, nil, 0)
+
+	case joinMsg.GetRequestedRole() != pb.NodeRole_NORMAL:
+		m.handleErrorMsg(addr, "Cannot join: requested role must be NORMAL or VIEWER")
+
+	default:
+		// проверяем есть ли место 5*5 для новой змеи
+		center, direction, hasSquare := m.spawn()
+
+		if !hasSquare {
+			m.announcement.CanJoin = proto.Bool(false)
+			m.handleErrorMsg(addr, "Cannot join: no available space")
+			logging.Engine.Info("Player cannot join: no available space", logging.Addr(addr))
+		} else {
+			// обрабатываем joinMsg
+			m.handleJoinMessage(msg.GetMsgSeq(), joinMsg, addr, center, direction)
+		}
 	}
 }
 
@@ -277,20 +481,34 @@ func (m *Master) sendStateMessage() {
 	ticker := time.NewTicker(time.Duration(m.Node.Config.GetStateDelayMs()) * time.Millisecond)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		m.GenerateFood()
-		m.UpdateGameState()
+	for {
+		select {
+		case <-m.Node.Done():
+			return
+		case <-ticker.C:
+		}
+
+		m.Node.Mu.Lock()
+		// мастер мог уступить игру, пока ждал блокировку
+		if m.Node.Closed() {
+			m.Node.Mu.Unlock()
+			return
+		}
+		start := time.Now()
+		if !m.paused {
+			m.GenerateFood()
+			m.UpdateGameState()
+		}
 
 		newStateOrder := m.Node.State.GetStateOrder() + 1
 		m.Node.State.StateOrder = proto.Int32(newStateOrder)
 
 		stateMsg := &pb.GameMessage{
-			MsgSeq: proto.Int64(m.Node.MsgSeq),
 			Type: &pb.GameMessage_State{
 				State: &pb.GameMessage_StateMsg{
 					State: &pb.GameState{
 						StateOrder: proto.Int32(newStateOrder),
-						Snakes:     m.Node.State.GetSnakes(),
+						Snakes:     common.EncodeSnakes(m.Node.State.GetSnakes(), m.Node.Config),
 						Foods:      m.Node.State.GetFoods(),
 						Players:    m.Node.State.GetPlayers(),
 					},
@@ -299,9 +517,36 @@ func (m *Master) sendStateMessage() {
 		}
 		allAddrs := m.getAllPlayersUDPAddrs()
 		m.sendMessageToAllPlayers(stateMsg, allAddrs)
+		m.observeTick(stateMsg, time.Since(start))
+		m.Node.Mu.Unlock()
 	}
 }
 
+// observeTick метрики хода: длительность, состав игры и размер состояния
+func (m *Master) observeTick(stateMsg *pb.GameMessage, duration time.Duration) {
+	metrics.Ticks.Inc()
+	metrics.TickDuration.Observe(duration.Seconds())
+
+	roles := make(map[pb.NodeRole]int)
+	for _, player := range m.players.Players {
+		roles[player.GetRole()]++
+	}
+	for role, name := range pb.NodeRole_name {
+		metrics.Players.Set(float64(roles[pb.NodeRole(role)]), strings.ToLower(name))
+	}
+
+	snakes := make(map[pb.GameState_Snake_SnakeState]int)
+	for _, snake := range m.Node.State.GetSnakes() {
+		snakes[snake.GetState()]++
+	}
+	for state, name := range pb.GameState_Snake_SnakeState_name {
+		metrics.Snakes.Set(float64(snakes[pb.GameState_Snake_SnakeState(state)]), strings.ToLower(name))
+	}
+
+	metrics.Food.Set(float64(len(m.Node.State.GetFoods())))
+	metrics.StateSize.Set(float64(proto.Size(stateMsg)))
+}
+
 // получение списка адресов всех игроков (кроме мастера)
 func (m *Master) getAllPlayersUDPAddrs() []*net.UDPAddr {
 	var addrs []*net.UDPAddr
@@ -310,10 +555,9 @@ func (m *Master) getAllPlayersUDPAddrs() []*net.UDPAddr {
 		if player.GetId() == m.Node.PlayerInfo.GetId() {
 			continue
 		}
-		addrStr := fmt.Sprintf("%s:%d", player.GetIpAddress(), player.GetPort())
-		addr, err := net.ResolveUDPAddr("udp", addrStr)
+		addr, err := common.ResolvePlayerAddr(player)
 		if err != nil {
-			log.Printf("Error resolving UDP address for player ID %d: %v", player.GetId(), err)
+			logging.Network.Error("Error resolving player address", logging.PlayerID(player.GetId()), logging.Err(err))
 			continue
 		}
 		addrs = append(addrs, addr)
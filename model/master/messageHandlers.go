@@ -1,31 +1,34 @@
 package master
 
 import (
+	"SnakeGame/logging"
+	"SnakeGame/metrics"
+	"SnakeGame/model/common"
 	pb "SnakeGame/model/proto"
-	"fmt"
 	"google.golang.org/protobuf/proto"
-	"log"
 	"net"
 	"time"
 )
 
-func (m *Master) handleErrorMsg(addr *net.UDPAddr) {
+func (m *Master) handleErrorMsg(addr *net.UDPAddr, text string) {
 	errorMsg := &pb.GameMessage{
 		Type: &pb.GameMessage_Error{
 			Error: &pb.GameMessage_ErrorMsg{
-				ErrorMessage: proto.String("Cannot join: no available space"),
+				ErrorMessage: proto.String(text),
 			},
 		},
 	}
 	m.Node.SendMessage(errorMsg, addr)
 }
 
-func (m *Master) handleJoinMessage(msgSeq int64, joinMsg *pb.GameMessage_JoinMsg, addr *net.UDPAddr, coord *pb.GameState_Coord) {
-	newPlayerID := int32(len(m.players.Players) + 1)
+// обработка принятого JoinMsg, center - центр свободного квадрата для змеи, у наблюдателя nil,
+// direction - куда смотрит голова змеи
+func (m *Master) handleJoinMessage(msgSeq int64, joinMsg *pb.GameMessage_JoinMsg, addr *net.UDPAddr, center *pb.GameState_Coord, direction pb.Direction) {
+	newPlayerID := m.newPlayerId()
 	newPlayer := &pb.GamePlayer{
 		Name:      proto.String(joinMsg.GetPlayerName()),
 		Id:        proto.Int32(newPlayerID),
-		IpAddress: proto.String(addr.IP.String()),
+		IpAddress: proto.String(common.IPString(addr)),
 		Port:      proto.Int32(int32(addr.Port)),
 		Role:      joinMsg.GetRequestedRole().Enum(),
 		Type:      joinMsg.GetPlayerType().Enum(),
@@ -33,20 +36,51 @@ func (m *Master) handleJoinMessage(msgSeq int64, joinMsg *pb.GameMessage_JoinMsg
 	}
 	m.players.Players = append(m.players.Players, newPlayer)
 	m.Node.State.Players = m.players
+	m.Node.LastInteraction[newPlayerID] = time.Now()
+	m.capabilities[newPlayerID] = common.NegotiateCapabilities(common.SupportedCapabilities, joinMsg.GetCapabilities())
+	if m.playerSupports(newPlayerID, pb.Capability_SESSIONS) {
+		m.newSession(newPlayerID)
+	}
+
+	m.sendJoinAck(msgSeq, newPlayerID, addr)
+	if center != nil {
+		snake := newSnake(newPlayerID, center, direction, m.Node.Config)
+		m.Node.State.Snakes = append(m.Node.State.Snakes, snake)
+		m.grid.addSnake(snake)
+	}
+	m.checkAndAssignDeputy()
 
+	logging.Engine.Info("New player joined", logging.PlayerID(newPlayerID), "name", newPlayer.GetName(),
+		"role", newPlayer.GetRole(), logging.Addr(addr))
+}
+
+// подтверждение JoinMsg, в receiver_id игрок узнаёт свой ID, а в самом Ack - о чём договорились
+func (m *Master) sendJoinAck(msgSeq int64, playerId int32, addr *net.UDPAddr) {
 	ackMsg := &pb.GameMessage{
 		MsgSeq:     proto.Int64(msgSeq),
-		SenderId:   proto.Int32(1),
-		ReceiverId: proto.Int32(newPlayerID),
+		SenderId:   proto.Int32(m.Node.PlayerInfo.GetId()),
+		ReceiverId: proto.Int32(playerId),
 		Type: &pb.GameMessage_Ack{
-			Ack: &pb.GameMessage_AckMsg{},
+			Ack: &pb.GameMessage_AckMsg{
+				ProtocolVersion: proto.Int32(common.ProtocolVersion),
+				Capabilities:    m.capabilities[playerId],
+				SessionToken:    m.sessionToken(playerId),
+			},
 		},
 	}
 	m.Node.SendMessage(ackMsg, addr)
-	m.addSnakeForNewPlayer(newPlayerID, coord)
-	m.checkAndAssignDeputy()
+}
 
-	log.Printf("New player joined, ID: %v", newPlayer)
+// новый ID игрока: никогда не повторяет ID текущих и выбывших игроков, а также зомби
+func (m *Master) newPlayerId() int32 {
+	for _, player := range m.players.Players {
+		m.lastPlayerId = max(m.lastPlayerId, player.GetId())
+	}
+	for _, snake := range m.Node.State.Snakes {
+		m.lastPlayerId = max(m.lastPlayerId, snake.GetPlayerId())
+	}
+	m.lastPlayerId++
+	return m.lastPlayerId
 }
 
 // назначение заместителя
@@ -73,26 +107,9 @@ func (m *Master) hasDeputy() bool {
 	return false
 }
 
-func (m *Master) addSnakeForNewPlayer(playerID int32, coord *pb.GameState_Coord) {
-	newSnake := &pb.GameState_Snake{
-		PlayerId: proto.Int32(playerID),
-		Points: []*pb.GameState_Coord{
-			{
-				X: proto.Int32(coord.GetX()),
-				Y: proto.Int32(coord.GetY()),
-			},
-		},
-		State:         pb.GameState_Snake_ALIVE.Enum(),
-		HeadDirection: pb.Direction_RIGHT.Enum(),
-	}
-
-	m.Node.State.Snakes = append(m.Node.State.Snakes, newSnake)
-}
-
 func (m *Master) handleDiscoverMessage(addr *net.UDPAddr) {
-	log.Printf("Received DiscoverMsg from %v via unicast", addr)
+	logging.Network.Debug("Received discover via unicast", logging.Addr(addr))
 	announcementMsg := &pb.GameMessage{
-		MsgSeq: proto.Int64(m.Node.MsgSeq),
 		Type: &pb.GameMessage_Announcement{
 			Announcement: &pb.GameMessage_AnnouncementMsg{
 				Games: []*pb.GameAnnouncement{m.announcement},
@@ -103,6 +120,13 @@ func (m *Master) handleDiscoverMessage(addr *net.UDPAddr) {
 	m.Node.SendMessage(announcementMsg, addr)
 }
 
+// Steer поворот змеи игрока, например самого мастера. Вызывается под Node.Mu
+func (m *Master) Steer(playerId int32, direction pb.Direction) {
+	m.handleSteerMessage(&pb.GameMessage_SteerMsg{Direction: direction.Enum()}, playerId)
+}
+
+// handleSteerMessage поворот не применяется сразу, а встаёт в очередь игрока: за ход змея
+// поворачивает не больше одного раза, иначе два быстрых нажатия развернули бы её в собственную шею
 func (m *Master) handleSteerMessage(steerMsg *pb.GameMessage_SteerMsg, playerId int32) {
 	var snake *pb.GameState_Snake
 	for _, s := range m.Node.State.Snakes {
@@ -113,34 +137,55 @@ func (m *Master) handleSteerMessage(steerMsg *pb.GameMessage_SteerMsg, playerId
 	}
 
 	if snake == nil {
-		log.Printf("No snake found for player ID: %d", playerId)
+		logging.Engine.Warn("No snake found for steering", logging.PlayerID(playerId))
 		return
 	}
 
 	newDirection := steerMsg.GetDirection()
-	currentDirection := snake.GetHeadDirection()
-
-	isOppositeDirection := func(cur, new pb.Direction) bool {
-		switch cur {
-		case pb.Direction_UP:
-			return new == pb.Direction_DOWN
-		case pb.Direction_DOWN:
-			return new == pb.Direction_UP
-		case pb.Direction_LEFT:
-			return new == pb.Direction_RIGHT
-		case pb.Direction_RIGHT:
-			return new == pb.Direction_LEFT
-		}
-		return false
-	}(currentDirection, newDirection)
+	if _, ok := pb.Direction_name[int32(newDirection)]; !ok {
+		logging.Engine.Warn("Unknown steering direction", logging.PlayerID(playerId), "direction", int32(newDirection))
+		return
+	}
 
-	if isOppositeDirection {
-		log.Printf("Invalid direction change from player ID: %d", playerId)
+	// повтор последнего поворота ничего не меняет
+	turns := m.turns[playerId]
+	last := snake.GetHeadDirection()
+	if len(turns) > 0 {
+		last = turns[len(turns)-1]
+	}
+	if newDirection == last {
 		return
 	}
+	if len(turns) >= steerBuffer {
+		logging.Engine.Debug("Steering buffer is full", logging.PlayerID(playerId), "direction", newDirection)
+		return
+	}
+	m.turns[playerId] = append(turns, newDirection)
+}
 
-	snake.HeadDirection = newDirection.Enum()
-	log.Printf("Player ID: %d changed direction to: %v", playerId, newDirection)
+// applyTurns перед ходом каждая змея берёт из очереди первый допустимый поворот. Поворот
+// проверяется по направлению, в котором змея на самом деле двигалась на прошлом ходу
+func (m *Master) applyTurns() {
+	for _, snake := range m.Node.State.Snakes {
+		playerId := snake.GetPlayerId()
+		turns := m.turns[playerId]
+		for len(turns) > 0 {
+			newDirection := turns[0]
+			turns = turns[1:]
+			if newDirection == snake.GetHeadDirection() || newDirection == oppositeDirection(snake.GetHeadDirection()) {
+				logging.Engine.Debug("Invalid direction change", logging.PlayerID(playerId), "direction", newDirection)
+				continue
+			}
+			snake.HeadDirection = newDirection.Enum()
+			logging.Engine.Debug("Player changed direction", logging.PlayerID(playerId), "direction", newDirection)
+			break
+		}
+		if len(turns) == 0 {
+			delete(m.turns, playerId)
+		} else {
+			m.turns[playerId] = turns
+		}
+	}
 }
 
 // обработка отвалившихся узлов
@@ -148,17 +193,29 @@ func (m *Master) checkTimeouts() {
 	ticker := time.NewTicker(time.Duration(0.8*float64(m.Node.Config.GetStateDelayMs())) * time.Millisecond)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-m.Node.Done():
+			return
+		case <-ticker.C:
+		}
+
+		m.Node.Mu.Lock()
 		now := time.Now()
 		for playerId, lastInteraction := range m.Node.LastInteraction {
 			if playerId == 0 {
 				continue
 			}
 			if now.Sub(lastInteraction) > time.Duration(0.8*float64(m.Node.Config.GetStateDelayMs()))*time.Millisecond {
-				log.Printf("player ID: %d has timeout", playerId)
+				logging.Engine.Info("Player timed out", logging.PlayerID(playerId))
+				metrics.Timeouts.Inc()
 				m.removePlayer(playerId)
 			}
 		}
+		m.expireSessions(now)
+		m.forgetDeposed(now)
+		m.limiter.sweep(now)
+		m.Node.Mu.Unlock()
 	}
 }
 
@@ -176,14 +233,14 @@ func (m *Master) removePlayer(playerId int32) {
 	}
 
 	if removedPlayer == nil {
-		log.Printf("Player ID: %d not found for removal", playerId)
+		logging.Engine.Warn("Player not found for removal", logging.PlayerID(playerId))
 		return
 	}
 
 	//if removedPlayer.GetRole() != pb.NodeRole_VIEWER {
 	// Удаляем только если игрок не VIEWER
 	m.players.Players = append(m.players.Players[:index], m.players.Players[index+1:]...)
-	delete(m.Node.LastSent, fmt.Sprintf("%s:%d", removedPlayer.GetIpAddress(), removedPlayer.GetPort()))
+	m.Node.ForgetAddress(common.JoinHostPort(removedPlayer.GetIpAddress(), removedPlayer.GetPort()))
 	//}
 
 	// Если игрок был DEPUTY, назначаем нового
@@ -191,11 +248,16 @@ func (m *Master) removePlayer(playerId int32) {
 		m.findNewDeputy()
 	}
 
-	// Если игрок стал VIEWER, переводим его змею в ZOMBIE
-	if removedPlayer.GetRole() == pb.NodeRole_VIEWER {
-		m.makeSnakeZombie(playerId)
+	// змея отвалившегося игрока становится ZOMBIE
+	m.makeSnakeZombie(playerId)
+
+	// с сессией игрок может вернуться, пока не истёк SessionGrace
+	if m.sessionToken(playerId) != nil {
+		m.suspendSession(removedPlayer)
+	} else {
+		delete(m.capabilities, playerId)
 	}
-	log.Printf("Player ID: %d processed for removal or role change", playerId)
+	logging.Engine.Info("Player removed", logging.PlayerID(playerId))
 
 	m.Node.State.Players = m.players
 }
@@ -214,7 +276,6 @@ func (m *Master) assignDeputy(player *pb.GamePlayer) {
 	player.Role = pb.NodeRole_DEPUTY.Enum()
 
 	roleChangeMsg := &pb.GameMessage{
-		MsgSeq:     proto.Int64(m.Node.MsgSeq),
 		SenderId:   proto.Int32(m.Node.PlayerInfo.GetId()),
 		ReceiverId: proto.Int32(player.GetId()),
 		Type: &pb.GameMessage_RoleChange{
@@ -224,64 +285,94 @@ func (m *Master) assignDeputy(player *pb.GamePlayer) {
 			},
 		},
 	}
-	playerAddr, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", player.GetIpAddress(), player.GetPort()))
+	playerAddr, err := common.ResolvePlayerAddr(player)
 	if err != nil {
-		log.Printf("Error resolving address for Deputy: %v", err)
+		logging.Network.Error("Error resolving deputy address", logging.PlayerID(player.GetId()), logging.Err(err))
 		return
 	}
 
 	m.Node.SendMessage(roleChangeMsg, playerAddr)
-	log.Printf("Player ID: %d is assigned as DEPUTY", player.GetId())
+	logging.Engine.Info("Assigned DEPUTY", logging.PlayerID(player.GetId()))
 }
 
 func (m *Master) makeSnakeZombie(playerId int32) {
 	for _, snake := range m.Node.State.Snakes {
 		if snake.GetPlayerId() == playerId {
 			snake.State = pb.GameState_Snake_ZOMBIE.Enum()
-			log.Printf("Snake for player ID: %d is now a ZOMBIE", playerId)
+			// зомби больше никто не поворачивает
+			delete(m.turns, playerId)
+			logging.Engine.Info("Snake is now a ZOMBIE", logging.PlayerID(playerId))
 			return
 		}
 	}
-	log.Printf("No snake found for player ID: %d to make ZOMBIE", playerId)
+	logging.Engine.Debug("No snake to make ZOMBIE", logging.PlayerID(playerId))
 }
 
-// обработка roleChangeMsg от Deputy
-func (m *Master) handleRoleChangeMessage(msg *pb.GameMessage, addr *net.UDPAddr) {
+// обработка roleChangeMsg от игрока playerId
+func (m *Master) handleRoleChangeMessage(msg *pb.GameMessage, playerId int32) {
 	roleChangeMsg := msg.GetRoleChange()
 
+	var sender *pb.GamePlayer
+	for _, player := range m.players.Players {
+		if player.GetId() == playerId {
+			sender = player
+			break
+		}
+	}
+
 	switch {
-	case roleChangeMsg.GetSenderRole() == pb.NodeRole_DEPUTY && roleChangeMsg.GetReceiverRole() == pb.NodeRole_MASTER:
-		// TODO: доделать
+	case roleChangeMsg.GetSenderRole() == pb.NodeRole_DEPUTY && roleChangeMsg.GetReceiverRole() == pb.NodeRole_MASTER &&
+		sender.GetRole() == pb.NodeRole_DEPUTY:
 		// DEPUTY -> MASTER
-		log.Printf("Deputy has taken over as MASTER. Stopping PlayerInfo.")
+		logging.Engine.Info("Deputy has taken over as MASTER, stepping down", logging.PlayerID(playerId))
 		m.stopMaster()
 
-	case roleChangeMsg.GetReceiverRole() == pb.NodeRole_VIEWER:
+	case roleChangeMsg.GetSenderRole() == pb.NodeRole_MASTER:
+		// заместитель или выбранный игроками узел занял место мастера, пока тот был недоступен
+		logging.Engine.Info("Another node took over as MASTER, stepping down", logging.PlayerID(playerId))
+		m.stopMaster()
+
+	case roleChangeMsg.GetReceiverRole() == pb.NodeRole_VIEWER || roleChangeMsg.GetSenderRole() == pb.NodeRole_VIEWER:
 		// Player -> VIEWER
-		playerId := msg.GetSenderId()
-		log.Printf("Player ID: %d is now a VIEWER. Converting snake to ZOMBIE.", playerId)
+		logging.Engine.Info("Player is now a VIEWER", logging.PlayerID(playerId))
 		m.makeSnakeZombie(playerId)
 
 		for _, player := range m.players.Players {
 			if player.GetId() == playerId {
+				wasDeputy := player.GetRole() == pb.NodeRole_DEPUTY
 				player.Role = pb.NodeRole_VIEWER.Enum()
+				if wasDeputy {
+					m.findNewDeputy()
+				}
 				break
 			}
 		}
 	default:
-		log.Printf("Received unknown RoleChangeMsg from player ID: %d", msg.GetSenderId())
+		logging.Engine.Warn("Unknown role change", logging.Message(msg), logging.PlayerID(playerId))
 	}
 }
 
-func (m *Master) stopMaster() {
-	log.Println("Switching PlayerInfo role to VIEWER...")
+// forgetDeposed смещённые мастера, не ответившие за deposedTimeout, скорее всего упали:
+// сообщение о новом мастере им больше не переотправляется
+func (m *Master) forgetDeposed(now time.Time) {
+	if len(m.deposed) == 0 || now.Sub(m.deposedAt) <= deposedTimeout {
+		return
+	}
+	for _, addr := range m.deposed {
+		m.Node.ForgetAddress(addr.String())
+	}
+	m.deposed = nil
+}
 
-	// Меняем роль мастера
+// stopMaster мастер уступает игру узлу, который его заменил: змея мастера становится зомби,
+// рассылка состояний и анонсов, проверка таймаутов, переотправки и пинги останавливаются,
+// unicast-сокет закрывается. Вызывается под Node.Mu
+func (m *Master) stopMaster() {
 	m.Node.PlayerInfo.Role = pb.NodeRole_VIEWER.Enum()
-	// Делаем змею мастера ZOMBIE
 	m.makeSnakeZombie(m.Node.PlayerInfo.GetId())
-
 	m.announcement.CanJoin = proto.Bool(false)
-	// Останавливаем функции мастера
-	log.Println("Master is now a VIEWER. Continuing as an observer.")
+
+	// циклы мастера ждут Node.Done, а приём сообщений завершается на закрытом сокете
+	m.Node.Close()
+	logging.Engine.Info("Master stepped down", logging.PlayerID(m.Node.PlayerInfo.GetId()))
 }
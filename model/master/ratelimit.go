@@ -0,0 +1,159 @@
+package master
+
+import (
+	"SnakeGame/logging"
+	"SnakeGame/metrics"
+	pb "SnakeGame/model/proto"
+	"net"
+	"time"
+)
+
+const (
+	// источник, от которого столько времени ничего не приходило, забывается вместе с его корзинами
+	sourceIdleTimeout = 10 * time.Second
+	// класс подписанных сообщений от неизвестного адреса: так игрок с новым адресом даёт о себе знать
+	signedClass = "signed"
+)
+
+// rateLimit скорость пополнения корзины в сообщениях в секунду и её ёмкость
+type rateLimit struct {
+	rate  float64
+	burst float64
+}
+
+// tokenBucket корзина токенов: каждое сообщение забирает токен, токены пополняются со скоростью rate
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) take(limit rateLimit, now time.Time) bool {
+	if b.last.IsZero() {
+		b.tokens = limit.burst
+	} else {
+		b.tokens = min(limit.burst, b.tokens+now.Sub(b.last).Seconds()*limit.rate)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// корзины одного адреса по классам сообщений
+type source struct {
+	buckets  map[string]*tokenBucket
+	lastSeen time.Time
+}
+
+// rateLimiter ограничение входящих сообщений по адресу отправителя и типу сообщения.
+// Игрокам разрешено всё, что нужно для игры, с запасом на переотправки.
+// Неизвестным адресам - только поиск игры и присоединение, а также подтверждения
+// и подписанные сообщения, причём все неизвестные адреса вместе делят ещё одну общую корзину
+type rateLimiter struct {
+	known   map[string]rateLimit
+	unknown map[string]rateLimit
+	// общая корзина неизвестных адресов: подменой адреса её не обойти
+	strangers      tokenBucket
+	strangersLimit rateLimit
+
+	sources map[string]*source
+	// отброшенные сообщения по типам
+	dropped map[string]uint64
+	// сколько отброшенных уже попало в лог
+	reported uint64
+}
+
+func newRateLimiter(stateDelayMs int32) *rateLimiter {
+	// игрок пингует и переотправляет сообщения каждые stateDelayMs/10, поэтому пределы для игроков растут с частотой ходов
+	ticks := 1000 / float64(max(stateDelayMs, 1))
+	perTick := func(count float64) rateLimit {
+		return rateLimit{rate: count * ticks, burst: 2 * count}
+	}
+	return &rateLimiter{
+		known: map[string]rateLimit{
+			"ping":        perTick(20),
+			"ack":         perTick(30),
+			"steer":       perTick(20),
+			"role_change": perTick(10),
+			"state":       perTick(20),
+			"join":        perTick(5),
+			"discover":    {rate: 2, burst: 5},
+		},
+		unknown: map[string]rateLimit{
+			"discover": {rate: 1, burst: 3},
+			"join":     {rate: 1, burst: 3},
+			// подтверждения ErrorMsg, которые получают те, кому отказали в присоединении
+			"ack":       {rate: 10, burst: 10},
+			signedClass: {rate: 5, burst: 5},
+		},
+		strangersLimit: rateLimit{rate: 50, burst: 100},
+		sources:        make(map[string]*source),
+		dropped:        make(map[string]uint64),
+	}
+}
+
+// allow можно ли обработать сообщение от addr; known - адрес принадлежит игроку
+func (l *rateLimiter) allow(msg *pb.GameMessage, addr *net.UDPAddr, known bool, now time.Time) bool {
+	msgType := logging.MessageType(msg)
+	class := msgType
+	limits := l.known
+	if !known {
+		limits = l.unknown
+		if msg.GetAck() == nil && msg.GetSessionTag() != nil {
+			class = signedClass
+		}
+	}
+
+	limit, ok := limits[class]
+	allowed := ok && (known || l.strangers.take(l.strangersLimit, now))
+	if allowed {
+		src, ok := l.sources[addr.String()]
+		if !ok {
+			src = &source{buckets: make(map[string]*tokenBucket)}
+			l.sources[addr.String()] = src
+		}
+		src.lastSeen = now
+		bucket, ok := src.buckets[class]
+		if !ok {
+			bucket = &tokenBucket{}
+			src.buckets[class] = bucket
+		}
+		allowed = bucket.take(limit, now)
+	}
+
+	if !allowed {
+		l.dropped[msgType]++
+		metrics.DroppedMessages.Inc(msgType)
+	}
+	return allowed
+}
+
+// sweep забываем молчащие адреса и сообщаем в лог, сколько сообщений отброшено с прошлого раза
+func (l *rateLimiter) sweep(now time.Time) {
+	for key, src := range l.sources {
+		if now.Sub(src.lastSeen) > sourceIdleTimeout {
+			delete(l.sources, key)
+		}
+	}
+
+	var total uint64
+	for _, count := range l.dropped {
+		total += count
+	}
+	if total > l.reported {
+		logging.Network.Warn("Rate limiter dropped messages", "count", total-l.reported, "by_type", l.dropped)
+		l.reported = total
+	}
+}
+
+// DroppedMessages сколько входящих сообщений отброшено ограничением скорости, по типам сообщений.
+// Вызывается под Node.Mu
+func (m *Master) DroppedMessages() map[string]uint64 {
+	dropped := make(map[string]uint64, len(m.limiter.dropped))
+	for msgType, count := range m.limiter.dropped {
+		dropped[msgType] = count
+	}
+	return dropped
+}
@@ -0,0 +1,180 @@
+package master
+
+import (
+	"SnakeGame/logging"
+	"SnakeGame/model/common"
+	pb "SnakeGame/model/proto"
+	"crypto/rand"
+	"google.golang.org/protobuf/proto"
+	"net"
+	"time"
+)
+
+// DefaultSessionGrace сколько ждём отвалившегося игрока, прежде чем забыть его сессию
+const DefaultSessionGrace = 30 * time.Second
+
+// сессия игрока, договорившегося о SESSIONS
+type session struct {
+	playerId int32
+	// сохранённое описание игрока, пока он отключён; nil, пока игрок в игре
+	player *pb.GamePlayer
+	// когда игрок отключился
+	since time.Time
+	// наибольший msg_seq от игрока: повтор старого подписанного сообщения не переносит игрока на чужой адрес
+	lastSeq int64
+}
+
+// выдача токена сессии новому игроку
+func (m *Master) newSession(playerId int32) []byte {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		logging.Engine.Error("Error generating session token", logging.PlayerID(playerId), logging.Err(err))
+		return nil
+	}
+	m.sessions[string(token)] = &session{playerId: playerId}
+	return token
+}
+
+// токен сессии игрока, nil если сессии нет
+func (m *Master) sessionToken(playerId int32) []byte {
+	for token, s := range m.sessions {
+		if s.playerId == playerId {
+			return []byte(token)
+		}
+	}
+	return nil
+}
+
+// игрок отвалился по таймауту: сессия ждёт его возвращения SessionGrace
+func (m *Master) suspendSession(player *pb.GamePlayer) {
+	if s := m.playerSession(player.GetId()); s != nil {
+		s.player = proto.Clone(player).(*pb.GamePlayer)
+		s.since = time.Now()
+		logging.Engine.Info("Session suspended", logging.PlayerID(player.GetId()), "grace", m.SessionGrace)
+	}
+}
+
+// забываем сессии игроков, не вернувшихся за SessionGrace
+func (m *Master) expireSessions(now time.Time) {
+	for token, s := range m.sessions {
+		if s.player != nil && now.Sub(s.since) > m.SessionGrace {
+			delete(m.sessions, token)
+			delete(m.capabilities, s.playerId)
+			logging.Engine.Info("Session expired", logging.PlayerID(s.playerId))
+		}
+	}
+}
+
+// resumeSession возвращение игрока по токену, возможно с нового адреса.
+// Возвращает false, если такой сессии нет или JoinMsg не новее уже полученных от игрока
+func (m *Master) resumeSession(msgSeq int64, token []byte, addr *net.UDPAddr) bool {
+	s, ok := m.sessions[string(token)]
+	if !ok || len(token) == 0 {
+		return false
+	}
+	// перехваченный JoinMsg с токеном можно повторить с другого адреса,
+	// поэтому, как и в migratePlayer, принимаем только msg_seq больше прежних
+	if msgSeq <= s.lastSeq {
+		logging.Network.Warn("Rejected replayed session resume", logging.PlayerID(s.playerId), logging.Addr(addr), "msg_seq", msgSeq)
+		return false
+	}
+	s.lastSeq = msgSeq
+
+	if s.player == nil {
+		// игрок ещё в игре, связь пропала ненадолго или сменился адрес
+		for _, player := range m.players.Players {
+			if player.GetId() == s.playerId {
+				m.moveToAddress(player, addr)
+				break
+			}
+		}
+		m.Node.LastInteraction[s.playerId] = time.Now()
+		m.sendJoinAck(msgSeq, s.playerId, addr)
+		return true
+	}
+
+	player := s.player
+	s.player = nil
+	player.IpAddress = proto.String(common.IPString(addr))
+	player.Port = proto.Int32(int32(addr.Port))
+	player.Role = pb.NodeRole_VIEWER.Enum()
+	for _, snake := range m.Node.State.Snakes {
+		if snake.GetPlayerId() == player.GetId() && snake.GetState() == pb.GameState_Snake_ZOMBIE {
+			snake.State = pb.GameState_Snake_ALIVE.Enum()
+			player.Role = pb.NodeRole_NORMAL.Enum()
+		}
+	}
+	m.players.Players = append(m.players.Players, player)
+	m.Node.State.Players = m.players
+	m.Node.LastInteraction[player.GetId()] = time.Now()
+
+	m.sendJoinAck(msgSeq, player.GetId(), addr)
+	m.checkAndAssignDeputy()
+	logging.Engine.Info("Session resumed", logging.PlayerID(player.GetId()), logging.Addr(addr),
+		"role", player.GetRole(), "score", player.GetScore())
+	return true
+}
+
+// сессия игрока, nil если её нет
+func (m *Master) playerSession(playerId int32) *session {
+	for _, s := range m.sessions {
+		if s.playerId == playerId {
+			return s
+		}
+	}
+	return nil
+}
+
+// учёт msg_seq подтверждённых сообщений игрока
+func (m *Master) noteMsgSeq(playerId int32, msg *pb.GameMessage) {
+	s := m.playerSession(playerId)
+	if s == nil || msg.GetAck() != nil {
+		return
+	}
+	if msg.GetMsgSeq() > s.lastSeq {
+		s.lastSeq = msg.GetMsgSeq()
+	}
+}
+
+// migratePlayer подписанное сообщение с незнакомого адреса: игрок сменил сеть или NAT выдал ему новый порт.
+// Возвращает ID игрока, если подпись верна, иначе -1
+func (m *Master) migratePlayer(msg *pb.GameMessage, addr *net.UDPAddr) int32 {
+	playerId := msg.GetSenderId()
+	token := m.sessionToken(playerId)
+	s := m.playerSession(playerId)
+	if token == nil || s.player != nil {
+		// отключённый игрок возвращается через JoinMsg с токеном
+		return -1
+	}
+	if !common.VerifyMessage(msg, token) || msg.GetMsgSeq() <= s.lastSeq {
+		logging.Network.Warn("Rejected signed message", logging.Message(msg), logging.PlayerID(playerId), logging.Addr(addr))
+		return -1
+	}
+
+	for _, player := range m.players.Players {
+		if player.GetId() == playerId {
+			m.moveToAddress(player, addr)
+			return playerId
+		}
+	}
+	return -1
+}
+
+// moveToAddress игрок теперь доступен по новому адресу
+func (m *Master) moveToAddress(player *pb.GamePlayer, addr *net.UDPAddr) {
+	if common.SameAddress(player, addr) {
+		return
+	}
+	oldAddr := common.JoinHostPort(player.GetIpAddress(), player.GetPort())
+	if old, err := common.ResolvePlayerAddr(player); err == nil {
+		m.Node.RedirectUnconfirmed(old, addr)
+	}
+	player.IpAddress = proto.String(common.IPString(addr))
+	player.Port = proto.Int32(int32(addr.Port))
+	logging.Engine.Info("Player moved to a new address", logging.PlayerID(player.GetId()), "from", oldAddr, logging.Addr(addr))
+}
+
+// договорились ли с игроком о возможности
+func (m *Master) playerSupports(playerId int32, capability pb.Capability) bool {
+	return common.HasCapability(m.capabilities[playerId], capability)
+}
@@ -0,0 +1,117 @@
+package player
+
+import (
+	"SnakeGame/connection"
+	"SnakeGame/model/common"
+	pb "SnakeGame/model/proto"
+	"google.golang.org/protobuf/proto"
+	"net"
+	"testing"
+)
+
+// примеры корректных сообщений от мастера, от них fuzzer строит свои варианты
+func seedMessages() []*pb.GameMessage {
+	state := &pb.GameState{
+		StateOrder: proto.Int32(5),
+		Snakes: []*pb.GameState_Snake{{
+			PlayerId:      proto.Int32(1),
+			Points:        []*pb.GameState_Coord{{X: proto.Int32(3), Y: proto.Int32(3)}},
+			State:         pb.GameState_Snake_ALIVE.Enum(),
+			HeadDirection: pb.Direction_LEFT.Enum(),
+		}},
+		Foods: []*pb.GameState_Coord{{X: proto.Int32(0), Y: proto.Int32(0)}},
+		Players: &pb.GamePlayers{Players: []*pb.GamePlayer{
+			{Name: proto.String("Master"), Id: proto.Int32(1), Role: pb.NodeRole_MASTER.Enum(), Score: proto.Int32(0)},
+			{Name: proto.String("fuzz"), Id: proto.Int32(2), Role: pb.NodeRole_NORMAL.Enum(), Score: proto.Int32(0)},
+		}},
+	}
+	return []*pb.GameMessage{
+		{MsgSeq: proto.Int64(1), ReceiverId: proto.Int32(2), Type: &pb.GameMessage_Ack{Ack: &pb.GameMessage_AckMsg{}}},
+		{MsgSeq: proto.Int64(2), Type: &pb.GameMessage_State{State: &pb.GameMessage_StateMsg{State: state}}},
+		{MsgSeq: proto.Int64(3), Type: &pb.GameMessage_Error{Error: &pb.GameMessage_ErrorMsg{ErrorMessage: proto.String("x")}}},
+		{MsgSeq: proto.Int64(4), Type: &pb.GameMessage_Ping{Ping: &pb.GameMessage_PingMsg{}}},
+		{MsgSeq: proto.Int64(5), Type: &pb.GameMessage_Announcement{Announcement: &pb.GameMessage_AnnouncementMsg{}}},
+		{MsgSeq: proto.Int64(6), Type: &pb.GameMessage_RoleChange{RoleChange: &pb.GameMessage_RoleChangeMsg{
+			ReceiverRole: pb.NodeRole_DEPUTY.Enum(),
+		}}},
+		{MsgSeq: proto.Int64(7), Type: &pb.GameMessage_RoleChange{RoleChange: &pb.GameMessage_RoleChangeMsg{
+			SenderRole:   pb.NodeRole_MASTER.Enum(),
+			ReceiverRole: pb.NodeRole_MASTER.Enum(),
+		}}},
+	}
+}
+
+// fuzzPlayer игрок, уже присоединившийся к игре, фоновые циклы не запускаются
+func fuzzPlayer(t *testing.T, withConfig bool) (*Player, *net.UDPAddr) {
+	network := connection.NewMemNetwork()
+	multicastConn, err := network.ListenMulticast(connection.MulticastGroup())
+	if err != nil {
+		t.Fatal(err)
+	}
+	unicastConn, err := network.ListenUDP(network.NewHost(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { multicastConn.Close() })
+
+	p, err := NewPlayer(multicastConn, unicastConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(p.Stop)
+
+	if withConfig {
+		p.Node.Config = &pb.GameConfig{
+			Width:        proto.Int32(20),
+			Height:       proto.Int32(15),
+			FoodStatic:   proto.Int32(1),
+			StateDelayMs: proto.Int32(1000),
+		}
+	}
+	p.MasterAddr = &net.UDPAddr{IP: net.ParseIP(network.NewHost()), Port: 40500}
+	p.Node.MasterAddr = p.MasterAddr
+	p.Node.PlayerInfo.Id = proto.Int32(2)
+	p.haveId = true
+	return p, p.MasterAddr
+}
+
+func FuzzHandleMessage(f *testing.F) {
+	for _, msg := range seedMessages() {
+		data, err := proto.Marshal(msg)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data, true, true)
+		f.Add(data, false, true)
+		f.Add(data, true, false)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte, fromMaster bool, withConfig bool) {
+		var msg pb.GameMessage
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return
+		}
+
+		p, addr := fuzzPlayer(t, withConfig)
+		if !fromMaster {
+			addr = &net.UDPAddr{IP: net.ParseIP("10.9.9.9"), Port: 1}
+		}
+
+		p.handleMessage(&msg, addr)
+		p.Node.Mu.Lock()
+		defer p.Node.Mu.Unlock()
+		p.handleMulticastMessage(&msg, addr)
+
+		if p.Node.State != nil {
+			if err := common.ValidateState(p.Node.State, p.Node.Config); err != nil {
+				t.Fatalf("accepted invalid state: %v", err)
+			}
+		}
+		if !fromMaster && p.Node.State != nil {
+			t.Fatalf("accepted state from unknown address %v", addr)
+		}
+		if p.Node.PlayerInfo.GetId() != 2 {
+			t.Fatalf("player ID changed to %d", p.Node.PlayerInfo.GetId())
+		}
+	})
+}
@@ -1,35 +1,46 @@
 package player
 
 import (
+	"SnakeGame/logging"
 	pb "SnakeGame/model/proto"
 	"google.golang.org/protobuf/proto"
-	"log"
+	"net"
 )
 
-func (p *Player) handleRoleChangeMessage(msg *pb.GameMessage) {
+func (p *Player) handleRoleChangeMessage(msg *pb.GameMessage, addr *net.UDPAddr) {
 	roleChangeMsg := msg.GetRoleChange()
+
+	// заместитель сообщает, что теперь он мастер
+	if roleChangeMsg.GetSenderRole() == pb.NodeRole_MASTER &&
+		(p.MasterAddr == nil || !p.MasterAddr.IP.Equal(addr.IP) || p.MasterAddr.Port != addr.Port) {
+		p.switchMaster(addr)
+		logging.Engine.Info("New MASTER", logging.Addr(addr))
+	}
+
 	switch {
 	case roleChangeMsg.GetReceiverRole() == pb.NodeRole_DEPUTY:
 		// DEPUTY
 		p.Node.PlayerInfo.Role = pb.NodeRole_DEPUTY.Enum()
-		log.Printf("Assigned as DEPUTY")
+		logging.Engine.Info("Assigned as DEPUTY", logging.PlayerID(p.Node.PlayerInfo.GetId()))
 	case roleChangeMsg.GetReceiverRole() == pb.NodeRole_MASTER:
 		// MASTER
-		p.Node.PlayerInfo.Role = pb.NodeRole_MASTER.Enum()
-		log.Printf("Assigned as MASTER")
-		// TODO: Implement logic to take over as MASTER
+		logging.Engine.Info("Assigned as MASTER", logging.PlayerID(p.Node.PlayerInfo.GetId()))
+		if p.master == nil {
+			p.becomeMaster()
+		}
 	case roleChangeMsg.GetReceiverRole() == pb.NodeRole_VIEWER:
 		// VIEWER
 		p.Node.PlayerInfo.Role = pb.NodeRole_VIEWER.Enum()
-		log.Printf("Assigned as VIEWER")
+		logging.Engine.Info("Assigned as VIEWER", logging.PlayerID(p.Node.PlayerInfo.GetId()))
+	case roleChangeMsg.GetSenderRole() == pb.NodeRole_MASTER:
+		// только смена мастера
 	default:
-		log.Printf("Received unknown RoleChangeMsg")
+		logging.Engine.Warn("Unknown role change", logging.Message(msg), logging.Addr(addr))
 	}
 }
 
 func (p *Player) sendRoleChangeRequest(newRole pb.NodeRole) {
 	roleChangeMsg := &pb.GameMessage{
-		MsgSeq:   proto.Int64(p.Node.MsgSeq),
 		SenderId: proto.Int32(p.Node.PlayerInfo.GetId()),
 		Type: &pb.GameMessage_RoleChange{
 			RoleChange: &pb.GameMessage_RoleChangeMsg{
@@ -40,5 +51,5 @@ func (p *Player) sendRoleChangeRequest(newRole pb.NodeRole) {
 	}
 
 	p.Node.SendMessage(roleChangeMsg, p.MasterAddr)
-	log.Printf("Player: Sent RoleChangeMsg to %v with new role: %v", p.MasterAddr, newRole)
+	logging.Engine.Info("Sent role change", logging.Message(roleChangeMsg), "role", newRole, logging.Addr(p.MasterAddr))
 }
@@ -1,13 +1,16 @@
 package player
 
 import (
+	"SnakeGame/connection"
+	"SnakeGame/logging"
+	"SnakeGame/metrics"
 	"SnakeGame/model/common"
+	"SnakeGame/model/master"
 	pb "SnakeGame/model/proto"
+	"errors"
 	"fmt"
 	"google.golang.org/protobuf/proto"
-	"log"
 	"net"
-	"os"
 	"time"
 )
 
@@ -18,6 +21,13 @@ type DiscoveredGame struct {
 	GameName        string
 	AnnouncementMsg *pb.GameMessage_AnnouncementMsg
 	MasterAddr      *net.UDPAddr
+
+	// версия протокола мастера, 0 у старых узлов
+	ProtocolVersion int32
+	// обязательные возможности игры, которых нет у этого узла: присоединиться нельзя
+	MissingCapabilities []pb.Capability
+	// параметры игры вне допустимых пределов: присоединиться нельзя
+	ConfigError error
 }
 
 type Player struct {
@@ -27,28 +37,36 @@ type Player struct {
 	MasterAddr      *net.UDPAddr
 	LastStateMsg    int32
 
-	haveId bool
+	// последнее сообщение об ошибке от мастера, например отказ в присоединении
+	LastError string
+	// возможности, о которых договорились с мастером в Ack на JoinMsg
+	Capabilities []pb.Capability
+	// мастер выгнал игрока: не возвращаемся в игру и не выбираем нового мастера
+	Kicked bool
+
+	haveId        bool
+	lastMasterMsg time.Time
+	// токен сессии из Ack на JoinMsg, по нему возвращаемся в игру после обрыва связи
+	sessionToken []byte
+	// отправлен JoinMsg с токеном, ждём состояния от мастера
+	resuming bool
+	// мастер замолчал, ждём нового мастера или выбираем его сами
+	masterLost bool
+	// узлы, которые молчали, пока были мастером или кандидатом на выборах
+	lostNodes map[int32]bool
+	// мастер, запущенный на этом узле после того, как заместитель заменил отвалившегося мастера
+	master *master.Master
 
 	DiscoveredGames []DiscoveredGame
 }
 
-func NewPlayer(multicastConn *net.UDPConn) *Player {
-	// создаем сокет для остальных сообщений
-	localAddr, err := net.ResolveUDPAddr("udp", ":0")
-	if err != nil {
-		log.Fatalf("Error resolving local UDP address: %v", err)
-	}
-	unicastConn, err := net.ListenUDP("udp", localAddr)
-	if err != nil {
-		log.Fatalf("Error creating unicast socket: %v", err)
-	}
-
-	playerIP, err := common.GetLocalIP()
+// NewPlayer создает игрока, unicastConn - сокет для общения с мастером
+func NewPlayer(multicastConn connection.Conn, unicastConn connection.Conn) (*Player, error) {
+	playerIP, playerPort, err := common.LocalAddr(unicastConn)
 	if err != nil {
-		log.Fatalf("Error getting local IP: %v", err)
+		return nil, fmt.Errorf("error getting local address: %w", err)
 	}
-	playerPort := unicastConn.LocalAddr().(*net.UDPAddr).Port
-	fmt.Printf("Выделенный локальный адрес: %s:%v\n", playerIP, playerPort)
+	logging.Network.Info("Player socket bound", "ip", playerIP, "port", playerPort)
 
 	playerInfo := &pb.GamePlayer{
 		Name:      proto.String("Player"),
@@ -71,33 +89,64 @@ func NewPlayer(multicastConn *net.UDPConn) *Player {
 		haveId: false,
 
 		DiscoveredGames: []DiscoveredGame{},
+	}, nil
+}
+
+// JoinGame присоединение к найденной игре под именем playerName.
+// К игре с недопустимыми параметрами не присоединяемся
+func (p *Player) JoinGame(playerName string, game *DiscoveredGame) error {
+	if err := common.ValidateConfig(game.Config); err != nil {
+		return fmt.Errorf("cannot join %q: %w", game.GameName, err)
 	}
+	p.Node.PlayerInfo.Name = proto.String(playerName)
+	p.Node.Config = game.Config
+	p.MasterAddr = game.MasterAddr
+	p.AnnouncementMsg = game.AnnouncementMsg
+	p.Start()
+	return nil
 }
 
 func (p *Player) Start() {
+	p.lastMasterMsg = time.Now()
 	p.discoverGames()
 	go p.receiveMessages()
+	go p.checkTimeouts()
 	go p.Node.ResendUnconfirmedMessages(p.Node.Config.GetStateDelayMs())
 	go p.Node.SendPings(p.Node.Config.GetStateDelayMs())
 }
 
+// Stop остановка игрока: фоновые циклы завершаются, unicast-сокет закрывается
+func (p *Player) Stop() {
+	p.Node.Close()
+}
+
 func (p *Player) ReceiveMulticastMessages() {
+	buf := make([]byte, 4096)
 	for {
-		buf := make([]byte, 4096)
 		n, addr, err := p.Node.MulticastConn.ReadFromUDP(buf)
+		if p.Node.Closed() || errors.Is(err, net.ErrClosed) {
+			return
+		}
 		if err != nil {
-			log.Printf("Error receiving multicast message: %v", err)
+			logging.Network.Error("Error receiving multicast message", logging.Err(err))
 			continue
 		}
 
 		var msg pb.GameMessage
 		err = proto.Unmarshal(buf[:n], &msg)
 		if err != nil {
-			log.Printf("Error unmarshaling multicast message: %v", err)
+			logging.Network.Warn("Error unmarshalling multicast message", logging.Addr(addr), logging.Err(err))
 			continue
 		}
+		metrics.MessagesReceived.Inc(logging.MessageType(&msg))
 
-		p.handleMulticastMessage(&msg, addr)
+		p.Node.Mu.Lock()
+		if p.master != nil {
+			p.master.HandleMulticastMessage(&msg, addr)
+		} else {
+			p.handleMulticastMessage(&msg, addr)
+		}
+		p.Node.Mu.Unlock()
 	}
 }
 
@@ -119,33 +168,50 @@ func (p *Player) addDiscoveredGame(announcement *pb.GameAnnouncement, addr *net.
 		}
 	}
 
+	missing := common.MissingCapabilities(announcement.GetRequiredCapabilities(), common.SupportedCapabilities)
+	configErr := common.ValidateConfig(announcement.GetConfig())
 	newGame := DiscoveredGame{
 		Players:         announcement.GetPlayers(),
 		Config:          announcement.GetConfig(),
-		CanJoin:         announcement.GetCanJoin(),
+		CanJoin:         announcement.GetCanJoin() && len(missing) == 0 && configErr == nil,
 		GameName:        announcement.GetGameName(),
 		AnnouncementMsg: announcementMsg,
 		MasterAddr:      addr,
+
+		ProtocolVersion:     announcement.GetProtocolVersion(),
+		MissingCapabilities: missing,
+		ConfigError:         configErr,
+	}
+	if len(missing) > 0 {
+		logging.Engine.Info("Game requires unsupported capabilities", "game", announcement.GetGameName(), "missing", missing)
+	}
+	if configErr != nil {
+		logging.Engine.Warn("Game has an invalid config", "game", announcement.GetGameName(), logging.Err(configErr))
 	}
 
 	p.DiscoveredGames = append(p.DiscoveredGames, newGame)
-	log.Printf("Discovered new game: '%s'", announcement.GetGameName())
+	logging.Engine.Info("Discovered new game", "game", announcement.GetGameName(), logging.Addr(addr))
 }
 
 func (p *Player) receiveMessages() {
+	buf := make([]byte, 4096)
 	for {
-		buf := make([]byte, 4096)
 		n, addr, err := p.Node.UnicastConn.ReadFromUDP(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
 		if err != nil {
-			log.Printf("Error receiving message: %v", err)
+			logging.Network.Error("Error receiving message", logging.Err(err))
 			continue
 		}
 
 		var msg pb.GameMessage
 		err = proto.Unmarshal(buf[:n], &msg)
 		if err != nil {
+			logging.Network.Warn("Error unmarshalling message", logging.Addr(addr), logging.Err(err))
 			continue
 		}
+		metrics.MessagesReceived.Inc(logging.MessageType(&msg))
 
 		p.handleMessage(&msg, addr)
 	}
@@ -154,51 +220,119 @@ func (p *Player) receiveMessages() {
 func (p *Player) handleMessage(msg *pb.GameMessage, addr *net.UDPAddr) {
 	p.Node.Mu.Lock()
 	defer p.Node.Mu.Unlock()
-	p.Node.LastInteraction[msg.GetSenderId()] = time.Now()
+	if p.master != nil {
+		p.master.HandleMessage(msg, addr)
+		return
+	}
+	// время общения учитываем только для известных узлов, иначе чужие пакеты раздувают таблицу
+	senderId := p.Node.GetPlayerIdByAddress(addr)
+	if senderId > 0 {
+		p.Node.LastInteraction[senderId] = time.Now()
+	}
+	logging.Network.Debug("Received message", logging.Message(msg), logging.PlayerID(senderId), logging.Addr(addr))
+	fromMaster := p.isMasterAddr(addr)
+	// Ack мастер шлёт и тем, кого уже убрал из игры, поэтому о связи с ним судим по остальным сообщениям
+	if fromMaster && msg.GetAck() == nil {
+		p.lastMasterMsg = time.Now()
+		p.masterLost = false
+		p.lostNodes = nil
+	}
 	switch t := msg.Type.(type) {
 	case *pb.GameMessage_Ack:
-		if !p.haveId {
+		if !fromMaster {
+			return
+		}
+		if !p.haveId && msg.GetReceiverId() > 0 {
 			p.Node.PlayerInfo.Id = proto.Int32(msg.GetReceiverId())
-			log.Printf("Joined game with ID: %d", p.Node.PlayerInfo.GetId())
+			// старый мастер ничего не присылает, тогда остаётся только базовый протокол
+			p.Capabilities = common.NegotiateCapabilities(common.SupportedCapabilities, t.Ack.GetCapabilities())
+			if common.HasCapability(p.Capabilities, pb.Capability_SESSIONS) {
+				p.sessionToken = t.Ack.GetSessionToken()
+				p.Node.SetSessionToken(p.sessionToken)
+			}
+			logging.Engine.Info("Joined game", logging.PlayerID(p.Node.PlayerInfo.GetId()),
+				"protocol_version", t.Ack.GetProtocolVersion(), "capabilities", p.Capabilities)
 			p.haveId = true
 		}
-		p.Node.AckChan <- msg.GetMsgSeq()
+		p.Node.HandleAck(msg.GetMsgSeq())
 	case *pb.GameMessage_Announcement:
+		// ответ на DiscoverMsg нужен только до присоединения и только от выбранного мастера
+		if p.haveId || (p.MasterAddr != nil && !fromMaster) {
+			return
+		}
 		p.MasterAddr = addr
 		p.Node.MasterAddr = addr
 		p.AnnouncementMsg = t.Announcement
-		log.Printf("Received AnnouncementMsg from %v via unicast", addr)
+		logging.Network.Debug("Received announcement via unicast", logging.Addr(addr))
 		p.sendJoinRequest()
 	case *pb.GameMessage_State:
+		if !fromMaster {
+			return
+		}
 		if t.State.GetState().GetStateOrder() <= p.LastStateMsg {
 			return
-		} else {
-			p.LastStateMsg = t.State.GetState().GetStateOrder()
 		}
+		// змеи приходят ключевыми точками, а узел хранит все их клетки
+		err := common.DecodeSnakes(t.State.GetState().GetSnakes(), p.Node.Config)
+		if err == nil {
+			err = common.ValidateState(t.State.GetState(), p.Node.Config)
+		}
+		if err != nil {
+			logging.Engine.Warn("Rejected state", logging.Message(msg), logging.Addr(addr), logging.Err(err))
+			return
+		}
+		p.LastStateMsg = t.State.GetState().GetStateOrder()
 		p.Node.State = t.State.GetState()
+		if p.resuming {
+			logging.Engine.Info("Resumed session with MASTER", logging.Addr(addr))
+			p.resuming = false
+		}
 		p.Node.SendAck(msg, addr)
 		p.Node.Cond.Broadcast()
 	case *pb.GameMessage_Error:
+		if !fromMaster {
+			return
+		}
 		p.Node.SendAck(msg, addr)
+		p.LastError = t.Error.GetErrorMessage()
 		if t.Error.GetErrorMessage() == "You have crashed and been removed from the game. Exiting..." {
-			log.Printf("Received crash notification: %s", t.Error.GetErrorMessage())
-			os.Exit(0)
+			// змея погибла, дальше только наблюдаем за игрой
+			logging.Engine.Info("Snake crashed, continuing as VIEWER", logging.PlayerID(p.Node.PlayerInfo.GetId()))
+			p.Node.PlayerInfo.Role = pb.NodeRole_VIEWER.Enum()
+			// мастер забыл сессию вместе со змеёй
+			p.sessionToken = nil
+			p.Node.SetSessionToken(nil)
+		} else if t.Error.GetErrorMessage() == "You have been kicked by the host" {
+			// сессия на мастере забыта, по токену вернуться уже нельзя
+			logging.Engine.Warn("Kicked by MASTER", logging.PlayerID(p.Node.PlayerInfo.GetId()))
+			p.Kicked = true
+			p.sessionToken = nil
+			p.Node.SetSessionToken(nil)
+		} else {
+			logging.Engine.Warn("Error from MASTER", "error", t.Error.GetErrorMessage())
 		}
 	case *pb.GameMessage_RoleChange:
-		p.handleRoleChangeMessage(msg)
+		// новым мастером может объявить себя только участник игры
+		if !fromMaster && (t.RoleChange.GetSenderRole() != pb.NodeRole_MASTER || p.Node.GetPlayerIdByAddress(addr) <= 0) {
+			return
+		}
+		p.handleRoleChangeMessage(msg, addr)
 		p.Node.SendAck(msg, addr)
 	case *pb.GameMessage_Ping:
 		// Отправляем AckMsg в ответ
 		p.Node.SendAck(msg, addr)
 	default:
-		log.Printf("Received unknown message")
+		logging.Network.Warn("Received unknown message type", logging.Message(msg), logging.Addr(addr))
 	}
 }
 
+func (p *Player) isMasterAddr(addr *net.UDPAddr) bool {
+	return p.MasterAddr != nil && p.MasterAddr.IP.Equal(addr.IP) && p.MasterAddr.Port == addr.Port
+}
+
 // DiscoverGames игрок ищет доступные игры
 func (p *Player) discoverGames() {
 	discoverMsg := &pb.GameMessage{
-		MsgSeq: proto.Int64(p.Node.MsgSeq),
 		Type: &pb.GameMessage_Discover{
 			Discover: &pb.GameMessage_DiscoverMsg{},
 		},
@@ -206,93 +340,221 @@ func (p *Player) discoverGames() {
 
 	multicastAddr, err := net.ResolveUDPAddr("udp", p.Node.MulticastAddress)
 	if err != nil {
-		log.Fatalf("Error resolving multicast address: %v", err)
+		logging.Network.Error("Error resolving multicast address", logging.Err(err))
 		return
 	}
 
 	p.Node.SendMessage(discoverMsg, multicastAddr)
-	log.Printf("Player: Sent DiscoverMsg to multicast address %v", multicastAddr)
+	logging.Network.Debug("Sent discover", logging.Addr(multicastAddr))
 }
 
 func (p *Player) sendJoinRequest() {
 	if p.AnnouncementMsg == nil || len(p.AnnouncementMsg.Games) == 0 {
-		log.Printf("Player: No available games to join")
+		logging.Engine.Warn("No available games to join")
 		return
 	}
 
 	joinMsg := &pb.GameMessage{
-		MsgSeq: proto.Int64(p.Node.MsgSeq),
 		Type: &pb.GameMessage_Join{
 			Join: &pb.GameMessage_JoinMsg{
 				PlayerType:    pb.PlayerType_HUMAN.Enum(),
 				PlayerName:    p.Node.PlayerInfo.Name,
 				GameName:      proto.String(p.AnnouncementMsg.Games[0].GetGameName()),
 				RequestedRole: pb.NodeRole_NORMAL.Enum(),
+
+				ProtocolVersion: proto.Int32(common.ProtocolVersion),
+				Capabilities:    common.SupportedCapabilities,
+				SessionToken:    p.sessionToken,
 			},
 		},
 	}
 
 	p.Node.SendMessage(joinMsg, p.MasterAddr)
-	log.Printf("Player: Sent JoinMsg to master at %v", p.MasterAddr)
+	logging.Engine.Info("Sent join request", logging.Message(joinMsg), logging.Addr(p.MasterAddr))
+}
+
+// Steer поворот своей змеи: мастеру отправляется SteerMsg,
+// а если узел сам стал мастером, поворот сразу встаёт в его очередь поворотов
+func (p *Player) Steer(direction pb.Direction) {
+	p.Node.Mu.Lock()
+	defer p.Node.Mu.Unlock()
+
+	if p.master != nil {
+		p.master.Steer(p.Node.PlayerInfo.GetId(), direction)
+		return
+	}
+
+	steerMsg := &pb.GameMessage{
+		Type: &pb.GameMessage_Steer{
+			Steer: &pb.GameMessage_SteerMsg{
+				Direction: direction.Enum(),
+			},
+		},
+	}
+
+	p.Node.SendMessage(steerMsg, p.MasterAddr)
+}
+
+// SessionToken токен сессии, выданный мастером, nil если мастер сессии не поддерживает
+func (p *Player) SessionToken() []byte {
+	p.Node.Mu.Lock()
+	defer p.Node.Mu.Unlock()
+	return p.sessionToken
+}
+
+// BecomeViewer осознанный выход из игры: змея становится зомби, игрок продолжает наблюдать
+func (p *Player) BecomeViewer() {
+	p.Node.Mu.Lock()
+	defer p.Node.Mu.Unlock()
+
+	p.sendRoleChangeRequest(pb.NodeRole_VIEWER)
+	p.Node.PlayerInfo.Role = pb.NodeRole_VIEWER.Enum()
+}
+
+// обработка отвалившегося мастера
+func (p *Player) checkTimeouts() {
+	timeout := time.Duration(0.8*float64(p.Node.Config.GetStateDelayMs())) * time.Millisecond
+	// за это время заместитель успевает заменить мастера и сообщить о себе
+	electionTimeout := 4 * timeout
+	ticker := time.NewTicker(timeout)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.Node.Done():
+			return
+		case <-ticker.C:
+		}
+
+		p.Node.Mu.Lock()
+		if p.master == nil && p.haveId && !p.Kicked {
+			silence := time.Since(p.lastMasterMsg)
+			switch {
+			case !p.masterLost && silence > timeout:
+				p.masterLost = true
+				p.handleMasterTimeout()
+			case p.masterLost && silence > electionTimeout:
+				p.electMaster()
+			}
+		}
+		p.Node.Mu.Unlock()
+	}
+}
+
+func (p *Player) handleMasterTimeout() {
+	logging.Engine.Warn("MASTER timed out", logging.Addr(p.MasterAddr))
+	metrics.Timeouts.Inc()
+
+	switch {
+	// Deputy заметил, что отвалился мастер и заменяет его
+	case p.Node.PlayerInfo.GetRole() == pb.NodeRole_DEPUTY:
+		p.becomeMaster()
+
+	// связь могла пропасть у нас, и мастер уже убрал нас из игры: возвращаемся по токену.
+	// Если мастер действительно упал, заместитель сам сообщит о себе RoleChangeMsg
+	case p.sessionToken != nil:
+		if !p.resuming {
+			p.resuming = true
+			logging.Engine.Info("Trying to resume session with MASTER", logging.Addr(p.MasterAddr))
+			p.sendJoinRequest()
+		}
+
+	// игрок заметил, что мастер отвалился и переходит к Deputy
+	default:
+		deputy := p.getDeputy()
+		if deputy == nil || deputy.GetId() == p.Node.PlayerInfo.GetId() {
+			logging.Engine.Warn("No DEPUTY available to switch to")
+			return
+		}
+		addr, err := common.ResolvePlayerAddr(deputy)
+		if err != nil {
+			logging.Network.Error("Error resolving deputy address", logging.PlayerID(deputy.GetId()), logging.Err(err))
+			return
+		}
+		p.switchMaster(addr)
+		logging.Engine.Info("Switched to DEPUTY as new MASTER", logging.PlayerID(deputy.GetId()), logging.Addr(p.MasterAddr))
+	}
+}
+
+// switchMaster переход к новому мастеру, неподтверждённые сообщения уходят ему
+func (p *Player) switchMaster(addr *net.UDPAddr) {
+	if p.MasterAddr != nil {
+		p.Node.RedirectUnconfirmed(p.MasterAddr, addr)
+	}
+	p.MasterAddr = addr
+	p.Node.MasterAddr = addr
+	p.lastMasterMsg = time.Now()
 }
 
-// обработка отвалившихся узлов
-//func (p *Player) checkTimeouts() {
-//	ticker := time.NewTicker(time.Duration(0.8*float64(p.node.Config.GetStateDelayMs())) * time.Millisecond)
-//	defer ticker.Stop()
-//
-//	for range ticker.C {
-//		now := time.Now()
-//		p.node.Mu.Lock()
-//		for _, lastInteraction := range p.node.LastInteraction {
-//			// TODO: добавить проверку на то что мастер отвалился
-//			if now.Sub(lastInteraction) > time.Duration(0.8*float64(p.node.Config.GetStateDelayMs()))*time.Millisecond {
-//				switch p.node.PlayerInfo.GetRole() {
-//				// игрок заметил, что мастер отвалился и переходит к Deputy
-//				case pb.NodeRole_NORMAL:
-//					deputy := p.getDeputy()
-//					if deputy != nil {
-//						addrStr := fmt.Sprintf("%s:%d", deputy.GetIpAddress(), deputy.GetPort())
-//						addr, err := net.ResolveUDPAddr("udp", addrStr)
-//						if err != nil {
-//							log.Printf("Error resolving deputy address: %v", err)
-//							p.node.Mu.Unlock()
-//							continue
-//						}
-//						p.MasterAddr = addr
-//						log.Printf("Switched to DEPUTY as new MASTER at %v", p.MasterAddr)
-//					} else {
-//						log.Printf("No DEPUTY available to switch to")
-//					}
-//
-//				// Deputy заметил, что отвалился мастер и заменяет его
-//				case pb.NodeRole_DEPUTY:
-//					p.becomeMaster()
-//				}
-//			}
-//		}
-//		p.node.Mu.Unlock()
-//	}
-//}
-//
-//func (p *Player) getDeputy() *pb.GamePlayer {
-//	for _, player := range p.node.State.Players.Players {
-//		if player.GetRole() == pb.NodeRole_DEPUTY {
-//			return player
-//		}
-//	}
-//	return nil
-//}
-//
-//func (p *Player) becomeMaster() {
-//	log.Printf("DEPUTY becoming new MASTER")
-//	// Обновляем роль игрока
-//	p.node.PlayerInfo.Role = pb.NodeRole_MASTER.Enum()
-//
-//	// Создаем новый мастер
-//	masterNode := master.NewDeputyMaster(p.node, p.node.PlayerInfo, p.LastStateMsg)
-//	// Запускаем мастер
-//	go masterNode.Start()
-//	// Останавливаем функции игрока
-//	p.stopPlayerFunctions()
-//}
+// electMaster выборы нового мастера, когда пропали и мастер, и заместитель.
+// Все узлы выбирают одинаково по списку игроков из последнего StateMsg: побеждает NORMAL с наименьшим ID
+func (p *Player) electMaster() {
+	if p.lostNodes == nil {
+		p.lostNodes = make(map[int32]bool)
+	}
+	for _, player := range p.Node.State.GetPlayers().GetPlayers() {
+		switch {
+		case player.GetRole() == pb.NodeRole_MASTER || player.GetRole() == pb.NodeRole_DEPUTY:
+			p.lostNodes[player.GetId()] = true
+		case p.MasterAddr != nil && common.SameAddress(player, p.MasterAddr):
+			// кандидат, к которому мы перешли на прошлых выборах, тоже молчит
+			p.lostNodes[player.GetId()] = true
+		}
+	}
+
+	var winner *pb.GamePlayer
+	for _, player := range p.Node.State.GetPlayers().GetPlayers() {
+		if player.GetRole() != pb.NodeRole_NORMAL || p.lostNodes[player.GetId()] {
+			continue
+		}
+		if winner == nil || player.GetId() < winner.GetId() {
+			winner = player
+		}
+	}
+
+	if winner == nil {
+		logging.Engine.Warn("No NORMAL node left to become MASTER")
+		return
+	}
+	if winner.GetId() == p.Node.PlayerInfo.GetId() {
+		logging.Engine.Info("Won election after losing MASTER and DEPUTY", logging.PlayerID(winner.GetId()))
+		p.becomeMaster()
+		return
+	}
+
+	addr, err := common.ResolvePlayerAddr(winner)
+	if err != nil {
+		logging.Network.Error("Error resolving elected MASTER address", logging.PlayerID(winner.GetId()), logging.Err(err))
+		return
+	}
+	p.switchMaster(addr)
+	logging.Engine.Info("Elected new MASTER", logging.PlayerID(winner.GetId()), logging.Addr(addr))
+}
+
+func (p *Player) getDeputy() *pb.GamePlayer {
+	for _, player := range p.Node.State.GetPlayers().GetPlayers() {
+		if player.GetRole() == pb.NodeRole_DEPUTY {
+			return player
+		}
+	}
+	return nil
+}
+
+func (p *Player) becomeMaster() {
+	if p.Node.State == nil || p.Node.Config == nil {
+		logging.Engine.Warn("Cannot become MASTER without game state")
+		return
+	}
+	logging.Engine.Info("Becoming new MASTER", logging.PlayerID(p.Node.PlayerInfo.GetId()))
+
+	gameName := ""
+	if p.AnnouncementMsg != nil && len(p.AnnouncementMsg.Games) > 0 {
+		gameName = p.AnnouncementMsg.Games[0].GetGameName()
+	}
+
+	// мастер свои сообщения не подписывает
+	p.Node.SetSessionToken(nil)
+	// мастер работает на том же узле, сообщения ему передаёт receiveMessages
+	p.master = master.NewDeputyMaster(p.Node, gameName, p.LastStateMsg)
+	p.master.Takeover()
+}
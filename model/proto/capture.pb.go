@@ -0,0 +1,235 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.35.2
+// 	protoc        v3.21.12
+// source: capture.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Откуда взялся пакет
+type CaptureRecord_Direction int32
+
+const (
+	CaptureRecord_GROUP    CaptureRecord_Direction = 0 // Получен инструментом захвата на multicast-группе
+	CaptureRecord_INBOUND  CaptureRecord_Direction = 1 // Получен зеркалирующим узлом
+	CaptureRecord_OUTBOUND CaptureRecord_Direction = 2 // Отправлен зеркалирующим узлом
+)
+
+// Enum value maps for CaptureRecord_Direction.
+var (
+	CaptureRecord_Direction_name = map[int32]string{
+		0: "GROUP",
+		1: "INBOUND",
+		2: "OUTBOUND",
+	}
+	CaptureRecord_Direction_value = map[string]int32{
+		"GROUP":    0,
+		"INBOUND":  1,
+		"OUTBOUND": 2,
+	}
+)
+
+func (x CaptureRecord_Direction) Enum() *CaptureRecord_Direction {
+	p := new(CaptureRecord_Direction)
+	*p = x
+	return p
+}
+
+func (x CaptureRecord_Direction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CaptureRecord_Direction) Descriptor() protoreflect.EnumDescriptor {
+	return file_capture_proto_enumTypes[0].Descriptor()
+}
+
+func (CaptureRecord_Direction) Type() protoreflect.EnumType {
+	return &file_capture_proto_enumTypes[0]
+}
+
+func (x CaptureRecord_Direction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Do not use.
+func (x *CaptureRecord_Direction) UnmarshalJSON(b []byte) error {
+	num, err := protoimpl.X.UnmarshalJSONEnum(x.Descriptor(), b)
+	if err != nil {
+		return err
+	}
+	*x = CaptureRecord_Direction(num)
+	return nil
+}
+
+// Deprecated: Use CaptureRecord_Direction.Descriptor instead.
+func (CaptureRecord_Direction) EnumDescriptor() ([]byte, []int) {
+	return file_capture_proto_rawDescGZIP(), []int{0, 0}
+}
+
+// Перехваченный пакет GameMessage. Узел с включённым зеркалированием отправляет такие записи
+// инструменту захвата, тот же формат (с префиксом длины) используется в файле захвата
+type CaptureRecord struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TimeUnixNano *int64                   `protobuf:"varint,1,req,name=time_unix_nano,json=timeUnixNano" json:"time_unix_nano,omitempty"` // Время перехвата
+	Direction    *CaptureRecord_Direction `protobuf:"varint,2,req,name=direction,enum=snakes.CaptureRecord_Direction" json:"direction,omitempty"`
+	Node         *string                  `protobuf:"bytes,3,opt,name=node" json:"node,omitempty"`       // Адрес unicast-сокета зеркалирующего узла, отсутствует для GROUP
+	Peer         *string                  `protobuf:"bytes,4,req,name=peer" json:"peer,omitempty"`       // Адрес другой стороны: отправителя для INBOUND и GROUP, получателя для OUTBOUND
+	Payload      []byte                   `protobuf:"bytes,5,req,name=payload" json:"payload,omitempty"` // Сериализованное GameMessage
+}
+
+func (x *CaptureRecord) Reset() {
+	*x = CaptureRecord{}
+	mi := &file_capture_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CaptureRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CaptureRecord) ProtoMessage() {}
+
+func (x *CaptureRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CaptureRecord.ProtoReflect.Descriptor instead.
+func (*CaptureRecord) Descriptor() ([]byte, []int) {
+	return file_capture_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CaptureRecord) GetTimeUnixNano() int64 {
+	if x != nil && x.TimeUnixNano != nil {
+		return *x.TimeUnixNano
+	}
+	return 0
+}
+
+func (x *CaptureRecord) GetDirection() CaptureRecord_Direction {
+	if x != nil && x.Direction != nil {
+		return *x.Direction
+	}
+	return CaptureRecord_GROUP
+}
+
+func (x *CaptureRecord) GetNode() string {
+	if x != nil && x.Node != nil {
+		return *x.Node
+	}
+	return ""
+}
+
+func (x *CaptureRecord) GetPeer() string {
+	if x != nil && x.Peer != nil {
+		return *x.Peer
+	}
+	return ""
+}
+
+func (x *CaptureRecord) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+var File_capture_proto protoreflect.FileDescriptor
+
+var file_capture_proto_rawDesc = []byte{
+	0x0a, 0x0d, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x06, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x22, 0xe9, 0x01, 0x0a, 0x0d, 0x43, 0x61, 0x70, 0x74,
+	0x75, 0x72, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x24, 0x0a, 0x0e, 0x74, 0x69, 0x6d,
+	0x65, 0x5f, 0x75, 0x6e, 0x69, 0x78, 0x5f, 0x6e, 0x61, 0x6e, 0x6f, 0x18, 0x01, 0x20, 0x02, 0x28,
+	0x03, 0x52, 0x0c, 0x74, 0x69, 0x6d, 0x65, 0x55, 0x6e, 0x69, 0x78, 0x4e, 0x61, 0x6e, 0x6f, 0x12,
+	0x3d, 0x0a, 0x09, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x02,
+	0x28, 0x0e, 0x32, 0x1f, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x43, 0x61, 0x70, 0x74,
+	0x75, 0x72, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x2e, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x52, 0x09, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12,
+	0x0a, 0x04, 0x6e, 0x6f, 0x64, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x6f,
+	0x64, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x65, 0x65, 0x72, 0x18, 0x04, 0x20, 0x02, 0x28, 0x09,
+	0x52, 0x04, 0x70, 0x65, 0x65, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61,
+	0x64, 0x18, 0x05, 0x20, 0x02, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64,
+	0x22, 0x31, 0x0a, 0x09, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x09, 0x0a,
+	0x05, 0x47, 0x52, 0x4f, 0x55, 0x50, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x49, 0x4e, 0x42, 0x4f,
+	0x55, 0x4e, 0x44, 0x10, 0x01, 0x12, 0x0c, 0x0a, 0x08, 0x4f, 0x55, 0x54, 0x42, 0x4f, 0x55, 0x4e,
+	0x44, 0x10, 0x02, 0x42, 0x09, 0x5a, 0x07, 0x2e, 0x2f, 0x6d, 0x6f, 0x64, 0x65, 0x6c,
+}
+
+var (
+	file_capture_proto_rawDescOnce sync.Once
+	file_capture_proto_rawDescData = file_capture_proto_rawDesc
+)
+
+func file_capture_proto_rawDescGZIP() []byte {
+	file_capture_proto_rawDescOnce.Do(func() {
+		file_capture_proto_rawDescData = protoimpl.X.CompressGZIP(file_capture_proto_rawDescData)
+	})
+	return file_capture_proto_rawDescData
+}
+
+var file_capture_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_capture_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_capture_proto_goTypes = []any{
+	(CaptureRecord_Direction)(0), // 0: snakes.CaptureRecord.Direction
+	(*CaptureRecord)(nil),        // 1: snakes.CaptureRecord
+}
+var file_capture_proto_depIdxs = []int32{
+	0, // 0: snakes.CaptureRecord.direction:type_name -> snakes.CaptureRecord.Direction
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_capture_proto_init() }
+func file_capture_proto_init() {
+	if File_capture_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_capture_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_capture_proto_goTypes,
+		DependencyIndexes: file_capture_proto_depIdxs,
+		EnumInfos:         file_capture_proto_enumTypes,
+		MessageInfos:      file_capture_proto_msgTypes,
+	}.Build()
+	File_capture_proto = out.File
+	file_capture_proto_rawDesc = nil
+	file_capture_proto_goTypes = nil
+	file_capture_proto_depIdxs = nil
+}
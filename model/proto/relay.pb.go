@@ -0,0 +1,378 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.35.2
+// 	protoc        v3.21.12
+// source: relay.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Сообщение между узлом и ретранслятором. Ретранслятор соединяет узлы из разных подсетей,
+// между которыми не проходят multicast и прямой UDP. Узлы известны ретранслятору по адресу,
+// с которого он видит их сокет, этот адрес заменяет узлу его собственный
+type RelayMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Type:
+	//
+	//	*RelayMessage_Register
+	//	*RelayMessage_Registered
+	//	*RelayMessage_Forward
+	Type isRelayMessage_Type `protobuf_oneof:"Type"`
+}
+
+func (x *RelayMessage) Reset() {
+	*x = RelayMessage{}
+	mi := &file_relay_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RelayMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RelayMessage) ProtoMessage() {}
+
+func (x *RelayMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_relay_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RelayMessage.ProtoReflect.Descriptor instead.
+func (*RelayMessage) Descriptor() ([]byte, []int) {
+	return file_relay_proto_rawDescGZIP(), []int{0}
+}
+
+func (m *RelayMessage) GetType() isRelayMessage_Type {
+	if m != nil {
+		return m.Type
+	}
+	return nil
+}
+
+func (x *RelayMessage) GetRegister() *RelayMessage_RegisterMsg {
+	if x, ok := x.GetType().(*RelayMessage_Register); ok {
+		return x.Register
+	}
+	return nil
+}
+
+func (x *RelayMessage) GetRegistered() *RelayMessage_RegisteredMsg {
+	if x, ok := x.GetType().(*RelayMessage_Registered); ok {
+		return x.Registered
+	}
+	return nil
+}
+
+func (x *RelayMessage) GetForward() *RelayMessage_ForwardMsg {
+	if x, ok := x.GetType().(*RelayMessage_Forward); ok {
+		return x.Forward
+	}
+	return nil
+}
+
+type isRelayMessage_Type interface {
+	isRelayMessage_Type()
+}
+
+type RelayMessage_Register struct {
+	Register *RelayMessage_RegisterMsg `protobuf:"bytes,1,opt,name=register,oneof"`
+}
+
+type RelayMessage_Registered struct {
+	Registered *RelayMessage_RegisteredMsg `protobuf:"bytes,2,opt,name=registered,oneof"`
+}
+
+type RelayMessage_Forward struct {
+	Forward *RelayMessage_ForwardMsg `protobuf:"bytes,3,opt,name=forward,oneof"`
+}
+
+func (*RelayMessage_Register) isRelayMessage_Type() {}
+
+func (*RelayMessage_Registered) isRelayMessage_Type() {}
+
+func (*RelayMessage_Forward) isRelayMessage_Type() {}
+
+// Регистрация сокета узла, повторяется периодически, чтобы ретранслятор не забыл узел
+type RelayMessage_RegisterMsg struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Multicast *bool `protobuf:"varint,1,opt,name=multicast,def=0" json:"multicast,omitempty"` // Сокет получает сообщения, отправленные на multicast-группу
+}
+
+// Default values for RelayMessage_RegisterMsg fields.
+const (
+	Default_RelayMessage_RegisterMsg_Multicast = bool(false)
+)
+
+func (x *RelayMessage_RegisterMsg) Reset() {
+	*x = RelayMessage_RegisterMsg{}
+	mi := &file_relay_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RelayMessage_RegisterMsg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RelayMessage_RegisterMsg) ProtoMessage() {}
+
+func (x *RelayMessage_RegisterMsg) ProtoReflect() protoreflect.Message {
+	mi := &file_relay_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RelayMessage_RegisterMsg.ProtoReflect.Descriptor instead.
+func (*RelayMessage_RegisterMsg) Descriptor() ([]byte, []int) {
+	return file_relay_proto_rawDescGZIP(), []int{0, 0}
+}
+
+func (x *RelayMessage_RegisterMsg) GetMulticast() bool {
+	if x != nil && x.Multicast != nil {
+		return *x.Multicast
+	}
+	return Default_RelayMessage_RegisterMsg_Multicast
+}
+
+// Ответ ретранслятора на RegisterMsg
+type RelayMessage_RegisteredMsg struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	IpAddress *string `protobuf:"bytes,1,req,name=ip_address,json=ipAddress" json:"ip_address,omitempty"` // Адрес сокета узла, каким его видит ретранслятор
+	Port      *int32  `protobuf:"varint,2,req,name=port" json:"port,omitempty"`                           // Порт сокета узла, каким его видит ретранслятор
+}
+
+func (x *RelayMessage_RegisteredMsg) Reset() {
+	*x = RelayMessage_RegisteredMsg{}
+	mi := &file_relay_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RelayMessage_RegisteredMsg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RelayMessage_RegisteredMsg) ProtoMessage() {}
+
+func (x *RelayMessage_RegisteredMsg) ProtoReflect() protoreflect.Message {
+	mi := &file_relay_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RelayMessage_RegisteredMsg.ProtoReflect.Descriptor instead.
+func (*RelayMessage_RegisteredMsg) Descriptor() ([]byte, []int) {
+	return file_relay_proto_rawDescGZIP(), []int{0, 1}
+}
+
+func (x *RelayMessage_RegisteredMsg) GetIpAddress() string {
+	if x != nil && x.IpAddress != nil {
+		return *x.IpAddress
+	}
+	return ""
+}
+
+func (x *RelayMessage_RegisteredMsg) GetPort() int32 {
+	if x != nil && x.Port != nil {
+		return *x.Port
+	}
+	return 0
+}
+
+// Пересылка GameMessage
+type RelayMessage_ForwardMsg struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	IpAddress *string `protobuf:"bytes,1,req,name=ip_address,json=ipAddress" json:"ip_address,omitempty"` // От узла: адрес получателя или multicast-группы. От ретранслятора: адрес отправителя
+	Port      *int32  `protobuf:"varint,2,req,name=port" json:"port,omitempty"`                           // Порт получателя или отправителя, как и ip_address
+	Payload   []byte  `protobuf:"bytes,3,req,name=payload" json:"payload,omitempty"`                      // Сериализованное GameMessage
+}
+
+func (x *RelayMessage_ForwardMsg) Reset() {
+	*x = RelayMessage_ForwardMsg{}
+	mi := &file_relay_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RelayMessage_ForwardMsg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RelayMessage_ForwardMsg) ProtoMessage() {}
+
+func (x *RelayMessage_ForwardMsg) ProtoReflect() protoreflect.Message {
+	mi := &file_relay_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RelayMessage_ForwardMsg.ProtoReflect.Descriptor instead.
+func (*RelayMessage_ForwardMsg) Descriptor() ([]byte, []int) {
+	return file_relay_proto_rawDescGZIP(), []int{0, 2}
+}
+
+func (x *RelayMessage_ForwardMsg) GetIpAddress() string {
+	if x != nil && x.IpAddress != nil {
+		return *x.IpAddress
+	}
+	return ""
+}
+
+func (x *RelayMessage_ForwardMsg) GetPort() int32 {
+	if x != nil && x.Port != nil {
+		return *x.Port
+	}
+	return 0
+}
+
+func (x *RelayMessage_ForwardMsg) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+var File_relay_proto protoreflect.FileDescriptor
+
+var file_relay_proto_rawDesc = []byte{
+	0x0a, 0x0b, 0x72, 0x65, 0x6c, 0x61, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x06, 0x73,
+	0x6e, 0x61, 0x6b, 0x65, 0x73, 0x22, 0xac, 0x03, 0x0a, 0x0c, 0x52, 0x65, 0x6c, 0x61, 0x79, 0x4d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x3e, 0x0a, 0x08, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74,
+	0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65,
+	0x73, 0x2e, 0x52, 0x65, 0x6c, 0x61, 0x79, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x52,
+	0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x4d, 0x73, 0x67, 0x48, 0x00, 0x52, 0x08, 0x72, 0x65,
+	0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x12, 0x44, 0x0a, 0x0a, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74,
+	0x65, 0x72, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x73, 0x6e, 0x61,
+	0x6b, 0x65, 0x73, 0x2e, 0x52, 0x65, 0x6c, 0x61, 0x79, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x65, 0x64, 0x4d, 0x73, 0x67, 0x48, 0x00,
+	0x52, 0x0a, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x65, 0x64, 0x12, 0x3b, 0x0a, 0x07,
+	0x66, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e,
+	0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x52, 0x65, 0x6c, 0x61, 0x79, 0x4d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x2e, 0x46, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x4d, 0x73, 0x67, 0x48, 0x00,
+	0x52, 0x07, 0x66, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x1a, 0x32, 0x0a, 0x0b, 0x52, 0x65, 0x67,
+	0x69, 0x73, 0x74, 0x65, 0x72, 0x4d, 0x73, 0x67, 0x12, 0x23, 0x0a, 0x09, 0x6d, 0x75, 0x6c, 0x74,
+	0x69, 0x63, 0x61, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x3a, 0x05, 0x66, 0x61, 0x6c,
+	0x73, 0x65, 0x52, 0x09, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x63, 0x61, 0x73, 0x74, 0x1a, 0x42, 0x0a,
+	0x0d, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x65, 0x64, 0x4d, 0x73, 0x67, 0x12, 0x1d,
+	0x0a, 0x0a, 0x69, 0x70, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x02,
+	0x28, 0x09, 0x52, 0x09, 0x69, 0x70, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x12, 0x0a,
+	0x04, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x02, 0x20, 0x02, 0x28, 0x05, 0x52, 0x04, 0x70, 0x6f, 0x72,
+	0x74, 0x1a, 0x59, 0x0a, 0x0a, 0x46, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x4d, 0x73, 0x67, 0x12,
+	0x1d, 0x0a, 0x0a, 0x69, 0x70, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20,
+	0x02, 0x28, 0x09, 0x52, 0x09, 0x69, 0x70, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x12,
+	0x0a, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x02, 0x20, 0x02, 0x28, 0x05, 0x52, 0x04, 0x70, 0x6f,
+	0x72, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x03, 0x20,
+	0x02, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x42, 0x06, 0x0a, 0x04,
+	0x54, 0x79, 0x70, 0x65, 0x42, 0x09, 0x5a, 0x07, 0x2e, 0x2f, 0x6d, 0x6f, 0x64, 0x65, 0x6c,
+}
+
+var (
+	file_relay_proto_rawDescOnce sync.Once
+	file_relay_proto_rawDescData = file_relay_proto_rawDesc
+)
+
+func file_relay_proto_rawDescGZIP() []byte {
+	file_relay_proto_rawDescOnce.Do(func() {
+		file_relay_proto_rawDescData = protoimpl.X.CompressGZIP(file_relay_proto_rawDescData)
+	})
+	return file_relay_proto_rawDescData
+}
+
+var file_relay_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_relay_proto_goTypes = []any{
+	(*RelayMessage)(nil),               // 0: snakes.RelayMessage
+	(*RelayMessage_RegisterMsg)(nil),   // 1: snakes.RelayMessage.RegisterMsg
+	(*RelayMessage_RegisteredMsg)(nil), // 2: snakes.RelayMessage.RegisteredMsg
+	(*RelayMessage_ForwardMsg)(nil),    // 3: snakes.RelayMessage.ForwardMsg
+}
+var file_relay_proto_depIdxs = []int32{
+	1, // 0: snakes.RelayMessage.register:type_name -> snakes.RelayMessage.RegisterMsg
+	2, // 1: snakes.RelayMessage.registered:type_name -> snakes.RelayMessage.RegisteredMsg
+	3, // 2: snakes.RelayMessage.forward:type_name -> snakes.RelayMessage.ForwardMsg
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_relay_proto_init() }
+func file_relay_proto_init() {
+	if File_relay_proto != nil {
+		return
+	}
+	file_relay_proto_msgTypes[0].OneofWrappers = []any{
+		(*RelayMessage_Register)(nil),
+		(*RelayMessage_Registered)(nil),
+		(*RelayMessage_Forward)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_relay_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_relay_proto_goTypes,
+		DependencyIndexes: file_relay_proto_depIdxs,
+		MessageInfos:      file_relay_proto_msgTypes,
+	}.Build()
+	File_relay_proto = out.File
+	file_relay_proto_rawDesc = nil
+	file_relay_proto_goTypes = nil
+	file_relay_proto_depIdxs = nil
+}
@@ -140,6 +140,73 @@ func (PlayerType) EnumDescriptor() ([]byte, []int) {
 	return file_snakes_proto_rawDescGZIP(), []int{1}
 }
 
+// Возможности узла сверх базового протокола. Узлы объявляют их при поиске игры и присоединении
+// и пользуются только теми, что поддерживают обе стороны. Старые узлы эти поля игнорируют
+type Capability int32
+
+const (
+	Capability_UNKNOWN_CAPABILITY Capability = 0 // Значение по умолчанию, узлы его не объявляют
+	Capability_SESSIONS           Capability = 1 // Токен сессии в AckMsg, по нему игрок возвращается в игру после обрыва связи
+	Capability_SOLID_WALLS        Capability = 2 // Стены по краям поля из GameConfig.solid_walls
+	Capability_OBSTACLES          Capability = 3 // Карта поля из GameConfig.game_map
+	Capability_PORTALS            Capability = 4 // Порталы карты из GameMap.portals
+)
+
+// Enum value maps for Capability.
+var (
+	Capability_name = map[int32]string{
+		0: "UNKNOWN_CAPABILITY",
+		1: "SESSIONS",
+		2: "SOLID_WALLS",
+		3: "OBSTACLES",
+		4: "PORTALS",
+	}
+	Capability_value = map[string]int32{
+		"UNKNOWN_CAPABILITY": 0,
+		"SESSIONS":           1,
+		"SOLID_WALLS":        2,
+		"OBSTACLES":          3,
+		"PORTALS":            4,
+	}
+)
+
+func (x Capability) Enum() *Capability {
+	p := new(Capability)
+	*p = x
+	return p
+}
+
+func (x Capability) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Capability) Descriptor() protoreflect.EnumDescriptor {
+	return file_snakes_proto_enumTypes[2].Descriptor()
+}
+
+func (Capability) Type() protoreflect.EnumType {
+	return &file_snakes_proto_enumTypes[2]
+}
+
+func (x Capability) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Do not use.
+func (x *Capability) UnmarshalJSON(b []byte) error {
+	num, err := protoimpl.X.UnmarshalJSONEnum(x.Descriptor(), b)
+	if err != nil {
+		return err
+	}
+	*x = Capability(num)
+	return nil
+}
+
+// Deprecated: Use Capability.Descriptor instead.
+func (Capability) EnumDescriptor() ([]byte, []int) {
+	return file_snakes_proto_rawDescGZIP(), []int{2}
+}
+
 type Direction int32
 
 const (
@@ -176,11 +243,11 @@ func (x Direction) String() string {
 }
 
 func (Direction) Descriptor() protoreflect.EnumDescriptor {
-	return file_snakes_proto_enumTypes[2].Descriptor()
+	return file_snakes_proto_enumTypes[3].Descriptor()
 }
 
 func (Direction) Type() protoreflect.EnumType {
-	return &file_snakes_proto_enumTypes[2]
+	return &file_snakes_proto_enumTypes[3]
 }
 
 func (x Direction) Number() protoreflect.EnumNumber {
@@ -199,7 +266,7 @@ func (x *Direction) UnmarshalJSON(b []byte) error {
 
 // Deprecated: Use Direction.Descriptor instead.
 func (Direction) EnumDescriptor() ([]byte, []int) {
-	return file_snakes_proto_rawDescGZIP(), []int{2}
+	return file_snakes_proto_rawDescGZIP(), []int{3}
 }
 
 // Статус змеи в игре
@@ -233,11 +300,11 @@ func (x GameState_Snake_SnakeState) String() string {
 }
 
 func (GameState_Snake_SnakeState) Descriptor() protoreflect.EnumDescriptor {
-	return file_snakes_proto_enumTypes[3].Descriptor()
+	return file_snakes_proto_enumTypes[4].Descriptor()
 }
 
 func (GameState_Snake_SnakeState) Type() protoreflect.EnumType {
-	return &file_snakes_proto_enumTypes[3]
+	return &file_snakes_proto_enumTypes[4]
 }
 
 func (x GameState_Snake_SnakeState) Number() protoreflect.EnumNumber {
@@ -256,7 +323,7 @@ func (x *GameState_Snake_SnakeState) UnmarshalJSON(b []byte) error {
 
 // Deprecated: Use GameState_Snake_SnakeState.Descriptor instead.
 func (GameState_Snake_SnakeState) EnumDescriptor() ([]byte, []int) {
-	return file_snakes_proto_rawDescGZIP(), []int{3, 1, 0}
+	return file_snakes_proto_rawDescGZIP(), []int{5, 1, 0}
 }
 
 // Игрок
@@ -364,10 +431,12 @@ type GameConfig struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Width        *int32 `protobuf:"varint,1,opt,name=width,def=40" json:"width,omitempty"`                                       // Ширина поля в клетках (от 10 до 100)
-	Height       *int32 `protobuf:"varint,2,opt,name=height,def=30" json:"height,omitempty"`                                     // Высота поля в клетках (от 10 до 100)
-	FoodStatic   *int32 `protobuf:"varint,3,opt,name=food_static,json=foodStatic,def=1" json:"food_static,omitempty"`            // Количество клеток с едой, независимо от числа игроков (от 0 до 100)
-	StateDelayMs *int32 `protobuf:"varint,5,opt,name=state_delay_ms,json=stateDelayMs,def=1000" json:"state_delay_ms,omitempty"` // Задержка между ходами (сменой состояний) в игре, в миллисекундах (от 100 до 3000)
+	Width        *int32   `protobuf:"varint,1,opt,name=width,def=40" json:"width,omitempty"`                                       // Ширина поля в клетках (от 10 до 100)
+	Height       *int32   `protobuf:"varint,2,opt,name=height,def=30" json:"height,omitempty"`                                     // Высота поля в клетках (от 10 до 100)
+	FoodStatic   *int32   `protobuf:"varint,3,opt,name=food_static,json=foodStatic,def=1" json:"food_static,omitempty"`            // Количество клеток с едой, независимо от числа игроков (от 0 до 100)
+	StateDelayMs *int32   `protobuf:"varint,5,opt,name=state_delay_ms,json=stateDelayMs,def=1000" json:"state_delay_ms,omitempty"` // Задержка между ходами (сменой состояний) в игре, в миллисекундах (от 100 до 3000)
+	SolidWalls   *bool    `protobuf:"varint,6,opt,name=solid_walls,json=solidWalls,def=0" json:"solid_walls,omitempty"`            // Края поля - стены: змея, врезавшаяся в край, погибает. Иначе поле склеено по краям
+	GameMap      *GameMap `protobuf:"bytes,7,opt,name=game_map,json=gameMap" json:"game_map,omitempty"`                            // Препятствия и места появления змей, отсутствует у пустого поля
 }
 
 // Default values for GameConfig fields.
@@ -376,6 +445,7 @@ const (
 	Default_GameConfig_Height       = int32(30)
 	Default_GameConfig_FoodStatic   = int32(1)
 	Default_GameConfig_StateDelayMs = int32(1000)
+	Default_GameConfig_SolidWalls   = bool(false)
 )
 
 func (x *GameConfig) Reset() {
@@ -436,6 +506,146 @@ func (x *GameConfig) GetStateDelayMs() int32 {
 	return Default_GameConfig_StateDelayMs
 }
 
+func (x *GameConfig) GetSolidWalls() bool {
+	if x != nil && x.SolidWalls != nil {
+		return *x.SolidWalls
+	}
+	return Default_GameConfig_SolidWalls
+}
+
+func (x *GameConfig) GetGameMap() *GameMap {
+	if x != nil {
+		return x.GameMap
+	}
+	return nil
+}
+
+// Карта поля. Клетки хранятся битами по строкам: клетке (x, y) соответствует бит номер y*width+x,
+// младший бит первого байта - клетка (0, 0). Длина - (width*height+7)/8 байт
+type GameMap struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name       *string   `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`                               // Название карты (для отображения в интерфейсе)
+	Obstacles  []byte    `protobuf:"bytes,2,opt,name=obstacles" json:"obstacles,omitempty"`                     // Препятствия: змея, попавшая на них головой, погибает, еда на них не появляется
+	SpawnZones []byte    `protobuf:"bytes,3,opt,name=spawn_zones,json=spawnZones" json:"spawn_zones,omitempty"` // Клетки, где может появиться голова новой змеи. Отсутствует - любые свободные
+	Portals    []*Portal `protobuf:"bytes,4,rep,name=portals" json:"portals,omitempty"`                         // Пары порталов
+}
+
+func (x *GameMap) Reset() {
+	*x = GameMap{}
+	mi := &file_snakes_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GameMap) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GameMap) ProtoMessage() {}
+
+func (x *GameMap) ProtoReflect() protoreflect.Message {
+	mi := &file_snakes_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GameMap.ProtoReflect.Descriptor instead.
+func (*GameMap) Descriptor() ([]byte, []int) {
+	return file_snakes_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GameMap) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *GameMap) GetObstacles() []byte {
+	if x != nil {
+		return x.Obstacles
+	}
+	return nil
+}
+
+func (x *GameMap) GetSpawnZones() []byte {
+	if x != nil {
+		return x.SpawnZones
+	}
+	return nil
+}
+
+func (x *GameMap) GetPortals() []*Portal {
+	if x != nil {
+		return x.Portals
+	}
+	return nil
+}
+
+// Пара порталов. Голова змеи, вошедшая в одну клетку пары, оказывается в другой и движется дальше
+// в том же направлении, тело следует за ней. Как передаётся такая змея, см. GameState.Snake.points
+type Portal struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	A *GameState_Coord `protobuf:"bytes,1,req,name=a" json:"a,omitempty"`
+	B *GameState_Coord `protobuf:"bytes,2,req,name=b" json:"b,omitempty"`
+}
+
+func (x *Portal) Reset() {
+	*x = Portal{}
+	mi := &file_snakes_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Portal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Portal) ProtoMessage() {}
+
+func (x *Portal) ProtoReflect() protoreflect.Message {
+	mi := &file_snakes_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Portal.ProtoReflect.Descriptor instead.
+func (*Portal) Descriptor() ([]byte, []int) {
+	return file_snakes_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Portal) GetA() *GameState_Coord {
+	if x != nil {
+		return x.A
+	}
+	return nil
+}
+
+func (x *Portal) GetB() *GameState_Coord {
+	if x != nil {
+		return x.B
+	}
+	return nil
+}
+
 // Игроки конкретной игры
 type GamePlayers struct {
 	state         protoimpl.MessageState
@@ -447,7 +657,7 @@ type GamePlayers struct {
 
 func (x *GamePlayers) Reset() {
 	*x = GamePlayers{}
-	mi := &file_snakes_proto_msgTypes[2]
+	mi := &file_snakes_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -459,7 +669,7 @@ func (x *GamePlayers) String() string {
 func (*GamePlayers) ProtoMessage() {}
 
 func (x *GamePlayers) ProtoReflect() protoreflect.Message {
-	mi := &file_snakes_proto_msgTypes[2]
+	mi := &file_snakes_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -472,7 +682,7 @@ func (x *GamePlayers) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GamePlayers.ProtoReflect.Descriptor instead.
 func (*GamePlayers) Descriptor() ([]byte, []int) {
-	return file_snakes_proto_rawDescGZIP(), []int{2}
+	return file_snakes_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *GamePlayers) GetPlayers() []*GamePlayer {
@@ -496,7 +706,7 @@ type GameState struct {
 
 func (x *GameState) Reset() {
 	*x = GameState{}
-	mi := &file_snakes_proto_msgTypes[3]
+	mi := &file_snakes_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -508,7 +718,7 @@ func (x *GameState) String() string {
 func (*GameState) ProtoMessage() {}
 
 func (x *GameState) ProtoReflect() protoreflect.Message {
-	mi := &file_snakes_proto_msgTypes[3]
+	mi := &file_snakes_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -521,7 +731,7 @@ func (x *GameState) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GameState.ProtoReflect.Descriptor instead.
 func (*GameState) Descriptor() ([]byte, []int) {
-	return file_snakes_proto_rawDescGZIP(), []int{3}
+	return file_snakes_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *GameState) GetStateOrder() int32 {
@@ -557,10 +767,13 @@ type GameAnnouncement struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Players  *GamePlayers `protobuf:"bytes,1,req,name=players" json:"players,omitempty"`                       // Текущие игроки
-	Config   *GameConfig  `protobuf:"bytes,2,req,name=config" json:"config,omitempty"`                         // Параметры игры
-	CanJoin  *bool        `protobuf:"varint,3,opt,name=can_join,json=canJoin,def=1" json:"can_join,omitempty"` // Можно ли новому игроку присоединиться к игре (есть ли место на поле)
-	GameName *string      `protobuf:"bytes,4,req,name=game_name,json=gameName" json:"game_name,omitempty"`     // Глобально уникальное имя игры, например "my game"
+	Players              *GamePlayers `protobuf:"bytes,1,req,name=players" json:"players,omitempty"`                                                                               // Текущие игроки
+	Config               *GameConfig  `protobuf:"bytes,2,req,name=config" json:"config,omitempty"`                                                                                 // Параметры игры
+	CanJoin              *bool        `protobuf:"varint,3,opt,name=can_join,json=canJoin,def=1" json:"can_join,omitempty"`                                                         // Можно ли новому игроку присоединиться к игре (есть ли место на поле)
+	GameName             *string      `protobuf:"bytes,4,req,name=game_name,json=gameName" json:"game_name,omitempty"`                                                             // Глобально уникальное имя игры, например "my game"
+	ProtocolVersion      *int32       `protobuf:"varint,5,opt,name=protocol_version,json=protocolVersion" json:"protocol_version,omitempty"`                                       // Версия протокола мастера, отсутствует у старых узлов
+	Capabilities         []Capability `protobuf:"varint,6,rep,name=capabilities,enum=snakes.Capability" json:"capabilities,omitempty"`                                             // Возможности, которые поддерживает мастер
+	RequiredCapabilities []Capability `protobuf:"varint,7,rep,name=required_capabilities,json=requiredCapabilities,enum=snakes.Capability" json:"required_capabilities,omitempty"` // Возможности, без которых в игру нельзя играть
 }
 
 // Default values for GameAnnouncement fields.
@@ -570,7 +783,7 @@ const (
 
 func (x *GameAnnouncement) Reset() {
 	*x = GameAnnouncement{}
-	mi := &file_snakes_proto_msgTypes[4]
+	mi := &file_snakes_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -582,7 +795,7 @@ func (x *GameAnnouncement) String() string {
 func (*GameAnnouncement) ProtoMessage() {}
 
 func (x *GameAnnouncement) ProtoReflect() protoreflect.Message {
-	mi := &file_snakes_proto_msgTypes[4]
+	mi := &file_snakes_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -595,7 +808,7 @@ func (x *GameAnnouncement) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GameAnnouncement.ProtoReflect.Descriptor instead.
 func (*GameAnnouncement) Descriptor() ([]byte, []int) {
-	return file_snakes_proto_rawDescGZIP(), []int{4}
+	return file_snakes_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *GameAnnouncement) GetPlayers() *GamePlayers {
@@ -626,6 +839,27 @@ func (x *GameAnnouncement) GetGameName() string {
 	return ""
 }
 
+func (x *GameAnnouncement) GetProtocolVersion() int32 {
+	if x != nil && x.ProtocolVersion != nil {
+		return *x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *GameAnnouncement) GetCapabilities() []Capability {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *GameAnnouncement) GetRequiredCapabilities() []Capability {
+	if x != nil {
+		return x.RequiredCapabilities
+	}
+	return nil
+}
+
 // Общий формат любого UDP-сообщения
 type GameMessage struct {
 	state         protoimpl.MessageState
@@ -635,6 +869,7 @@ type GameMessage struct {
 	MsgSeq     *int64 `protobuf:"varint,1,req,name=msg_seq,json=msgSeq" json:"msg_seq,omitempty"`              // Порядковый номер сообщения, уникален для отправителя в пределах игры, монотонно возрастает
 	SenderId   *int32 `protobuf:"varint,10,opt,name=sender_id,json=senderId" json:"sender_id,omitempty"`       // ID игрока-отправителя этого сообщения (обязательно для AckMsg и RoleChangeMsg)
 	ReceiverId *int32 `protobuf:"varint,11,opt,name=receiver_id,json=receiverId" json:"receiver_id,omitempty"` // ID игрока-получателя этого сообщения (обязательно для AckMsg и RoleChangeMsg)
+	SessionTag []byte `protobuf:"bytes,13,opt,name=session_tag,json=sessionTag" json:"session_tag,omitempty"`  // При SESSIONS: подпись сообщения токеном сессии, по ней мастер узнаёт игрока с нового адреса
 	// Тип сообщения
 	//
 	// Types that are assignable to Type:
@@ -653,7 +888,7 @@ type GameMessage struct {
 
 func (x *GameMessage) Reset() {
 	*x = GameMessage{}
-	mi := &file_snakes_proto_msgTypes[5]
+	mi := &file_snakes_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -665,7 +900,7 @@ func (x *GameMessage) String() string {
 func (*GameMessage) ProtoMessage() {}
 
 func (x *GameMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_snakes_proto_msgTypes[5]
+	mi := &file_snakes_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -678,7 +913,7 @@ func (x *GameMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GameMessage.ProtoReflect.Descriptor instead.
 func (*GameMessage) Descriptor() ([]byte, []int) {
-	return file_snakes_proto_rawDescGZIP(), []int{5}
+	return file_snakes_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *GameMessage) GetMsgSeq() int64 {
@@ -702,6 +937,13 @@ func (x *GameMessage) GetReceiverId() int32 {
 	return 0
 }
 
+func (x *GameMessage) GetSessionTag() []byte {
+	if x != nil {
+		return x.SessionTag
+	}
+	return nil
+}
+
 func (m *GameMessage) GetType() isGameMessage_Type {
 	if m != nil {
 		return m.Type
@@ -850,7 +1092,7 @@ const (
 
 func (x *GameState_Coord) Reset() {
 	*x = GameState_Coord{}
-	mi := &file_snakes_proto_msgTypes[6]
+	mi := &file_snakes_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -862,7 +1104,7 @@ func (x *GameState_Coord) String() string {
 func (*GameState_Coord) ProtoMessage() {}
 
 func (x *GameState_Coord) ProtoReflect() protoreflect.Message {
-	mi := &file_snakes_proto_msgTypes[6]
+	mi := &file_snakes_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -875,7 +1117,7 @@ func (x *GameState_Coord) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GameState_Coord.ProtoReflect.Descriptor instead.
 func (*GameState_Coord) Descriptor() ([]byte, []int) {
-	return file_snakes_proto_rawDescGZIP(), []int{3, 0}
+	return file_snakes_proto_rawDescGZIP(), []int{5, 0}
 }
 
 func (x *GameState_Coord) GetX() int32 {
@@ -902,6 +1144,10 @@ type GameState_Snake struct {
 	// Список "ключевых" точек змеи. Первая точка хранит координаты головы змеи.
 	// Каждая следующая - смещение следующей "ключевой" точки относительно предыдущей,
 	// в частности последняя точка хранит смещение хвоста змеи относительно предыдущей "ключевой" точки.
+	// Смещение проходит по клеткам змеи от головы к хвосту и может переходить через край поля.
+	// Если клетка змеи лежит на портале, следующая за ней клетка отсчитывается от парной клетки портала:
+	// голова вышла из этой клетки, а вошла в портал из клетки рядом с парной. Поэтому змея, прошедшая
+	// через портал не поворачивая, описывается одним смещением, как если бы портала не было.
 	Points        []*GameState_Coord          `protobuf:"bytes,2,rep,name=points" json:"points,omitempty"`
 	State         *GameState_Snake_SnakeState `protobuf:"varint,3,req,name=state,enum=snakes.GameState_Snake_SnakeState,def=0" json:"state,omitempty"`               // статус змеи в игре
 	HeadDirection *Direction                  `protobuf:"varint,4,req,name=head_direction,json=headDirection,enum=snakes.Direction" json:"head_direction,omitempty"` // Направление, в котором "повёрнута" голова змейки в текущий момент
@@ -914,7 +1160,7 @@ const (
 
 func (x *GameState_Snake) Reset() {
 	*x = GameState_Snake{}
-	mi := &file_snakes_proto_msgTypes[7]
+	mi := &file_snakes_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -926,7 +1172,7 @@ func (x *GameState_Snake) String() string {
 func (*GameState_Snake) ProtoMessage() {}
 
 func (x *GameState_Snake) ProtoReflect() protoreflect.Message {
-	mi := &file_snakes_proto_msgTypes[7]
+	mi := &file_snakes_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -939,7 +1185,7 @@ func (x *GameState_Snake) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GameState_Snake.ProtoReflect.Descriptor instead.
 func (*GameState_Snake) Descriptor() ([]byte, []int) {
-	return file_snakes_proto_rawDescGZIP(), []int{3, 1}
+	return file_snakes_proto_rawDescGZIP(), []int{5, 1}
 }
 
 func (x *GameState_Snake) GetPlayerId() int32 {
@@ -979,7 +1225,7 @@ type GameMessage_PingMsg struct {
 
 func (x *GameMessage_PingMsg) Reset() {
 	*x = GameMessage_PingMsg{}
-	mi := &file_snakes_proto_msgTypes[8]
+	mi := &file_snakes_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -991,7 +1237,7 @@ func (x *GameMessage_PingMsg) String() string {
 func (*GameMessage_PingMsg) ProtoMessage() {}
 
 func (x *GameMessage_PingMsg) ProtoReflect() protoreflect.Message {
-	mi := &file_snakes_proto_msgTypes[8]
+	mi := &file_snakes_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1004,7 +1250,7 @@ func (x *GameMessage_PingMsg) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GameMessage_PingMsg.ProtoReflect.Descriptor instead.
 func (*GameMessage_PingMsg) Descriptor() ([]byte, []int) {
-	return file_snakes_proto_rawDescGZIP(), []int{5, 0}
+	return file_snakes_proto_rawDescGZIP(), []int{7, 0}
 }
 
 // Не-центральный игрок просит повернуть голову змеи
@@ -1018,7 +1264,7 @@ type GameMessage_SteerMsg struct {
 
 func (x *GameMessage_SteerMsg) Reset() {
 	*x = GameMessage_SteerMsg{}
-	mi := &file_snakes_proto_msgTypes[9]
+	mi := &file_snakes_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1030,7 +1276,7 @@ func (x *GameMessage_SteerMsg) String() string {
 func (*GameMessage_SteerMsg) ProtoMessage() {}
 
 func (x *GameMessage_SteerMsg) ProtoReflect() protoreflect.Message {
-	mi := &file_snakes_proto_msgTypes[9]
+	mi := &file_snakes_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1043,7 +1289,7 @@ func (x *GameMessage_SteerMsg) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GameMessage_SteerMsg.ProtoReflect.Descriptor instead.
 func (*GameMessage_SteerMsg) Descriptor() ([]byte, []int) {
-	return file_snakes_proto_rawDescGZIP(), []int{5, 1}
+	return file_snakes_proto_rawDescGZIP(), []int{7, 1}
 }
 
 func (x *GameMessage_SteerMsg) GetDirection() Direction {
@@ -1058,11 +1304,15 @@ type GameMessage_AckMsg struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
+
+	ProtocolVersion *int32       `protobuf:"varint,1,opt,name=protocol_version,json=protocolVersion" json:"protocol_version,omitempty"` // Только в ответ на JoinMsg: версия протокола мастера
+	Capabilities    []Capability `protobuf:"varint,2,rep,name=capabilities,enum=snakes.Capability" json:"capabilities,omitempty"`       // Только в ответ на JoinMsg: возможности, которые поддерживают обе стороны
+	SessionToken    []byte       `protobuf:"bytes,3,opt,name=session_token,json=sessionToken" json:"session_token,omitempty"`           // Только в ответ на JoinMsg при SESSIONS: токен для возвращения в игру
 }
 
 func (x *GameMessage_AckMsg) Reset() {
 	*x = GameMessage_AckMsg{}
-	mi := &file_snakes_proto_msgTypes[10]
+	mi := &file_snakes_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1074,7 +1324,7 @@ func (x *GameMessage_AckMsg) String() string {
 func (*GameMessage_AckMsg) ProtoMessage() {}
 
 func (x *GameMessage_AckMsg) ProtoReflect() protoreflect.Message {
-	mi := &file_snakes_proto_msgTypes[10]
+	mi := &file_snakes_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1087,7 +1337,28 @@ func (x *GameMessage_AckMsg) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GameMessage_AckMsg.ProtoReflect.Descriptor instead.
 func (*GameMessage_AckMsg) Descriptor() ([]byte, []int) {
-	return file_snakes_proto_rawDescGZIP(), []int{5, 2}
+	return file_snakes_proto_rawDescGZIP(), []int{7, 2}
+}
+
+func (x *GameMessage_AckMsg) GetProtocolVersion() int32 {
+	if x != nil && x.ProtocolVersion != nil {
+		return *x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *GameMessage_AckMsg) GetCapabilities() []Capability {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *GameMessage_AckMsg) GetSessionToken() []byte {
+	if x != nil {
+		return x.SessionToken
+	}
+	return nil
 }
 
 // Центральный узел сообщает остальным игрокам состояние игры
@@ -1101,7 +1372,7 @@ type GameMessage_StateMsg struct {
 
 func (x *GameMessage_StateMsg) Reset() {
 	*x = GameMessage_StateMsg{}
-	mi := &file_snakes_proto_msgTypes[11]
+	mi := &file_snakes_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1113,7 +1384,7 @@ func (x *GameMessage_StateMsg) String() string {
 func (*GameMessage_StateMsg) ProtoMessage() {}
 
 func (x *GameMessage_StateMsg) ProtoReflect() protoreflect.Message {
-	mi := &file_snakes_proto_msgTypes[11]
+	mi := &file_snakes_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1126,7 +1397,7 @@ func (x *GameMessage_StateMsg) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GameMessage_StateMsg.ProtoReflect.Descriptor instead.
 func (*GameMessage_StateMsg) Descriptor() ([]byte, []int) {
-	return file_snakes_proto_rawDescGZIP(), []int{5, 3}
+	return file_snakes_proto_rawDescGZIP(), []int{7, 3}
 }
 
 func (x *GameMessage_StateMsg) GetState() *GameState {
@@ -1147,7 +1418,7 @@ type GameMessage_AnnouncementMsg struct {
 
 func (x *GameMessage_AnnouncementMsg) Reset() {
 	*x = GameMessage_AnnouncementMsg{}
-	mi := &file_snakes_proto_msgTypes[12]
+	mi := &file_snakes_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1159,7 +1430,7 @@ func (x *GameMessage_AnnouncementMsg) String() string {
 func (*GameMessage_AnnouncementMsg) ProtoMessage() {}
 
 func (x *GameMessage_AnnouncementMsg) ProtoReflect() protoreflect.Message {
-	mi := &file_snakes_proto_msgTypes[12]
+	mi := &file_snakes_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1172,7 +1443,7 @@ func (x *GameMessage_AnnouncementMsg) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GameMessage_AnnouncementMsg.ProtoReflect.Descriptor instead.
 func (*GameMessage_AnnouncementMsg) Descriptor() ([]byte, []int) {
-	return file_snakes_proto_rawDescGZIP(), []int{5, 4}
+	return file_snakes_proto_rawDescGZIP(), []int{7, 4}
 }
 
 func (x *GameMessage_AnnouncementMsg) GetGames() []*GameAnnouncement {
@@ -1191,7 +1462,7 @@ type GameMessage_DiscoverMsg struct {
 
 func (x *GameMessage_DiscoverMsg) Reset() {
 	*x = GameMessage_DiscoverMsg{}
-	mi := &file_snakes_proto_msgTypes[13]
+	mi := &file_snakes_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1203,7 +1474,7 @@ func (x *GameMessage_DiscoverMsg) String() string {
 func (*GameMessage_DiscoverMsg) ProtoMessage() {}
 
 func (x *GameMessage_DiscoverMsg) ProtoReflect() protoreflect.Message {
-	mi := &file_snakes_proto_msgTypes[13]
+	mi := &file_snakes_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1216,7 +1487,7 @@ func (x *GameMessage_DiscoverMsg) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GameMessage_DiscoverMsg.ProtoReflect.Descriptor instead.
 func (*GameMessage_DiscoverMsg) Descriptor() ([]byte, []int) {
-	return file_snakes_proto_rawDescGZIP(), []int{5, 5}
+	return file_snakes_proto_rawDescGZIP(), []int{7, 5}
 }
 
 // Новый игрок хочет присоединиться к идущей игре
@@ -1225,10 +1496,13 @@ type GameMessage_JoinMsg struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	PlayerType    *PlayerType `protobuf:"varint,1,opt,name=player_type,json=playerType,enum=snakes.PlayerType,def=0" json:"player_type,omitempty"`  // Тип присоединяющегося игрока
-	PlayerName    *string     `protobuf:"bytes,3,req,name=player_name,json=playerName" json:"player_name,omitempty"`                                // Имя игрока
-	GameName      *string     `protobuf:"bytes,4,req,name=game_name,json=gameName" json:"game_name,omitempty"`                                      // Глобально уникальное имя игры, к которой хотим присоединиться
-	RequestedRole *NodeRole   `protobuf:"varint,5,req,name=requested_role,json=requestedRole,enum=snakes.NodeRole" json:"requested_role,omitempty"` // NORMAL, если хотим играть; VIEWER, если хотим только понаблюдать; остальные значения недопустимы
+	PlayerType      *PlayerType  `protobuf:"varint,1,opt,name=player_type,json=playerType,enum=snakes.PlayerType,def=0" json:"player_type,omitempty"`  // Тип присоединяющегося игрока
+	PlayerName      *string      `protobuf:"bytes,3,req,name=player_name,json=playerName" json:"player_name,omitempty"`                                // Имя игрока
+	GameName        *string      `protobuf:"bytes,4,req,name=game_name,json=gameName" json:"game_name,omitempty"`                                      // Глобально уникальное имя игры, к которой хотим присоединиться
+	RequestedRole   *NodeRole    `protobuf:"varint,5,req,name=requested_role,json=requestedRole,enum=snakes.NodeRole" json:"requested_role,omitempty"` // NORMAL, если хотим играть; VIEWER, если хотим только понаблюдать; остальные значения недопустимы
+	ProtocolVersion *int32       `protobuf:"varint,6,opt,name=protocol_version,json=protocolVersion" json:"protocol_version,omitempty"`                // Версия протокола игрока, отсутствует у старых узлов
+	Capabilities    []Capability `protobuf:"varint,7,rep,name=capabilities,enum=snakes.Capability" json:"capabilities,omitempty"`                      // Возможности, которые поддерживает игрок
+	SessionToken    []byte       `protobuf:"bytes,8,opt,name=session_token,json=sessionToken" json:"session_token,omitempty"`                          // Токен из AckMsg, если игрок возвращается в игру после обрыва связи
 }
 
 // Default values for GameMessage_JoinMsg fields.
@@ -1238,7 +1512,7 @@ const (
 
 func (x *GameMessage_JoinMsg) Reset() {
 	*x = GameMessage_JoinMsg{}
-	mi := &file_snakes_proto_msgTypes[14]
+	mi := &file_snakes_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1250,7 +1524,7 @@ func (x *GameMessage_JoinMsg) String() string {
 func (*GameMessage_JoinMsg) ProtoMessage() {}
 
 func (x *GameMessage_JoinMsg) ProtoReflect() protoreflect.Message {
-	mi := &file_snakes_proto_msgTypes[14]
+	mi := &file_snakes_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1263,7 +1537,7 @@ func (x *GameMessage_JoinMsg) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GameMessage_JoinMsg.ProtoReflect.Descriptor instead.
 func (*GameMessage_JoinMsg) Descriptor() ([]byte, []int) {
-	return file_snakes_proto_rawDescGZIP(), []int{5, 6}
+	return file_snakes_proto_rawDescGZIP(), []int{7, 6}
 }
 
 func (x *GameMessage_JoinMsg) GetPlayerType() PlayerType {
@@ -1294,6 +1568,27 @@ func (x *GameMessage_JoinMsg) GetRequestedRole() NodeRole {
 	return NodeRole_NORMAL
 }
 
+func (x *GameMessage_JoinMsg) GetProtocolVersion() int32 {
+	if x != nil && x.ProtocolVersion != nil {
+		return *x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *GameMessage_JoinMsg) GetCapabilities() []Capability {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *GameMessage_JoinMsg) GetSessionToken() []byte {
+	if x != nil {
+		return x.SessionToken
+	}
+	return nil
+}
+
 // Ошибка операции (например отказ в присоединении к игре, т.к. нет места на поле)
 type GameMessage_ErrorMsg struct {
 	state         protoimpl.MessageState
@@ -1305,7 +1600,7 @@ type GameMessage_ErrorMsg struct {
 
 func (x *GameMessage_ErrorMsg) Reset() {
 	*x = GameMessage_ErrorMsg{}
-	mi := &file_snakes_proto_msgTypes[15]
+	mi := &file_snakes_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1317,7 +1612,7 @@ func (x *GameMessage_ErrorMsg) String() string {
 func (*GameMessage_ErrorMsg) ProtoMessage() {}
 
 func (x *GameMessage_ErrorMsg) ProtoReflect() protoreflect.Message {
-	mi := &file_snakes_proto_msgTypes[15]
+	mi := &file_snakes_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1330,7 +1625,7 @@ func (x *GameMessage_ErrorMsg) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GameMessage_ErrorMsg.ProtoReflect.Descriptor instead.
 func (*GameMessage_ErrorMsg) Descriptor() ([]byte, []int) {
-	return file_snakes_proto_rawDescGZIP(), []int{5, 7}
+	return file_snakes_proto_rawDescGZIP(), []int{7, 7}
 }
 
 func (x *GameMessage_ErrorMsg) GetErrorMessage() string {
@@ -1357,7 +1652,7 @@ type GameMessage_RoleChangeMsg struct {
 
 func (x *GameMessage_RoleChangeMsg) Reset() {
 	*x = GameMessage_RoleChangeMsg{}
-	mi := &file_snakes_proto_msgTypes[16]
+	mi := &file_snakes_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1369,7 +1664,7 @@ func (x *GameMessage_RoleChangeMsg) String() string {
 func (*GameMessage_RoleChangeMsg) ProtoMessage() {}
 
 func (x *GameMessage_RoleChangeMsg) ProtoReflect() protoreflect.Message {
-	mi := &file_snakes_proto_msgTypes[16]
+	mi := &file_snakes_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1382,7 +1677,7 @@ func (x *GameMessage_RoleChangeMsg) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GameMessage_RoleChangeMsg.ProtoReflect.Descriptor instead.
 func (*GameMessage_RoleChangeMsg) Descriptor() ([]byte, []int) {
-	return file_snakes_proto_rawDescGZIP(), []int{5, 8}
+	return file_snakes_proto_rawDescGZIP(), []int{7, 8}
 }
 
 func (x *GameMessage_RoleChangeMsg) GetSenderRole() NodeRole {
@@ -1416,7 +1711,7 @@ var file_snakes_proto_rawDesc = []byte{
 	0x32, 0x12, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72,
 	0x54, 0x79, 0x70, 0x65, 0x3a, 0x05, 0x48, 0x55, 0x4d, 0x41, 0x4e, 0x52, 0x04, 0x74, 0x79, 0x70,
 	0x65, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x07, 0x20, 0x02, 0x28, 0x05,
-	0x52, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x22, 0x92, 0x01, 0x0a, 0x0a, 0x47, 0x61, 0x6d, 0x65,
+	0x52, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x22, 0xe6, 0x01, 0x0a, 0x0a, 0x47, 0x61, 0x6d, 0x65,
 	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x18, 0x0a, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x18,
 	0x01, 0x20, 0x01, 0x28, 0x05, 0x3a, 0x02, 0x34, 0x30, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68,
 	0x12, 0x1a, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05,
@@ -1425,138 +1720,193 @@ var file_snakes_proto_rawDesc = []byte{
 	0x05, 0x3a, 0x01, 0x31, 0x52, 0x0a, 0x66, 0x6f, 0x6f, 0x64, 0x53, 0x74, 0x61, 0x74, 0x69, 0x63,
 	0x12, 0x2a, 0x0a, 0x0e, 0x73, 0x74, 0x61, 0x74, 0x65, 0x5f, 0x64, 0x65, 0x6c, 0x61, 0x79, 0x5f,
 	0x6d, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x3a, 0x04, 0x31, 0x30, 0x30, 0x30, 0x52, 0x0c,
-	0x73, 0x74, 0x61, 0x74, 0x65, 0x44, 0x65, 0x6c, 0x61, 0x79, 0x4d, 0x73, 0x22, 0x3b, 0x0a, 0x0b,
-	0x47, 0x61, 0x6d, 0x65, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x12, 0x2c, 0x0a, 0x07, 0x70,
-	0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x73,
-	0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72,
-	0x52, 0x07, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x22, 0xde, 0x03, 0x0a, 0x09, 0x47, 0x61,
-	0x6d, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x74, 0x61, 0x74, 0x65,
-	0x5f, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x02, 0x28, 0x05, 0x52, 0x0a, 0x73, 0x74,
-	0x61, 0x74, 0x65, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x2f, 0x0a, 0x06, 0x73, 0x6e, 0x61, 0x6b,
-	0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65,
-	0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x2e, 0x53, 0x6e, 0x61, 0x6b,
-	0x65, 0x52, 0x06, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x12, 0x2d, 0x0a, 0x05, 0x66, 0x6f, 0x6f,
-	0x64, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65,
-	0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x2e, 0x43, 0x6f, 0x6f, 0x72,
-	0x64, 0x52, 0x05, 0x66, 0x6f, 0x6f, 0x64, 0x73, 0x12, 0x2d, 0x0a, 0x07, 0x70, 0x6c, 0x61, 0x79,
-	0x65, 0x72, 0x73, 0x18, 0x04, 0x20, 0x02, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x73, 0x6e, 0x61, 0x6b,
-	0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x52, 0x07,
-	0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x1a, 0x29, 0x0a, 0x05, 0x43, 0x6f, 0x6f, 0x72, 0x64,
-	0x12, 0x0f, 0x0a, 0x01, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x11, 0x3a, 0x01, 0x30, 0x52, 0x01,
-	0x78, 0x12, 0x0f, 0x0a, 0x01, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x11, 0x3a, 0x01, 0x30, 0x52,
-	0x01, 0x79, 0x1a, 0xf5, 0x01, 0x0a, 0x05, 0x53, 0x6e, 0x61, 0x6b, 0x65, 0x12, 0x1b, 0x0a, 0x09,
-	0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x02, 0x28, 0x05, 0x52,
-	0x08, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x12, 0x2f, 0x0a, 0x06, 0x70, 0x6f, 0x69,
-	0x6e, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73, 0x6e, 0x61, 0x6b,
-	0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x2e, 0x43, 0x6f, 0x6f,
-	0x72, 0x64, 0x52, 0x06, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x3f, 0x0a, 0x05, 0x73, 0x74,
-	0x61, 0x74, 0x65, 0x18, 0x03, 0x20, 0x02, 0x28, 0x0e, 0x32, 0x22, 0x2e, 0x73, 0x6e, 0x61, 0x6b,
-	0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x2e, 0x53, 0x6e, 0x61,
-	0x6b, 0x65, 0x2e, 0x53, 0x6e, 0x61, 0x6b, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x3a, 0x05, 0x41,
-	0x4c, 0x49, 0x56, 0x45, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x12, 0x38, 0x0a, 0x0e, 0x68,
-	0x65, 0x61, 0x64, 0x5f, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20,
-	0x02, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x44, 0x69, 0x72,
-	0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0d, 0x68, 0x65, 0x61, 0x64, 0x44, 0x69, 0x72, 0x65,
-	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x23, 0x0a, 0x0a, 0x53, 0x6e, 0x61, 0x6b, 0x65, 0x53, 0x74,
-	0x61, 0x74, 0x65, 0x12, 0x09, 0x0a, 0x05, 0x41, 0x4c, 0x49, 0x56, 0x45, 0x10, 0x00, 0x12, 0x0a,
-	0x0a, 0x06, 0x5a, 0x4f, 0x4d, 0x42, 0x49, 0x45, 0x10, 0x01, 0x22, 0xab, 0x01, 0x0a, 0x10, 0x47,
-	0x61, 0x6d, 0x65, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12,
-	0x2d, 0x0a, 0x07, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x02, 0x28, 0x0b,
-	0x32, 0x13, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x50, 0x6c,
-	0x61, 0x79, 0x65, 0x72, 0x73, 0x52, 0x07, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x12, 0x2a,
-	0x0a, 0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x18, 0x02, 0x20, 0x02, 0x28, 0x0b, 0x32, 0x12,
-	0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x43, 0x6f, 0x6e, 0x66,
-	0x69, 0x67, 0x52, 0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x1f, 0x0a, 0x08, 0x63, 0x61,
-	0x6e, 0x5f, 0x6a, 0x6f, 0x69, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x3a, 0x04, 0x74, 0x72,
-	0x75, 0x65, 0x52, 0x07, 0x63, 0x61, 0x6e, 0x4a, 0x6f, 0x69, 0x6e, 0x12, 0x1b, 0x0a, 0x09, 0x67,
-	0x61, 0x6d, 0x65, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x02, 0x28, 0x09, 0x52, 0x08,
-	0x67, 0x61, 0x6d, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0xb8, 0x09, 0x0a, 0x0b, 0x47, 0x61, 0x6d,
-	0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x6d, 0x73, 0x67, 0x5f,
-	0x73, 0x65, 0x71, 0x18, 0x01, 0x20, 0x02, 0x28, 0x03, 0x52, 0x06, 0x6d, 0x73, 0x67, 0x53, 0x65,
-	0x71, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x0a,
-	0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x49, 0x64, 0x12, 0x1f,
-	0x0a, 0x0b, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x0b, 0x20,
-	0x01, 0x28, 0x05, 0x52, 0x0a, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x49, 0x64, 0x12,
-	0x31, 0x0a, 0x04, 0x70, 0x69, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e,
+	0x73, 0x74, 0x61, 0x74, 0x65, 0x44, 0x65, 0x6c, 0x61, 0x79, 0x4d, 0x73, 0x12, 0x26, 0x0a, 0x0b,
+	0x73, 0x6f, 0x6c, 0x69, 0x64, 0x5f, 0x77, 0x61, 0x6c, 0x6c, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x08, 0x3a, 0x05, 0x66, 0x61, 0x6c, 0x73, 0x65, 0x52, 0x0a, 0x73, 0x6f, 0x6c, 0x69, 0x64, 0x57,
+	0x61, 0x6c, 0x6c, 0x73, 0x12, 0x2a, 0x0a, 0x08, 0x67, 0x61, 0x6d, 0x65, 0x5f, 0x6d, 0x61, 0x70,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e,
+	0x47, 0x61, 0x6d, 0x65, 0x4d, 0x61, 0x70, 0x52, 0x07, 0x67, 0x61, 0x6d, 0x65, 0x4d, 0x61, 0x70,
+	0x22, 0x86, 0x01, 0x0a, 0x07, 0x47, 0x61, 0x6d, 0x65, 0x4d, 0x61, 0x70, 0x12, 0x12, 0x0a, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x12, 0x1c, 0x0a, 0x09, 0x6f, 0x62, 0x73, 0x74, 0x61, 0x63, 0x6c, 0x65, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x09, 0x6f, 0x62, 0x73, 0x74, 0x61, 0x63, 0x6c, 0x65, 0x73, 0x12, 0x1f,
+	0x0a, 0x0b, 0x73, 0x70, 0x61, 0x77, 0x6e, 0x5f, 0x7a, 0x6f, 0x6e, 0x65, 0x73, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x0a, 0x73, 0x70, 0x61, 0x77, 0x6e, 0x5a, 0x6f, 0x6e, 0x65, 0x73, 0x12,
+	0x28, 0x0a, 0x07, 0x70, 0x6f, 0x72, 0x74, 0x61, 0x6c, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x0e, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x50, 0x6f, 0x72, 0x74, 0x61, 0x6c,
+	0x52, 0x07, 0x70, 0x6f, 0x72, 0x74, 0x61, 0x6c, 0x73, 0x22, 0x56, 0x0a, 0x06, 0x50, 0x6f, 0x72,
+	0x74, 0x61, 0x6c, 0x12, 0x25, 0x0a, 0x01, 0x61, 0x18, 0x01, 0x20, 0x02, 0x28, 0x0b, 0x32, 0x17,
+	0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x53, 0x74, 0x61, 0x74,
+	0x65, 0x2e, 0x43, 0x6f, 0x6f, 0x72, 0x64, 0x52, 0x01, 0x61, 0x12, 0x25, 0x0a, 0x01, 0x62, 0x18,
+	0x02, 0x20, 0x02, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47,
+	0x61, 0x6d, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x2e, 0x43, 0x6f, 0x6f, 0x72, 0x64, 0x52, 0x01,
+	0x62, 0x22, 0x3b, 0x0a, 0x0b, 0x47, 0x61, 0x6d, 0x65, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73,
+	0x12, 0x2c, 0x0a, 0x07, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x12, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x50,
+	0x6c, 0x61, 0x79, 0x65, 0x72, 0x52, 0x07, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x22, 0xde,
+	0x03, 0x0a, 0x09, 0x47, 0x61, 0x6d, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x1f, 0x0a, 0x0b,
+	0x73, 0x74, 0x61, 0x74, 0x65, 0x5f, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x02, 0x28,
+	0x05, 0x52, 0x0a, 0x73, 0x74, 0x61, 0x74, 0x65, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x2f, 0x0a,
+	0x06, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e,
+	0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65,
+	0x2e, 0x53, 0x6e, 0x61, 0x6b, 0x65, 0x52, 0x06, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x12, 0x2d,
+	0x0a, 0x05, 0x66, 0x6f, 0x6f, 0x64, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e,
+	0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65,
+	0x2e, 0x43, 0x6f, 0x6f, 0x72, 0x64, 0x52, 0x05, 0x66, 0x6f, 0x6f, 0x64, 0x73, 0x12, 0x2d, 0x0a,
+	0x07, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x18, 0x04, 0x20, 0x02, 0x28, 0x0b, 0x32, 0x13,
+	0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x50, 0x6c, 0x61, 0x79,
+	0x65, 0x72, 0x73, 0x52, 0x07, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x1a, 0x29, 0x0a, 0x05,
+	0x43, 0x6f, 0x6f, 0x72, 0x64, 0x12, 0x0f, 0x0a, 0x01, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x11,
+	0x3a, 0x01, 0x30, 0x52, 0x01, 0x78, 0x12, 0x0f, 0x0a, 0x01, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x11, 0x3a, 0x01, 0x30, 0x52, 0x01, 0x79, 0x1a, 0xf5, 0x01, 0x0a, 0x05, 0x53, 0x6e, 0x61, 0x6b,
+	0x65, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x02, 0x28, 0x05, 0x52, 0x08, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x12, 0x2f,
+	0x0a, 0x06, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17,
+	0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x53, 0x74, 0x61, 0x74,
+	0x65, 0x2e, 0x43, 0x6f, 0x6f, 0x72, 0x64, 0x52, 0x06, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12,
+	0x3f, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x03, 0x20, 0x02, 0x28, 0x0e, 0x32, 0x22,
+	0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x53, 0x74, 0x61, 0x74,
+	0x65, 0x2e, 0x53, 0x6e, 0x61, 0x6b, 0x65, 0x2e, 0x53, 0x6e, 0x61, 0x6b, 0x65, 0x53, 0x74, 0x61,
+	0x74, 0x65, 0x3a, 0x05, 0x41, 0x4c, 0x49, 0x56, 0x45, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65,
+	0x12, 0x38, 0x0a, 0x0e, 0x68, 0x65, 0x61, 0x64, 0x5f, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x18, 0x04, 0x20, 0x02, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65,
+	0x73, 0x2e, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0d, 0x68, 0x65, 0x61,
+	0x64, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x23, 0x0a, 0x0a, 0x53, 0x6e,
+	0x61, 0x6b, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x09, 0x0a, 0x05, 0x41, 0x4c, 0x49, 0x56,
+	0x45, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x5a, 0x4f, 0x4d, 0x42, 0x49, 0x45, 0x10, 0x01, 0x22,
+	0xd7, 0x02, 0x0a, 0x10, 0x47, 0x61, 0x6d, 0x65, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65,
+	0x6d, 0x65, 0x6e, 0x74, 0x12, 0x2d, 0x0a, 0x07, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x18,
+	0x01, 0x20, 0x02, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47,
+	0x61, 0x6d, 0x65, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x52, 0x07, 0x70, 0x6c, 0x61, 0x79,
+	0x65, 0x72, 0x73, 0x12, 0x2a, 0x0a, 0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x18, 0x02, 0x20,
+	0x02, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d,
+	0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12,
+	0x1f, 0x0a, 0x08, 0x63, 0x61, 0x6e, 0x5f, 0x6a, 0x6f, 0x69, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x08, 0x3a, 0x04, 0x74, 0x72, 0x75, 0x65, 0x52, 0x07, 0x63, 0x61, 0x6e, 0x4a, 0x6f, 0x69, 0x6e,
+	0x12, 0x1b, 0x0a, 0x09, 0x67, 0x61, 0x6d, 0x65, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20,
+	0x02, 0x28, 0x09, 0x52, 0x08, 0x67, 0x61, 0x6d, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x29, 0x0a,
+	0x10, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
+	0x6c, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x36, 0x0a, 0x0c, 0x63, 0x61, 0x70, 0x61,
+	0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0e, 0x32, 0x12,
+	0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x43, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69,
+	0x74, 0x79, 0x52, 0x0c, 0x63, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73,
+	0x12, 0x47, 0x0a, 0x15, 0x72, 0x65, 0x71, 0x75, 0x69, 0x72, 0x65, 0x64, 0x5f, 0x63, 0x61, 0x70,
+	0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0e, 0x32,
+	0x12, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x43, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c,
+	0x69, 0x74, 0x79, 0x52, 0x14, 0x72, 0x65, 0x71, 0x75, 0x69, 0x72, 0x65, 0x64, 0x43, 0x61, 0x70,
+	0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x22, 0xea, 0x0b, 0x0a, 0x0b, 0x47, 0x61,
+	0x6d, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x6d, 0x73, 0x67,
+	0x5f, 0x73, 0x65, 0x71, 0x18, 0x01, 0x20, 0x02, 0x28, 0x03, 0x52, 0x06, 0x6d, 0x73, 0x67, 0x53,
+	0x65, 0x71, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18,
+	0x0a, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x49, 0x64, 0x12,
+	0x1f, 0x0a, 0x0b, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x0b,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x49, 0x64,
+	0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x61, 0x67, 0x18,
+	0x0d, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x54, 0x61,
+	0x67, 0x12, 0x31, 0x0a, 0x04, 0x70, 0x69, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1b, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x2e, 0x50, 0x69, 0x6e, 0x67, 0x4d, 0x73, 0x67, 0x48, 0x00, 0x52, 0x04,
+	0x70, 0x69, 0x6e, 0x67, 0x12, 0x34, 0x0a, 0x05, 0x73, 0x74, 0x65, 0x65, 0x72, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d,
+	0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x53, 0x74, 0x65, 0x65, 0x72, 0x4d, 0x73,
+	0x67, 0x48, 0x00, 0x52, 0x05, 0x73, 0x74, 0x65, 0x65, 0x72, 0x12, 0x2e, 0x0a, 0x03, 0x61, 0x63,
+	0x6b, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73,
+	0x2e, 0x47, 0x61, 0x6d, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x41, 0x63, 0x6b,
+	0x4d, 0x73, 0x67, 0x48, 0x00, 0x52, 0x03, 0x61, 0x63, 0x6b, 0x12, 0x34, 0x0a, 0x05, 0x73, 0x74,
+	0x61, 0x74, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x73, 0x6e, 0x61, 0x6b,
+	0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x53,
+	0x74, 0x61, 0x74, 0x65, 0x4d, 0x73, 0x67, 0x48, 0x00, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65,
+	0x12, 0x49, 0x0a, 0x0c, 0x61, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e,
+	0x47, 0x61, 0x6d, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x41, 0x6e, 0x6e, 0x6f,
+	0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x4d, 0x73, 0x67, 0x48, 0x00, 0x52, 0x0c, 0x61,
+	0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x31, 0x0a, 0x04, 0x6a,
+	0x6f, 0x69, 0x6e, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x73, 0x6e, 0x61, 0x6b,
+	0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x4a,
+	0x6f, 0x69, 0x6e, 0x4d, 0x73, 0x67, 0x48, 0x00, 0x52, 0x04, 0x6a, 0x6f, 0x69, 0x6e, 0x12, 0x34,
+	0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e,
 	0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61,
-	0x67, 0x65, 0x2e, 0x50, 0x69, 0x6e, 0x67, 0x4d, 0x73, 0x67, 0x48, 0x00, 0x52, 0x04, 0x70, 0x69,
-	0x6e, 0x67, 0x12, 0x34, 0x0a, 0x05, 0x73, 0x74, 0x65, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x1c, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x4d,
-	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x53, 0x74, 0x65, 0x65, 0x72, 0x4d, 0x73, 0x67, 0x48,
-	0x00, 0x52, 0x05, 0x73, 0x74, 0x65, 0x65, 0x72, 0x12, 0x2e, 0x0a, 0x03, 0x61, 0x63, 0x6b, 0x18,
-	0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47,
-	0x61, 0x6d, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x41, 0x63, 0x6b, 0x4d, 0x73,
-	0x67, 0x48, 0x00, 0x52, 0x03, 0x61, 0x63, 0x6b, 0x12, 0x34, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74,
-	0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73,
-	0x2e, 0x47, 0x61, 0x6d, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x53, 0x74, 0x61,
-	0x74, 0x65, 0x4d, 0x73, 0x67, 0x48, 0x00, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x12, 0x49,
-	0x0a, 0x0c, 0x61, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x06,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61,
-	0x6d, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e,
-	0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x4d, 0x73, 0x67, 0x48, 0x00, 0x52, 0x0c, 0x61, 0x6e, 0x6e,
-	0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x31, 0x0a, 0x04, 0x6a, 0x6f, 0x69,
-	0x6e, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73,
-	0x2e, 0x47, 0x61, 0x6d, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x4a, 0x6f, 0x69,
-	0x6e, 0x4d, 0x73, 0x67, 0x48, 0x00, 0x52, 0x04, 0x6a, 0x6f, 0x69, 0x6e, 0x12, 0x34, 0x0a, 0x05,
-	0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x73, 0x6e,
-	0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
-	0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x4d, 0x73, 0x67, 0x48, 0x00, 0x52, 0x05, 0x65, 0x72, 0x72,
-	0x6f, 0x72, 0x12, 0x44, 0x0a, 0x0b, 0x72, 0x6f, 0x6c, 0x65, 0x5f, 0x63, 0x68, 0x61, 0x6e, 0x67,
-	0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73,
-	0x2e, 0x47, 0x61, 0x6d, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x52, 0x6f, 0x6c,
-	0x65, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x4d, 0x73, 0x67, 0x48, 0x00, 0x52, 0x0a, 0x72, 0x6f,
-	0x6c, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x3d, 0x0a, 0x08, 0x64, 0x69, 0x73, 0x63,
-	0x6f, 0x76, 0x65, 0x72, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x73, 0x6e, 0x61,
-	0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e,
-	0x44, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x4d, 0x73, 0x67, 0x48, 0x00, 0x52, 0x08, 0x64,
-	0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x1a, 0x09, 0x0a, 0x07, 0x50, 0x69, 0x6e, 0x67, 0x4d,
-	0x73, 0x67, 0x1a, 0x3b, 0x0a, 0x08, 0x53, 0x74, 0x65, 0x65, 0x72, 0x4d, 0x73, 0x67, 0x12, 0x2f,
-	0x0a, 0x09, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x02, 0x28,
-	0x0e, 0x32, 0x11, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x44, 0x69, 0x72, 0x65, 0x63,
-	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x09, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x1a,
-	0x08, 0x0a, 0x06, 0x41, 0x63, 0x6b, 0x4d, 0x73, 0x67, 0x1a, 0x33, 0x0a, 0x08, 0x53, 0x74, 0x61,
-	0x74, 0x65, 0x4d, 0x73, 0x67, 0x12, 0x27, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x01,
-	0x20, 0x02, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61,
-	0x6d, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x1a, 0x41,
-	0x0a, 0x0f, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x4d, 0x73,
-	0x67, 0x12, 0x2e, 0x0a, 0x05, 0x67, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
-	0x32, 0x18, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x41, 0x6e,
-	0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x05, 0x67, 0x61, 0x6d, 0x65,
-	0x73, 0x1a, 0x0d, 0x0a, 0x0b, 0x44, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x4d, 0x73, 0x67,
-	0x1a, 0xbc, 0x01, 0x0a, 0x07, 0x4a, 0x6f, 0x69, 0x6e, 0x4d, 0x73, 0x67, 0x12, 0x3a, 0x0a, 0x0b,
-	0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x0e, 0x32, 0x12, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x50, 0x6c, 0x61, 0x79, 0x65,
-	0x72, 0x54, 0x79, 0x70, 0x65, 0x3a, 0x05, 0x48, 0x55, 0x4d, 0x41, 0x4e, 0x52, 0x0a, 0x70, 0x6c,
-	0x61, 0x79, 0x65, 0x72, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x70, 0x6c, 0x61, 0x79,
-	0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x02, 0x28, 0x09, 0x52, 0x0a, 0x70,
-	0x6c, 0x61, 0x79, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x67, 0x61, 0x6d,
-	0x65, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x02, 0x28, 0x09, 0x52, 0x08, 0x67, 0x61,
-	0x6d, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x37, 0x0a, 0x0e, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x65, 0x64, 0x5f, 0x72, 0x6f, 0x6c, 0x65, 0x18, 0x05, 0x20, 0x02, 0x28, 0x0e, 0x32, 0x10,
+	0x67, 0x65, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x4d, 0x73, 0x67, 0x48, 0x00, 0x52, 0x05, 0x65,
+	0x72, 0x72, 0x6f, 0x72, 0x12, 0x44, 0x0a, 0x0b, 0x72, 0x6f, 0x6c, 0x65, 0x5f, 0x63, 0x68, 0x61,
+	0x6e, 0x67, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x73, 0x6e, 0x61, 0x6b,
+	0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x52,
+	0x6f, 0x6c, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x4d, 0x73, 0x67, 0x48, 0x00, 0x52, 0x0a,
+	0x72, 0x6f, 0x6c, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x3d, 0x0a, 0x08, 0x64, 0x69,
+	0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x73,
+	0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x2e, 0x44, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x4d, 0x73, 0x67, 0x48, 0x00, 0x52,
+	0x08, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x1a, 0x09, 0x0a, 0x07, 0x50, 0x69, 0x6e,
+	0x67, 0x4d, 0x73, 0x67, 0x1a, 0x3b, 0x0a, 0x08, 0x53, 0x74, 0x65, 0x65, 0x72, 0x4d, 0x73, 0x67,
+	0x12, 0x2f, 0x0a, 0x09, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20,
+	0x02, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x44, 0x69, 0x72,
+	0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x09, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x1a, 0x90, 0x01, 0x0a, 0x06, 0x41, 0x63, 0x6b, 0x4d, 0x73, 0x67, 0x12, 0x29, 0x0a, 0x10,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x36, 0x0a, 0x0c, 0x63, 0x61, 0x70, 0x61, 0x62,
+	0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0e, 0x32, 0x12, 0x2e,
+	0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x43, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74,
+	0x79, 0x52, 0x0c, 0x63, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x12,
+	0x23, 0x0a, 0x0d, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x54,
+	0x6f, 0x6b, 0x65, 0x6e, 0x1a, 0x33, 0x0a, 0x08, 0x53, 0x74, 0x61, 0x74, 0x65, 0x4d, 0x73, 0x67,
+	0x12, 0x27, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x02, 0x28, 0x0b, 0x32,
+	0x11, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x53, 0x74, 0x61,
+	0x74, 0x65, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x1a, 0x41, 0x0a, 0x0f, 0x41, 0x6e, 0x6e,
+	0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x4d, 0x73, 0x67, 0x12, 0x2e, 0x0a, 0x05,
+	0x67, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x73, 0x6e,
+	0x61, 0x6b, 0x65, 0x73, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63,
+	0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x05, 0x67, 0x61, 0x6d, 0x65, 0x73, 0x1a, 0x0d, 0x0a, 0x0b,
+	0x44, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x4d, 0x73, 0x67, 0x1a, 0xc4, 0x02, 0x0a, 0x07,
+	0x4a, 0x6f, 0x69, 0x6e, 0x4d, 0x73, 0x67, 0x12, 0x3a, 0x0a, 0x0b, 0x70, 0x6c, 0x61, 0x79, 0x65,
+	0x72, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x12, 0x2e, 0x73,
+	0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x54, 0x79, 0x70, 0x65,
+	0x3a, 0x05, 0x48, 0x55, 0x4d, 0x41, 0x4e, 0x52, 0x0a, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x54,
+	0x79, 0x70, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x5f, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x03, 0x20, 0x02, 0x28, 0x09, 0x52, 0x0a, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72,
+	0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x67, 0x61, 0x6d, 0x65, 0x5f, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x04, 0x20, 0x02, 0x28, 0x09, 0x52, 0x08, 0x67, 0x61, 0x6d, 0x65, 0x4e, 0x61, 0x6d,
+	0x65, 0x12, 0x37, 0x0a, 0x0e, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x65, 0x64, 0x5f, 0x72,
+	0x6f, 0x6c, 0x65, 0x18, 0x05, 0x20, 0x02, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x73, 0x6e, 0x61, 0x6b,
+	0x65, 0x73, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x6f, 0x6c, 0x65, 0x52, 0x0d, 0x72, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x65, 0x64, 0x52, 0x6f, 0x6c, 0x65, 0x12, 0x29, 0x0a, 0x10, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x0f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x56, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x36, 0x0a, 0x0c, 0x63, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c,
+	0x69, 0x74, 0x69, 0x65, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0e, 0x32, 0x12, 0x2e, 0x73, 0x6e,
+	0x61, 0x6b, 0x65, 0x73, 0x2e, 0x43, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x52,
+	0x0c, 0x63, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x12, 0x23, 0x0a,
+	0x0d, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x54, 0x6f, 0x6b,
+	0x65, 0x6e, 0x1a, 0x2f, 0x0a, 0x08, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x4d, 0x73, 0x67, 0x12, 0x23,
+	0x0a, 0x0d, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18,
+	0x01, 0x20, 0x02, 0x28, 0x09, 0x52, 0x0c, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x4d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x1a, 0x79, 0x0a, 0x0d, 0x52, 0x6f, 0x6c, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x67,
+	0x65, 0x4d, 0x73, 0x67, 0x12, 0x31, 0x0a, 0x0b, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x5f, 0x72,
+	0x6f, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x73, 0x6e, 0x61, 0x6b,
+	0x65, 0x73, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x6f, 0x6c, 0x65, 0x52, 0x0a, 0x73, 0x65, 0x6e,
+	0x64, 0x65, 0x72, 0x52, 0x6f, 0x6c, 0x65, 0x12, 0x35, 0x0a, 0x0d, 0x72, 0x65, 0x63, 0x65, 0x69,
+	0x76, 0x65, 0x72, 0x5f, 0x72, 0x6f, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10,
 	0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x6f, 0x6c, 0x65,
-	0x52, 0x0d, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x65, 0x64, 0x52, 0x6f, 0x6c, 0x65, 0x1a,
-	0x2f, 0x0a, 0x08, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x4d, 0x73, 0x67, 0x12, 0x23, 0x0a, 0x0d, 0x65,
-	0x72, 0x72, 0x6f, 0x72, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x02,
-	0x28, 0x09, 0x52, 0x0c, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
-	0x1a, 0x79, 0x0a, 0x0d, 0x52, 0x6f, 0x6c, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x4d, 0x73,
-	0x67, 0x12, 0x31, 0x0a, 0x0b, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x5f, 0x72, 0x6f, 0x6c, 0x65,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x73, 0x6e, 0x61, 0x6b, 0x65, 0x73, 0x2e,
-	0x4e, 0x6f, 0x64, 0x65, 0x52, 0x6f, 0x6c, 0x65, 0x52, 0x0a, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72,
-	0x52, 0x6f, 0x6c, 0x65, 0x12, 0x35, 0x0a, 0x0d, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72,
-	0x5f, 0x72, 0x6f, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x73, 0x6e,
-	0x61, 0x6b, 0x65, 0x73, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x6f, 0x6c, 0x65, 0x52, 0x0c, 0x72,
-	0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x52, 0x6f, 0x6c, 0x65, 0x42, 0x06, 0x0a, 0x04, 0x54,
-	0x79, 0x70, 0x65, 0x2a, 0x3a, 0x0a, 0x08, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x6f, 0x6c, 0x65, 0x12,
-	0x0a, 0x0a, 0x06, 0x4e, 0x4f, 0x52, 0x4d, 0x41, 0x4c, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x4d,
-	0x41, 0x53, 0x54, 0x45, 0x52, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x44, 0x45, 0x50, 0x55, 0x54,
-	0x59, 0x10, 0x02, 0x12, 0x0a, 0x0a, 0x06, 0x56, 0x49, 0x45, 0x57, 0x45, 0x52, 0x10, 0x03, 0x2a,
-	0x22, 0x0a, 0x0a, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x54, 0x79, 0x70, 0x65, 0x12, 0x09, 0x0a,
-	0x05, 0x48, 0x55, 0x4d, 0x41, 0x4e, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05, 0x52, 0x4f, 0x42, 0x4f,
-	0x54, 0x10, 0x01, 0x2a, 0x32, 0x0a, 0x09, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e,
-	0x12, 0x06, 0x0a, 0x02, 0x55, 0x50, 0x10, 0x01, 0x12, 0x08, 0x0a, 0x04, 0x44, 0x4f, 0x57, 0x4e,
-	0x10, 0x02, 0x12, 0x08, 0x0a, 0x04, 0x4c, 0x45, 0x46, 0x54, 0x10, 0x03, 0x12, 0x09, 0x0a, 0x05,
-	0x52, 0x49, 0x47, 0x48, 0x54, 0x10, 0x04, 0x42, 0x09, 0x5a, 0x07, 0x2e, 0x2f, 0x6d, 0x6f, 0x64,
-	0x65, 0x6c,
+	0x52, 0x0c, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x52, 0x6f, 0x6c, 0x65, 0x42, 0x06,
+	0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x2a, 0x3a, 0x0a, 0x08, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x6f,
+	0x6c, 0x65, 0x12, 0x0a, 0x0a, 0x06, 0x4e, 0x4f, 0x52, 0x4d, 0x41, 0x4c, 0x10, 0x00, 0x12, 0x0a,
+	0x0a, 0x06, 0x4d, 0x41, 0x53, 0x54, 0x45, 0x52, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x44, 0x45,
+	0x50, 0x55, 0x54, 0x59, 0x10, 0x02, 0x12, 0x0a, 0x0a, 0x06, 0x56, 0x49, 0x45, 0x57, 0x45, 0x52,
+	0x10, 0x03, 0x2a, 0x22, 0x0a, 0x0a, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x54, 0x79, 0x70, 0x65,
+	0x12, 0x09, 0x0a, 0x05, 0x48, 0x55, 0x4d, 0x41, 0x4e, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05, 0x52,
+	0x4f, 0x42, 0x4f, 0x54, 0x10, 0x01, 0x2a, 0x5f, 0x0a, 0x0a, 0x43, 0x61, 0x70, 0x61, 0x62, 0x69,
+	0x6c, 0x69, 0x74, 0x79, 0x12, 0x16, 0x0a, 0x12, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x5f,
+	0x43, 0x41, 0x50, 0x41, 0x42, 0x49, 0x4c, 0x49, 0x54, 0x59, 0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08,
+	0x53, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x53, 0x10, 0x01, 0x12, 0x0f, 0x0a, 0x0b, 0x53, 0x4f,
+	0x4c, 0x49, 0x44, 0x5f, 0x57, 0x41, 0x4c, 0x4c, 0x53, 0x10, 0x02, 0x12, 0x0d, 0x0a, 0x09, 0x4f,
+	0x42, 0x53, 0x54, 0x41, 0x43, 0x4c, 0x45, 0x53, 0x10, 0x03, 0x12, 0x0b, 0x0a, 0x07, 0x50, 0x4f,
+	0x52, 0x54, 0x41, 0x4c, 0x53, 0x10, 0x04, 0x2a, 0x32, 0x0a, 0x09, 0x44, 0x69, 0x72, 0x65, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x06, 0x0a, 0x02, 0x55, 0x50, 0x10, 0x01, 0x12, 0x08, 0x0a, 0x04,
+	0x44, 0x4f, 0x57, 0x4e, 0x10, 0x02, 0x12, 0x08, 0x0a, 0x04, 0x4c, 0x45, 0x46, 0x54, 0x10, 0x03,
+	0x12, 0x09, 0x0a, 0x05, 0x52, 0x49, 0x47, 0x48, 0x54, 0x10, 0x04, 0x42, 0x09, 0x5a, 0x07, 0x2e,
+	0x2f, 0x6d, 0x6f, 0x64, 0x65, 0x6c,
 }
 
 var (
@@ -1571,64 +1921,75 @@ func file_snakes_proto_rawDescGZIP() []byte {
 	return file_snakes_proto_rawDescData
 }
 
-var file_snakes_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
-var file_snakes_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_snakes_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
+var file_snakes_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
 var file_snakes_proto_goTypes = []any{
 	(NodeRole)(0),                       // 0: snakes.NodeRole
 	(PlayerType)(0),                     // 1: snakes.PlayerType
-	(Direction)(0),                      // 2: snakes.Direction
-	(GameState_Snake_SnakeState)(0),     // 3: snakes.GameState.Snake.SnakeState
-	(*GamePlayer)(nil),                  // 4: snakes.GamePlayer
-	(*GameConfig)(nil),                  // 5: snakes.GameConfig
-	(*GamePlayers)(nil),                 // 6: snakes.GamePlayers
-	(*GameState)(nil),                   // 7: snakes.GameState
-	(*GameAnnouncement)(nil),            // 8: snakes.GameAnnouncement
-	(*GameMessage)(nil),                 // 9: snakes.GameMessage
-	(*GameState_Coord)(nil),             // 10: snakes.GameState.Coord
-	(*GameState_Snake)(nil),             // 11: snakes.GameState.Snake
-	(*GameMessage_PingMsg)(nil),         // 12: snakes.GameMessage.PingMsg
-	(*GameMessage_SteerMsg)(nil),        // 13: snakes.GameMessage.SteerMsg
-	(*GameMessage_AckMsg)(nil),          // 14: snakes.GameMessage.AckMsg
-	(*GameMessage_StateMsg)(nil),        // 15: snakes.GameMessage.StateMsg
-	(*GameMessage_AnnouncementMsg)(nil), // 16: snakes.GameMessage.AnnouncementMsg
-	(*GameMessage_DiscoverMsg)(nil),     // 17: snakes.GameMessage.DiscoverMsg
-	(*GameMessage_JoinMsg)(nil),         // 18: snakes.GameMessage.JoinMsg
-	(*GameMessage_ErrorMsg)(nil),        // 19: snakes.GameMessage.ErrorMsg
-	(*GameMessage_RoleChangeMsg)(nil),   // 20: snakes.GameMessage.RoleChangeMsg
+	(Capability)(0),                     // 2: snakes.Capability
+	(Direction)(0),                      // 3: snakes.Direction
+	(GameState_Snake_SnakeState)(0),     // 4: snakes.GameState.Snake.SnakeState
+	(*GamePlayer)(nil),                  // 5: snakes.GamePlayer
+	(*GameConfig)(nil),                  // 6: snakes.GameConfig
+	(*GameMap)(nil),                     // 7: snakes.GameMap
+	(*Portal)(nil),                      // 8: snakes.Portal
+	(*GamePlayers)(nil),                 // 9: snakes.GamePlayers
+	(*GameState)(nil),                   // 10: snakes.GameState
+	(*GameAnnouncement)(nil),            // 11: snakes.GameAnnouncement
+	(*GameMessage)(nil),                 // 12: snakes.GameMessage
+	(*GameState_Coord)(nil),             // 13: snakes.GameState.Coord
+	(*GameState_Snake)(nil),             // 14: snakes.GameState.Snake
+	(*GameMessage_PingMsg)(nil),         // 15: snakes.GameMessage.PingMsg
+	(*GameMessage_SteerMsg)(nil),        // 16: snakes.GameMessage.SteerMsg
+	(*GameMessage_AckMsg)(nil),          // 17: snakes.GameMessage.AckMsg
+	(*GameMessage_StateMsg)(nil),        // 18: snakes.GameMessage.StateMsg
+	(*GameMessage_AnnouncementMsg)(nil), // 19: snakes.GameMessage.AnnouncementMsg
+	(*GameMessage_DiscoverMsg)(nil),     // 20: snakes.GameMessage.DiscoverMsg
+	(*GameMessage_JoinMsg)(nil),         // 21: snakes.GameMessage.JoinMsg
+	(*GameMessage_ErrorMsg)(nil),        // 22: snakes.GameMessage.ErrorMsg
+	(*GameMessage_RoleChangeMsg)(nil),   // 23: snakes.GameMessage.RoleChangeMsg
 }
 var file_snakes_proto_depIdxs = []int32{
 	0,  // 0: snakes.GamePlayer.role:type_name -> snakes.NodeRole
 	1,  // 1: snakes.GamePlayer.type:type_name -> snakes.PlayerType
-	4,  // 2: snakes.GamePlayers.players:type_name -> snakes.GamePlayer
-	11, // 3: snakes.GameState.snakes:type_name -> snakes.GameState.Snake
-	10, // 4: snakes.GameState.foods:type_name -> snakes.GameState.Coord
-	6,  // 5: snakes.GameState.players:type_name -> snakes.GamePlayers
-	6,  // 6: snakes.GameAnnouncement.players:type_name -> snakes.GamePlayers
-	5,  // 7: snakes.GameAnnouncement.config:type_name -> snakes.GameConfig
-	12, // 8: snakes.GameMessage.ping:type_name -> snakes.GameMessage.PingMsg
-	13, // 9: snakes.GameMessage.steer:type_name -> snakes.GameMessage.SteerMsg
-	14, // 10: snakes.GameMessage.ack:type_name -> snakes.GameMessage.AckMsg
-	15, // 11: snakes.GameMessage.state:type_name -> snakes.GameMessage.StateMsg
-	16, // 12: snakes.GameMessage.announcement:type_name -> snakes.GameMessage.AnnouncementMsg
-	18, // 13: snakes.GameMessage.join:type_name -> snakes.GameMessage.JoinMsg
-	19, // 14: snakes.GameMessage.error:type_name -> snakes.GameMessage.ErrorMsg
-	20, // 15: snakes.GameMessage.role_change:type_name -> snakes.GameMessage.RoleChangeMsg
-	17, // 16: snakes.GameMessage.discover:type_name -> snakes.GameMessage.DiscoverMsg
-	10, // 17: snakes.GameState.Snake.points:type_name -> snakes.GameState.Coord
-	3,  // 18: snakes.GameState.Snake.state:type_name -> snakes.GameState.Snake.SnakeState
-	2,  // 19: snakes.GameState.Snake.head_direction:type_name -> snakes.Direction
-	2,  // 20: snakes.GameMessage.SteerMsg.direction:type_name -> snakes.Direction
-	7,  // 21: snakes.GameMessage.StateMsg.state:type_name -> snakes.GameState
-	8,  // 22: snakes.GameMessage.AnnouncementMsg.games:type_name -> snakes.GameAnnouncement
-	1,  // 23: snakes.GameMessage.JoinMsg.player_type:type_name -> snakes.PlayerType
-	0,  // 24: snakes.GameMessage.JoinMsg.requested_role:type_name -> snakes.NodeRole
-	0,  // 25: snakes.GameMessage.RoleChangeMsg.sender_role:type_name -> snakes.NodeRole
-	0,  // 26: snakes.GameMessage.RoleChangeMsg.receiver_role:type_name -> snakes.NodeRole
-	27, // [27:27] is the sub-list for method output_type
-	27, // [27:27] is the sub-list for method input_type
-	27, // [27:27] is the sub-list for extension type_name
-	27, // [27:27] is the sub-list for extension extendee
-	0,  // [0:27] is the sub-list for field type_name
+	7,  // 2: snakes.GameConfig.game_map:type_name -> snakes.GameMap
+	8,  // 3: snakes.GameMap.portals:type_name -> snakes.Portal
+	13, // 4: snakes.Portal.a:type_name -> snakes.GameState.Coord
+	13, // 5: snakes.Portal.b:type_name -> snakes.GameState.Coord
+	5,  // 6: snakes.GamePlayers.players:type_name -> snakes.GamePlayer
+	14, // 7: snakes.GameState.snakes:type_name -> snakes.GameState.Snake
+	13, // 8: snakes.GameState.foods:type_name -> snakes.GameState.Coord
+	9,  // 9: snakes.GameState.players:type_name -> snakes.GamePlayers
+	9,  // 10: snakes.GameAnnouncement.players:type_name -> snakes.GamePlayers
+	6,  // 11: snakes.GameAnnouncement.config:type_name -> snakes.GameConfig
+	2,  // 12: snakes.GameAnnouncement.capabilities:type_name -> snakes.Capability
+	2,  // 13: snakes.GameAnnouncement.required_capabilities:type_name -> snakes.Capability
+	15, // 14: snakes.GameMessage.ping:type_name -> snakes.GameMessage.PingMsg
+	16, // 15: snakes.GameMessage.steer:type_name -> snakes.GameMessage.SteerMsg
+	17, // 16: snakes.GameMessage.ack:type_name -> snakes.GameMessage.AckMsg
+	18, // 17: snakes.GameMessage.state:type_name -> snakes.GameMessage.StateMsg
+	19, // 18: snakes.GameMessage.announcement:type_name -> snakes.GameMessage.AnnouncementMsg
+	21, // 19: snakes.GameMessage.join:type_name -> snakes.GameMessage.JoinMsg
+	22, // 20: snakes.GameMessage.error:type_name -> snakes.GameMessage.ErrorMsg
+	23, // 21: snakes.GameMessage.role_change:type_name -> snakes.GameMessage.RoleChangeMsg
+	20, // 22: snakes.GameMessage.discover:type_name -> snakes.GameMessage.DiscoverMsg
+	13, // 23: snakes.GameState.Snake.points:type_name -> snakes.GameState.Coord
+	4,  // 24: snakes.GameState.Snake.state:type_name -> snakes.GameState.Snake.SnakeState
+	3,  // 25: snakes.GameState.Snake.head_direction:type_name -> snakes.Direction
+	3,  // 26: snakes.GameMessage.SteerMsg.direction:type_name -> snakes.Direction
+	2,  // 27: snakes.GameMessage.AckMsg.capabilities:type_name -> snakes.Capability
+	10, // 28: snakes.GameMessage.StateMsg.state:type_name -> snakes.GameState
+	11, // 29: snakes.GameMessage.AnnouncementMsg.games:type_name -> snakes.GameAnnouncement
+	1,  // 30: snakes.GameMessage.JoinMsg.player_type:type_name -> snakes.PlayerType
+	0,  // 31: snakes.GameMessage.JoinMsg.requested_role:type_name -> snakes.NodeRole
+	2,  // 32: snakes.GameMessage.JoinMsg.capabilities:type_name -> snakes.Capability
+	0,  // 33: snakes.GameMessage.RoleChangeMsg.sender_role:type_name -> snakes.NodeRole
+	0,  // 34: snakes.GameMessage.RoleChangeMsg.receiver_role:type_name -> snakes.NodeRole
+	35, // [35:35] is the sub-list for method output_type
+	35, // [35:35] is the sub-list for method input_type
+	35, // [35:35] is the sub-list for extension type_name
+	35, // [35:35] is the sub-list for extension extendee
+	0,  // [0:35] is the sub-list for field type_name
 }
 
 func init() { file_snakes_proto_init() }
@@ -1636,7 +1997,7 @@ func file_snakes_proto_init() {
 	if File_snakes_proto != nil {
 		return
 	}
-	file_snakes_proto_msgTypes[5].OneofWrappers = []any{
+	file_snakes_proto_msgTypes[7].OneofWrappers = []any{
 		(*GameMessage_Ping)(nil),
 		(*GameMessage_Steer)(nil),
 		(*GameMessage_Ack)(nil),
@@ -1652,8 +2013,8 @@ func file_snakes_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_snakes_proto_rawDesc,
-			NumEnums:      4,
-			NumMessages:   17,
+			NumEnums:      5,
+			NumMessages:   19,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,208 @@
+package relay
+
+import (
+	"SnakeGame/connection"
+	"SnakeGame/logging"
+	pb "SnakeGame/model/proto"
+	"errors"
+	"google.golang.org/protobuf/proto"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// узел, не напоминавший о себе столько времени, забывается
+	clientTimeout = 30 * time.Second
+	// игра, не объявлявшаяся столько времени, считается законченной
+	announcementTimeout = 5 * time.Second
+)
+
+// зарегистрированный сокет узла
+type client struct {
+	addr      *net.UDPAddr
+	multicast bool
+	lastSeen  time.Time
+}
+
+// последнее объявление игры от мастера
+type announcement struct {
+	payload  []byte
+	lastSeen time.Time
+}
+
+// Server ретранслятор: пересылает GameMessage между узлами, которые не видят друг друга напрямую,
+// рассылает сообщения на multicast-группу всем подписанным сокетам и отвечает на DiscoverMsg известными играми
+type Server struct {
+	conn connection.Conn
+
+	mu            sync.Mutex
+	clients       map[string]*client
+	announcements map[string]*announcement
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewServer(conn connection.Conn) *Server {
+	return &Server{
+		conn:          conn,
+		clients:       make(map[string]*client),
+		announcements: make(map[string]*announcement),
+		done:          make(chan struct{}),
+	}
+}
+
+// Serve обработка сообщений до Close
+func (s *Server) Serve() {
+	go s.expire()
+
+	buf := make([]byte, 65536)
+	for {
+		n, addr, err := s.conn.ReadFromUDP(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			logging.Network.Error("Error receiving relay message", logging.Err(err))
+			continue
+		}
+
+		var msg pb.RelayMessage
+		if err := proto.Unmarshal(buf[:n], &msg); err != nil {
+			continue
+		}
+		s.handleMessage(&msg, addr)
+	}
+}
+
+// Close остановка ретранслятора
+func (s *Server) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+	s.conn.Close()
+}
+
+func (s *Server) handleMessage(msg *pb.RelayMessage, addr *net.UDPAddr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch t := msg.Type.(type) {
+	case *pb.RelayMessage_Register:
+		key := addr.String()
+		if _, ok := s.clients[key]; !ok {
+			logging.Network.Info("Relay: registered node", logging.Addr(addr), "multicast", t.Register.GetMulticast())
+		}
+		s.clients[key] = &client{addr: addr, multicast: t.Register.GetMulticast(), lastSeen: time.Now()}
+		s.send(addr, &pb.RelayMessage{
+			Type: &pb.RelayMessage_Registered{
+				Registered: &pb.RelayMessage_RegisteredMsg{
+					IpAddress: proto.String(addr.IP.String()),
+					Port:      proto.Int32(int32(addr.Port)),
+				},
+			},
+		})
+
+	case *pb.RelayMessage_Forward:
+		sender, ok := s.clients[addr.String()]
+		if !ok {
+			// пересылаем только зарегистрированным узлам, иначе ретранслятором можно бить по чужим адресам
+			return
+		}
+		sender.lastSeen = time.Now()
+
+		to := &net.UDPAddr{IP: net.ParseIP(t.Forward.GetIpAddress()), Port: int(t.Forward.GetPort())}
+		if to.IP == nil {
+			return
+		}
+		if to.IP.IsMulticast() {
+			s.handleGroupMessage(t.Forward.GetPayload(), addr)
+			return
+		}
+		if _, ok := s.clients[to.String()]; ok {
+			s.forward(to, addr, t.Forward.GetPayload())
+		}
+	}
+}
+
+// сообщение на multicast-группу: рассылаем всем подписанным сокетам, объявления игр запоминаем
+func (s *Server) handleGroupMessage(payload []byte, from *net.UDPAddr) {
+	var gameMsg pb.GameMessage
+	if err := proto.Unmarshal(payload, &gameMsg); err != nil {
+		return
+	}
+
+	switch gameMsg.Type.(type) {
+	case *pb.GameMessage_Announcement:
+		if _, known := s.announcements[from.String()]; !known {
+			logging.Network.Info("Relay: game announced", logging.Addr(from))
+		}
+		s.announcements[from.String()] = &announcement{payload: payload, lastSeen: time.Now()}
+	case *pb.GameMessage_Discover:
+		// отвечаем известными играми от имени их мастеров
+		for key, a := range s.announcements {
+			if master, ok := s.clients[key]; ok {
+				s.forward(from, master.addr, a.payload)
+			}
+		}
+	}
+
+	for _, c := range s.clients {
+		if c.multicast && c.addr.String() != from.String() {
+			s.forward(c.addr, from, payload)
+		}
+	}
+}
+
+// пересылка GameMessage узлу to от узла from
+func (s *Server) forward(to, from *net.UDPAddr, payload []byte) {
+	s.send(to, &pb.RelayMessage{
+		Type: &pb.RelayMessage_Forward{
+			Forward: &pb.RelayMessage_ForwardMsg{
+				IpAddress: proto.String(from.IP.String()),
+				Port:      proto.Int32(int32(from.Port)),
+				Payload:   payload,
+			},
+		},
+	})
+}
+
+func (s *Server) send(to *net.UDPAddr, msg *pb.RelayMessage) {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		logging.Network.Error("Error marshalling relay message", logging.Err(err))
+		return
+	}
+	if _, err := s.conn.WriteToUDP(data, to); err != nil && !errors.Is(err, net.ErrClosed) {
+		logging.Network.Error("Error sending relay message", logging.Addr(to), logging.Err(err))
+	}
+}
+
+// забываем молчащие узлы и закончившиеся игры
+func (s *Server) expire() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		now := time.Now()
+		for key, c := range s.clients {
+			if now.Sub(c.lastSeen) > clientTimeout {
+				delete(s.clients, key)
+				logging.Network.Info("Relay: node expired", logging.Addr(c.addr))
+			}
+		}
+		for key, a := range s.announcements {
+			if now.Sub(a.lastSeen) > announcementTimeout {
+				delete(s.announcements, key)
+				logging.Network.Info("Relay: game is over", "addr", key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,403 @@
+package ui
+
+import (
+	"SnakeGame/connection"
+	"SnakeGame/logging"
+	"SnakeGame/model/common"
+	"SnakeGame/model/maps"
+	"SnakeGame/model/master"
+	pb "SnakeGame/model/proto"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"google.golang.org/protobuf/proto"
+	"image/color"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// editorFieldSize сторона поля редактора в пикселях, клетки подстраиваются под размер карты
+const editorFieldSize = 600
+
+var spawnZoneColor = color.RGBA{R: 40, G: 90, B: 140, A: 255}
+
+// Клетки карты в редакторе, они же инструменты рисования
+const (
+	editorEmpty = iota
+	editorObstacle
+	editorSpawn
+	editorPortal
+)
+
+// Инструменты рисования: какой клеткой рисует мышь
+var editorTools = []struct {
+	name string
+	cell int
+}{
+	{"Стена", editorObstacle},
+	{"Место появления", editorSpawn},
+	{"Портал", editorPortal},
+	{"Ластик", editorEmpty},
+}
+
+// mapCanvas поле редактора карт: клик и перетаскивание мышью рисуют выбранной клеткой.
+// Порталы ставятся только кликом: два клика подряд - пара порталов
+type mapCanvas struct {
+	widget.BaseWidget
+
+	width, height int32
+	cellSize      float32
+	cells         []int
+	rects         []*canvas.Rectangle
+	content       *fyne.Container
+	// пары клеток-порталов, у последней пары второй клетки может ещё не быть (-1)
+	portals [][2]int32
+
+	// клетка, которой рисует мышь
+	brush int
+	// вызывается, когда закончили рисовать
+	onChange func()
+}
+
+func newMapCanvas() *mapCanvas {
+	c := &mapCanvas{content: container.NewWithoutLayout(), brush: editorObstacle}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+func (c *mapCanvas) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(c.content)
+}
+
+func (c *mapCanvas) MinSize() fyne.Size {
+	return fyne.NewSize(float32(c.width)*c.cellSize, float32(c.height)*c.cellSize)
+}
+
+// load поле width×height с клетками карты из config, без config - пустое
+func (c *mapCanvas) load(width, height int32, config *pb.GameConfig) {
+	c.width, c.height = width, height
+	c.cellSize = float32(editorFieldSize) / float32(max(width, height))
+	c.cells = make([]int, width*height)
+	c.rects = make([]*canvas.Rectangle, width*height)
+	c.portals = nil
+	c.content.Objects = nil
+
+	zones := config.GetGameMap().GetSpawnZones()
+	for y := int32(0); y < height; y++ {
+		for x := int32(0); x < width; x++ {
+			i := y*width + x
+			switch {
+			case maps.Obstacle(config, x, y):
+				c.cells[i] = editorObstacle
+			case maps.HasCell(zones, width, x, y):
+				c.cells[i] = editorSpawn
+			}
+			rect := canvas.NewRectangle(cellColor(c.cells[i]))
+			rect.StrokeColor = color.Black
+			rect.StrokeWidth = 1
+			rect.Resize(fyne.NewSize(c.cellSize, c.cellSize))
+			rect.Move(fyne.NewPos(float32(x)*c.cellSize, float32(y)*c.cellSize))
+			c.rects[i] = rect
+			c.content.Add(rect)
+		}
+	}
+	for _, portal := range config.GetGameMap().GetPortals() {
+		a := portal.GetA().GetY()*width + portal.GetA().GetX()
+		b := portal.GetB().GetY()*width + portal.GetB().GetX()
+		c.cells[a], c.cells[b] = editorPortal, editorPortal
+		c.portals = append(c.portals, [2]int32{a, b})
+	}
+	c.recolorPortals()
+	c.content.Resize(c.MinSize())
+	c.Refresh()
+	c.changed()
+}
+
+// config параметры игры с нарисованной картой, порталы без пары в неё не попадают
+func (c *mapCanvas) config(name string, solidWalls bool) *pb.GameConfig {
+	obstacles := maps.NewCells(c.width, c.height)
+	zones := maps.NewCells(c.width, c.height)
+	gameMap := &pb.GameMap{Name: proto.String(name)}
+	for i, cell := range c.cells {
+		x, y := int32(i)%c.width, int32(i)/c.width
+		switch cell {
+		case editorObstacle:
+			maps.SetCell(obstacles, c.width, x, y)
+			gameMap.Obstacles = obstacles
+		case editorSpawn:
+			maps.SetCell(zones, c.width, x, y)
+			gameMap.SpawnZones = zones
+		}
+	}
+	for _, pair := range c.portals {
+		if pair[1] >= 0 {
+			gameMap.Portals = append(gameMap.Portals, &pb.Portal{A: c.coord(pair[0]), B: c.coord(pair[1])})
+		}
+	}
+	return &pb.GameConfig{
+		Width:      proto.Int32(c.width),
+		Height:     proto.Int32(c.height),
+		SolidWalls: proto.Bool(solidWalls),
+		GameMap:    gameMap,
+	}
+}
+
+// unpaired есть ли портал без пары
+func (c *mapCanvas) unpaired() bool {
+	return len(c.portals) > 0 && c.portals[len(c.portals)-1][1] < 0
+}
+
+func (c *mapCanvas) coord(i int32) *pb.GameState_Coord {
+	return &pb.GameState_Coord{X: proto.Int32(i % c.width), Y: proto.Int32(i / c.width)}
+}
+
+// cellAt клетка под курсором
+func (c *mapCanvas) cellAt(pos fyne.Position) (int32, bool) {
+	x, y := int32(pos.X/c.cellSize), int32(pos.Y/c.cellSize)
+	if pos.X < 0 || pos.Y < 0 || x >= c.width || y >= c.height {
+		return 0, false
+	}
+	return y*c.width + x, true
+}
+
+func (c *mapCanvas) paint(pos fyne.Position) {
+	i, ok := c.cellAt(pos)
+	if !ok || c.cells[i] == c.brush || c.brush == editorPortal {
+		return
+	}
+	// портал без второй клетки не нужен: стираем всю пару
+	if c.cells[i] == editorPortal {
+		c.removePortal(i)
+	}
+	c.setCell(i, c.brush)
+}
+
+// placePortal клетка портала: первая клетка новой пары или вторая клетка незаконченной
+func (c *mapCanvas) placePortal(pos fyne.Position) {
+	i, ok := c.cellAt(pos)
+	if !ok || c.cells[i] == editorPortal {
+		return
+	}
+	switch {
+	case c.unpaired():
+		c.portals[len(c.portals)-1][1] = i
+	case len(c.portals) < common.MaxPortals:
+		c.portals = append(c.portals, [2]int32{i, -1})
+	default:
+		return
+	}
+	c.cells[i] = editorPortal
+	c.recolorPortals()
+}
+
+func (c *mapCanvas) removePortal(i int32) {
+	for n, pair := range c.portals {
+		if pair[0] != i && pair[1] != i {
+			continue
+		}
+		for _, end := range pair {
+			if end >= 0 {
+				c.setCell(end, editorEmpty)
+			}
+		}
+		c.portals = append(c.portals[:n], c.portals[n+1:]...)
+		c.recolorPortals()
+		return
+	}
+}
+
+func (c *mapCanvas) setCell(i int32, cell int) {
+	c.cells[i] = cell
+	c.rects[i].FillColor = cellColor(cell)
+	c.rects[i].Refresh()
+}
+
+// recolorPortals пары порталов различаются цветом, как и в игре
+func (c *mapCanvas) recolorPortals() {
+	for n, pair := range c.portals {
+		for _, end := range pair {
+			if end >= 0 {
+				c.rects[end].FillColor = portalColor(n)
+				c.rects[end].Refresh()
+			}
+		}
+	}
+}
+
+func (c *mapCanvas) changed() {
+	if c.onChange != nil {
+		c.onChange()
+	}
+}
+
+func (c *mapCanvas) Tapped(event *fyne.PointEvent) {
+	if c.brush == editorPortal {
+		c.placePortal(event.Position)
+	} else {
+		c.paint(event.Position)
+	}
+	c.changed()
+}
+
+func (c *mapCanvas) Dragged(event *fyne.DragEvent) {
+	c.paint(event.Position)
+}
+
+func (c *mapCanvas) DragEnd() {
+	c.changed()
+}
+
+func cellColor(cell int) color.Color {
+	switch cell {
+	case editorObstacle:
+		return obstacleColor
+	case editorSpawn:
+		return spawnZoneColor
+	}
+	return fieldColor
+}
+
+// ShowMapEditor редактор карт: размер поля, стены, препятствия, места появления змей и порталы.
+// Карты сохраняются в папку maps.Dir(), откуда их берёт экран настроек игры
+func ShowMapEditor(w fyne.Window, multConn connection.Conn) {
+	logging.UI.Debug("Opening map editor")
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("Название карты")
+	fileEntry := widget.NewEntry()
+	fileEntry.SetPlaceHolder("Имя файла без .json")
+	fileEntry.Validator = func(s string) error {
+		if s == "" || strings.ContainsAny(s, `/\`) {
+			return fmt.Errorf("нужно имя файла без папки")
+		}
+		return nil
+	}
+	widthEntry := newRangeEntry("30", common.MinFieldSize, common.MaxFieldSize)
+	heightEntry := newRangeEntry("20", common.MinFieldSize, common.MaxFieldSize)
+	wallsCheck := widget.NewCheck("стены по краям поля", nil)
+	spawnLabel := widget.NewLabel("")
+
+	field := newMapCanvas()
+	currentConfig := func() *pb.GameConfig {
+		return field.config(nameEntry.Text, wallsCheck.Checked)
+	}
+	// сколько змей поместится на карте, пересчитывается после каждого мазка
+	field.onChange = func() {
+		spawnLabel.SetText(fmt.Sprintf("Змей помещается: %d (нужно хотя бы %d)",
+			master.SpawnAreas(currentConfig()), master.MinSpawnAreas))
+	}
+	wallsCheck.OnChanged = func(bool) { field.changed() }
+
+	toolNames := make([]string, len(editorTools))
+	for i, tool := range editorTools {
+		toolNames[i] = tool.name
+	}
+	tools := widget.NewRadioGroup(toolNames, func(name string) {
+		for _, tool := range editorTools {
+			if tool.name == name {
+				field.brush = tool.cell
+			}
+		}
+	})
+	tools.Horizontal = true
+	tools.Required = true
+	tools.SetSelected(toolNames[0])
+
+	resizeButton := widget.NewButton("Новое поле", func() {
+		if widthEntry.Validate() != nil || heightEntry.Validate() != nil {
+			return
+		}
+		width, _ := strconv.Atoi(widthEntry.Text)
+		height, _ := strconv.Atoi(heightEntry.Text)
+		field.load(int32(width), int32(height), nil)
+	})
+
+	var paths []string
+	loadSelect := widget.NewSelect(nil, func(name string) {
+		for _, path := range paths {
+			if filepath.Base(path) != name {
+				continue
+			}
+			config, err := maps.Load(path)
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			nameEntry.SetText(config.GetGameMap().GetName())
+			fileEntry.SetText(strings.TrimSuffix(name, filepath.Ext(name)))
+			widthEntry.SetText(strconv.Itoa(int(config.GetWidth())))
+			heightEntry.SetText(strconv.Itoa(int(config.GetHeight())))
+			wallsCheck.SetChecked(config.GetSolidWalls())
+			field.load(config.GetWidth(), config.GetHeight(), config)
+		}
+	})
+	loadSelect.PlaceHolder = "Открыть карту"
+	listMaps := func() {
+		var err error
+		if paths, err = maps.List(maps.Dir()); err != nil {
+			logging.UI.Warn("Cannot list maps", logging.Err(err))
+		}
+		names := make([]string, len(paths))
+		for i, path := range paths {
+			names[i] = filepath.Base(path)
+		}
+		loadSelect.SetOptions(names)
+	}
+	listMaps()
+
+	saveButton := widget.NewButton("Сохранить", func() {
+		if err := fileEntry.Validate(); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		if field.unpaired() {
+			dialog.ShowError(fmt.Errorf("у последнего портала нет пары"), w)
+			return
+		}
+		config := currentConfig()
+		if err := common.ValidateConfig(config); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		if areas := master.SpawnAreas(config); areas < master.MinSpawnAreas {
+			dialog.ShowError(fmt.Errorf("на карте помещается змей: %d, нужно хотя бы %d. Освободите место или добавьте места появления",
+				areas, master.MinSpawnAreas), w)
+			return
+		}
+		path := filepath.Join(maps.Dir(), fileEntry.Text+".json")
+		if err := maps.Save(path, config); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		logging.UI.Info("Map saved", "path", path)
+		dialog.ShowInformation("Карта сохранена", path, w)
+		listMaps()
+	})
+
+	backButton := widget.NewButton("Назад", func() {
+		ShowMainMenu(w, multConn)
+	})
+
+	controls := container.NewVBox(
+		widget.NewLabelWithStyle("Редактор карт", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		loadSelect,
+		widget.NewForm(
+			widget.NewFormItem("Название", nameEntry),
+			widget.NewFormItem("Файл", fileEntry),
+			widget.NewFormItem("Ширина", widthEntry),
+			widget.NewFormItem("Высота", heightEntry),
+			widget.NewFormItem("Стены", wallsCheck),
+		),
+		resizeButton,
+		tools,
+		spawnLabel,
+		saveButton,
+		backButton,
+	)
+
+	field.load(30, 20, nil)
+	w.SetContent(container.NewHBox(controls, container.NewCenter(field)))
+}
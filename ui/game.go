@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"SnakeGame/model/maps"
 	pb "SnakeGame/model/proto"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -9,6 +10,20 @@ import (
 
 const CellSize = 20
 
+// Цвета клеток поля
+var (
+	fieldColor    = color.RGBA{R: 50, G: 50, B: 50, A: 255}
+	obstacleColor = color.RGBA{R: 110, G: 90, B: 70, A: 255}
+	// пары порталов различаются цветом
+	portalColors = []color.RGBA{
+		{R: 0, G: 200, B: 255, A: 255},
+		{R: 255, G: 0, B: 200, A: 255},
+		{R: 255, G: 230, B: 0, A: 255},
+		{R: 120, G: 255, B: 120, A: 255},
+		{R: 255, G: 255, B: 255, A: 255},
+	}
+)
+
 // renderGameState выводит игру на экран
 func renderGameState(content *fyne.Container, state *pb.GameState, config *pb.GameConfig) {
 	content.Objects = nil
@@ -16,7 +31,7 @@ func renderGameState(content *fyne.Container, state *pb.GameState, config *pb.Ga
 	// игровое поле
 	for i := int32(0); i < config.GetWidth(); i++ {
 		for j := int32(0); j < config.GetHeight(); j++ {
-			cell := canvas.NewRectangle(color.RGBA{R: 50, G: 50, B: 50, A: 255})
+			cell := canvas.NewRectangle(fieldColor)
 			cell.StrokeColor = color.RGBA{R: 0, G: 0, B: 0, A: 255}
 			cell.StrokeWidth = 1
 			cell.Resize(fyne.NewSize(CellSize, CellSize))
@@ -25,6 +40,40 @@ func renderGameState(content *fyne.Container, state *pb.GameState, config *pb.Ga
 		}
 	}
 
+	// препятствия карты
+	for x := int32(0); x < config.GetWidth(); x++ {
+		for y := int32(0); y < config.GetHeight(); y++ {
+			if !maps.Obstacle(config, x, y) {
+				continue
+			}
+			obstacle := canvas.NewRectangle(obstacleColor)
+			obstacle.Resize(fyne.NewSize(CellSize, CellSize))
+			obstacle.Move(fyne.NewPos(float32(x)*CellSize, float32(y)*CellSize))
+			content.Add(obstacle)
+		}
+	}
+
+	// порталы: кольца одного цвета у парных клеток
+	for i, portal := range config.GetGameMap().GetPortals() {
+		for _, end := range []*pb.GameState_Coord{portal.GetA(), portal.GetB()} {
+			ring := canvas.NewCircle(color.Transparent)
+			ring.StrokeColor = portalColor(i)
+			ring.StrokeWidth = 3
+			ring.Resize(fyne.NewSize(CellSize, CellSize))
+			ring.Move(fyne.NewPos(float32(end.GetX())*CellSize, float32(end.GetY())*CellSize))
+			content.Add(ring)
+		}
+	}
+
+	// стены по краям поля
+	if config.GetSolidWalls() {
+		border := canvas.NewRectangle(color.Transparent)
+		border.StrokeColor = color.RGBA{R: 200, G: 200, B: 200, A: 255}
+		border.StrokeWidth = 3
+		border.Resize(fyne.NewSize(float32(config.GetWidth())*CellSize, float32(config.GetHeight())*CellSize))
+		content.Add(border)
+	}
+
 	// еда
 	for _, food := range state.Foods {
 		apple := canvas.NewCircle(color.RGBA{255, 128, 0, 255})
@@ -37,30 +86,12 @@ func renderGameState(content *fyne.Container, state *pb.GameState, config *pb.Ga
 
 	// змеи
 	for _, snake := range state.Snakes {
+		role := getUserById(snake.GetPlayerId(), state)
+		if snake.GetState() == pb.GameState_Snake_ZOMBIE {
+			role = pb.NodeRole_VIEWER
+		}
 		for i, point := range snake.Points {
-			var rect *canvas.Rectangle
-			role := getUserById(snake.GetPlayerId(), state)
-			if i == 0 {
-				// голова
-				switch role {
-				case pb.NodeRole_MASTER:
-					rect = canvas.NewRectangle(color.RGBA{255, 0, 0, 255})
-				case pb.NodeRole_NORMAL:
-					rect = canvas.NewRectangle(color.RGBA{0, 255, 0, 255})
-				case pb.NodeRole_DEPUTY:
-					rect = canvas.NewRectangle(color.RGBA{150, 90, 255, 255})
-				}
-			} else {
-				// тело
-				switch role {
-				case pb.NodeRole_MASTER:
-					rect = canvas.NewRectangle(color.RGBA{128, 0, 0, 255})
-				case pb.NodeRole_NORMAL:
-					rect = canvas.NewRectangle(color.RGBA{0, 128, 0, 255})
-				case pb.NodeRole_DEPUTY:
-					rect = canvas.NewRectangle(color.RGBA{120, 60, 200, 255})
-				}
-			}
+			rect := canvas.NewRectangle(snakeColor(role, i == 0))
 			rect.Resize(fyne.NewSize(CellSize, CellSize))
 			x := float32(point.GetX()) * CellSize
 			y := float32(point.GetY()) * CellSize
@@ -72,11 +103,43 @@ func renderGameState(content *fyne.Container, state *pb.GameState, config *pb.Ga
 	content.Refresh()
 }
 
+// snakeColor цвет головы или тела змеи по роли её хозяина. У змей-зомби, змей зрителей
+// и игроков не из списка хозяина в игре нет, они серые
+func snakeColor(role pb.NodeRole, head bool) color.Color {
+	switch role {
+	case pb.NodeRole_MASTER:
+		if head {
+			return color.RGBA{255, 0, 0, 255}
+		}
+		return color.RGBA{128, 0, 0, 255}
+	case pb.NodeRole_NORMAL:
+		if head {
+			return color.RGBA{0, 255, 0, 255}
+		}
+		return color.RGBA{0, 128, 0, 255}
+	case pb.NodeRole_DEPUTY:
+		if head {
+			return color.RGBA{150, 90, 255, 255}
+		}
+		return color.RGBA{120, 60, 200, 255}
+	default:
+		if head {
+			return color.RGBA{160, 160, 160, 255}
+		}
+		return color.RGBA{100, 100, 100, 255}
+	}
+}
+
+func portalColor(pair int) color.Color {
+	return portalColors[pair%len(portalColors)]
+}
+
+// getUserById роль игрока; игрок не из списка змеей не управляет, как зритель
 func getUserById(id int32, state *pb.GameState) pb.NodeRole {
-	for _, player := range state.Players.Players {
+	for _, player := range state.GetPlayers().GetPlayers() {
 		if player.GetId() == id {
 			return player.GetRole()
 		}
 	}
-	return 0
+	return pb.NodeRole_VIEWER
 }
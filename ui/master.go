@@ -1,73 +1,279 @@
 package ui
 
 import (
+	"SnakeGame/connection"
+	"SnakeGame/logging"
 	"SnakeGame/model/common"
+	"SnakeGame/model/maps"
 	"SnakeGame/model/master"
 	pb "SnakeGame/model/proto"
 	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 	"google.golang.org/protobuf/proto"
 	"math/rand"
-	"net"
 	"strconv"
 	"time"
 )
 
-// ShowGameConfig настройки игры
-func ShowGameConfig(w fyne.Window, multConn *net.UDPConn) {
-	widthEntry := widget.NewEntry()
-	widthEntry.SetText("25")
-	heightEntry := widget.NewEntry()
-	heightEntry.SetText("25")
-	foodEntry := widget.NewEntry()
-	foodEntry.SetText("10")
-	delayEntry := widget.NewEntry()
-	delayEntry.SetText("180")
-
-	startButton := widget.NewButton("Начать игру", func() {
-		width, _ := strconv.Atoi(widthEntry.Text)
-		height, _ := strconv.Atoi(heightEntry.Text)
-		food, _ := strconv.Atoi(foodEntry.Text)
-		delay, _ := strconv.Atoi(delayEntry.Text)
-
-		config := &pb.GameConfig{
-			Width:        proto.Int32(int32(width)),
-			Height:       proto.Int32(int32(height)),
-			FoodStatic:   proto.Int32(int32(food)),
-			StateDelayMs: proto.Int32(int32(delay)),
-		}
+// preset готовые значения части настроек игры
+type preset struct {
+	name   string
+	values map[*widget.Entry]string
+}
 
-		ShowMasterGameScreen(w, config, multConn)
-	})
+// ShowGameConfig настройки игры: поля проверяются по пределам GameConfig,
+// ошибки показываются под полем, а с ошибками игру не начать
+func ShowGameConfig(w fyne.Window, multConn connection.Conn) {
+	widthEntry := newRangeEntry("40", common.MinFieldSize, common.MaxFieldSize)
+	heightEntry := newRangeEntry("30", common.MinFieldSize, common.MaxFieldSize)
+	foodEntry := newRangeEntry("10", common.MinFoodStatic, common.MaxFoodStatic)
+	delayEntry := newRangeEntry("180", common.MinStateDelayMs, common.MaxStateDelayMs)
+	graceEntry := newRangeEntry(strconv.Itoa(int(master.DefaultSessionGrace/time.Second)), 0, 3600)
+	wallsCheck := widget.NewCheck("змея погибает на краю поля", nil)
 
-	backButton := widget.NewButton("Назад", func() {
-		ShowMainMenu(w, multConn)
+	sizePresets := []preset{
+		{"Маленькое", map[*widget.Entry]string{widthEntry: "20", heightEntry: "15", foodEntry: "5"}},
+		{"Среднее", map[*widget.Entry]string{widthEntry: "40", heightEntry: "30", foodEntry: "10"}},
+		{"Большое", map[*widget.Entry]string{widthEntry: "80", heightEntry: "60", foodEntry: "40"}},
+	}
+	sizeSelect := newPresetSelect(sizePresets)
+	speedPresets := []preset{
+		{"Медленно", map[*widget.Entry]string{delayEntry: "500"}},
+		{"Обычно", map[*widget.Entry]string{delayEntry: "180"}},
+		{"Быстро", map[*widget.Entry]string{delayEntry: "100"}},
+	}
+
+	// случайная арена строится по размеру поля, плотности и сиду
+	densitySlider := widget.NewSlider(0.1, 0.9)
+	densitySlider.Step = 0.05
+	densitySlider.Value = 0.5
+	seedEntry := widget.NewEntry()
+	seedEntry.Validator = func(s string) error {
+		if _, err := strconv.ParseInt(s, 10, 64); err != nil {
+			return fmt.Errorf("нужно целое число")
+		}
+		return nil
+	}
+	newSeed := func() {
+		seedEntry.SetText(strconv.FormatInt(rand.Int63n(1000000), 10))
+	}
+	newSeed()
+	seedButton := widget.NewButton("Другой", newSeed)
+	playersEntry := newRangeEntry(strconv.Itoa(master.MinSpawnAreas), 1, 50)
+	arenaFields := []fyne.Disableable{densitySlider, seedEntry, seedButton, playersEntry}
+
+	// карта сама задаёт размер поля и стены
+	mapConfigs := loadMaps()
+	var selectedMap *pb.GameConfig
+	var selectedArena *arenaStyle
+	mapSelect := widget.NewSelect(mapNames(mapConfigs), func(name string) {
+		selectedMap, selectedArena = nil, nil
+		for _, config := range mapConfigs {
+			if config.GetGameMap().GetName() == name {
+				selectedMap = config
+			}
+		}
+		for i := range arenaStyles {
+			if arenaStyles[i].name == name {
+				selectedArena = &arenaStyles[i]
+			}
+		}
+		for _, field := range []fyne.Disableable{widthEntry, heightEntry, wallsCheck, sizeSelect} {
+			if selectedMap != nil {
+				field.Disable()
+			} else {
+				field.Enable()
+			}
+		}
+		for _, field := range arenaFields {
+			if selectedArena != nil {
+				field.Enable()
+			} else {
+				field.Disable()
+			}
+		}
+		if selectedMap != nil {
+			widthEntry.SetText(strconv.Itoa(int(selectedMap.GetWidth())))
+			heightEntry.SetText(strconv.Itoa(int(selectedMap.GetHeight())))
+			wallsCheck.SetChecked(selectedMap.GetSolidWalls())
+		}
 	})
+	mapSelect.SetSelected(noMap)
 
 	form := &widget.Form{
 		Items: []*widget.FormItem{
-			{Text: "Ширина поля", Widget: widthEntry},
-			{Text: "Высота поля", Widget: heightEntry},
-			{Text: "Количество еды", Widget: foodEntry},
-			{Text: "Задержка (мс)", Widget: delayEntry},
+			{Text: "Карта", Widget: mapSelect},
+			{Text: "Плотность арены", Widget: densitySlider},
+			{Text: "Сид арены", Widget: container.NewBorder(nil, nil, nil, seedButton, seedEntry)},
+			{Text: "Игроков на арене", Widget: playersEntry},
+			{Text: "Поле", Widget: sizeSelect},
+			{Text: "Скорость", Widget: newPresetSelect(speedPresets)},
+			{Text: "Ширина поля", Widget: widthEntry, HintText: rangeHint(common.MinFieldSize, common.MaxFieldSize)},
+			{Text: "Высота поля", Widget: heightEntry, HintText: rangeHint(common.MinFieldSize, common.MaxFieldSize)},
+			{Text: "Количество еды", Widget: foodEntry, HintText: rangeHint(common.MinFoodStatic, common.MaxFoodStatic)},
+			{Text: "Задержка (мс)", Widget: delayEntry, HintText: rangeHint(common.MinStateDelayMs, common.MaxStateDelayMs)},
+			{Text: "Стены", Widget: wallsCheck},
+			{Text: "Переподключение (с)", Widget: graceEntry},
+		},
+		SubmitText: "Начать игру",
+		// кнопка неактивна, пока в полях есть ошибки
+		OnSubmit: func() {
+			width, _ := strconv.Atoi(widthEntry.Text)
+			height, _ := strconv.Atoi(heightEntry.Text)
+			food, _ := strconv.Atoi(foodEntry.Text)
+			delay, _ := strconv.Atoi(delayEntry.Text)
+			grace, _ := strconv.Atoi(graceEntry.Text)
+
+			config := &pb.GameConfig{
+				Width:        proto.Int32(int32(width)),
+				Height:       proto.Int32(int32(height)),
+				FoodStatic:   proto.Int32(int32(food)),
+				StateDelayMs: proto.Int32(int32(delay)),
+				SolidWalls:   proto.Bool(wallsCheck.Checked),
+			}
+			if selectedMap != nil {
+				config.GameMap = selectedMap.GetGameMap()
+			}
+			if selectedArena != nil {
+				seed, _ := strconv.ParseInt(seedEntry.Text, 10, 64)
+				players, _ := strconv.Atoi(playersEntry.Text)
+				arena, err := master.GenerateArena(master.Arena{
+					Style:      selectedArena.style,
+					Width:      int32(width),
+					Height:     int32(height),
+					SolidWalls: wallsCheck.Checked,
+					Density:    densitySlider.Value,
+					Seed:       seed,
+					Players:    players,
+				}, fmt.Sprintf("%s, сид %d", selectedArena.name, seed))
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				config.GameMap = arena.GetGameMap()
+			}
+			if err := common.ValidateConfig(config); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+
+			ShowMasterGameScreen(w, config, time.Duration(grace)*time.Second, multConn)
+		},
+		CancelText: "Назад",
+		OnCancel: func() {
+			ShowMainMenu(w, multConn)
 		},
 	}
 
 	content := container.NewVBox(
 		widget.NewLabelWithStyle("Настройки игры", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
 		form,
-		startButton,
-		backButton,
 	)
 
 	w.SetContent(container.NewCenter(content))
 }
 
-// ShowMasterGameScreen показывает экран игры
-func ShowMasterGameScreen(w fyne.Window, config *pb.GameConfig, multConn *net.UDPConn) {
-	masterNode := master.NewMaster(multConn, config)
+// пункт списка карт для пустого поля
+const noMap = "Без карты"
+
+// arenaStyle пункт списка карт для случайной арены
+type arenaStyle struct {
+	name  string
+	style master.ArenaStyle
+}
+
+var arenaStyles = []arenaStyle{
+	{"Случайный лабиринт", master.ArenaMaze},
+	{"Случайные пещеры", master.ArenaCaves},
+	{"Случайные колонны", master.ArenaPillars},
+}
+
+// loadMaps карты из папки maps.Dir(), файлы с ошибками пропускаются
+func loadMaps() []*pb.GameConfig {
+	paths, err := maps.List(maps.Dir())
+	if err != nil {
+		logging.UI.Warn("Cannot list maps", logging.Err(err))
+		return nil
+	}
+	var configs []*pb.GameConfig
+	for _, path := range paths {
+		config, err := maps.Load(path)
+		if err != nil {
+			logging.UI.Warn("Skipping map", logging.Err(err))
+			continue
+		}
+		configs = append(configs, config)
+	}
+	return configs
+}
+
+func mapNames(configs []*pb.GameConfig) []string {
+	names := []string{noMap}
+	for _, config := range configs {
+		names = append(names, config.GetGameMap().GetName())
+	}
+	for _, arena := range arenaStyles {
+		names = append(names, arena.name)
+	}
+	return names
+}
+
+// newRangeEntry поле для целого числа от min до max
+func newRangeEntry(text string, min, max int) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetText(text)
+	entry.Validator = func(s string) error {
+		value, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("нужно целое число")
+		}
+		if value < min || value > max {
+			return fmt.Errorf("от %d до %d", min, max)
+		}
+		return nil
+	}
+	return entry
+}
+
+func rangeHint(min, max int) string {
+	return fmt.Sprintf("от %d до %d", min, max)
+}
+
+// newPresetSelect выбор готовых значений, которые подставляются в поля формы
+func newPresetSelect(presets []preset) *widget.Select {
+	names := make([]string, len(presets))
+	for i, p := range presets {
+		names[i] = p.name
+	}
+	return widget.NewSelect(names, func(name string) {
+		for _, p := range presets {
+			if p.name != name {
+				continue
+			}
+			for entry, value := range p.values {
+				entry.SetText(value)
+			}
+		}
+	})
+}
+
+// ShowMasterGameScreen показывает экран игры, sessionGrace - сколько ждать возвращения отвалившегося игрока
+func ShowMasterGameScreen(w fyne.Window, config *pb.GameConfig, sessionGrace time.Duration, multConn connection.Conn) {
+	unicastConn, err := connection.Unicast()
+	if err != nil {
+		dialog.ShowError(err, w)
+		return
+	}
+	masterNode, err := master.NewMaster(multConn, unicastConn, config)
+	if err != nil {
+		unicastConn.Close()
+		dialog.ShowError(err, w)
+		return
+	}
+	masterNode.SessionGrace = sessionGrace
 	go masterNode.Start()
 
 	gameContent := CreateGameContent(config)
@@ -77,6 +283,7 @@ func ShowMasterGameScreen(w fyne.Window, config *pb.GameConfig, multConn *net.UD
 	roleLabel := widget.NewLabel("Роль: ")
 	infoPanel, scoreTable, foodCountLabel := createInfoPanel(config, func() {
 		StopGameLoop()
+		masterNode.Stop()
 		ShowMainMenu(w, multConn)
 	}, scoreLabel, nameLabel, roleLabel)
 
@@ -87,16 +294,37 @@ func ShowMasterGameScreen(w fyne.Window, config *pb.GameConfig, multConn *net.UD
 	splitContent.SetOffset(0.7)
 
 	w.SetContent(splitContent)
+	if addr := master.AdminAddr(); addr != "" {
+		serveAdmin(w, masterNode, addr)
+	}
 
-	StartGameLoopForMaster(w, masterNode.Node, gameContent, scoreTable, foodCountLabel,
+	StartGameLoopForMaster(w, masterNode, gameContent, scoreTable, foodCountLabel,
 		func(score int32) { scoreLabel.SetText(fmt.Sprintf("Счет: %d", score)) },
 		func(name string) { nameLabel.SetText(fmt.Sprintf("Имя: %v", name)) },
 		func(role pb.NodeRole) { roleLabel.SetText(fmt.Sprintf("Роль: %v", role)) },
 	)
 }
 
-func StartGameLoopForMaster(w fyne.Window, node *common.Node, gameContent *fyne.Container,
+// serveAdmin запускает API управления и показывает хозяину игры адрес и токен,
+// больше токен нигде не появляется. Поле с токеном можно выделить и скопировать
+func serveAdmin(w fyne.Window, masterNode *master.Master, addr string) {
+	listenAddr, token, err := masterNode.ServeAdmin(addr)
+	if err != nil {
+		dialog.ShowError(err, w)
+		return
+	}
+	tokenEntry := widget.NewEntry()
+	tokenEntry.SetText(token)
+	dialog.ShowCustom("API управления", "Закрыть", container.NewVBox(
+		widget.NewLabel(fmt.Sprintf("Адрес: %v", listenAddr)),
+		widget.NewLabel("Токен (заголовок Authorization: Bearer <токен>):"),
+		tokenEntry,
+	), w)
+}
+
+func StartGameLoopForMaster(w fyne.Window, masterNode *master.Master, gameContent *fyne.Container,
 	scoreTable *widget.Table, foodCountLabel *widget.Label, updateScore func(int32), updateName func(string), updateRole func(pb.NodeRole)) {
+	node := masterNode.Node
 	rand.NewSource(time.Now().UnixNano())
 
 	gameTicker = time.NewTicker(time.Millisecond * 60)
@@ -105,7 +333,7 @@ func StartGameLoopForMaster(w fyne.Window, node *common.Node, gameContent *fyne.
 
 	// обработка клавиш
 	w.Canvas().SetOnTypedKey(func(e *fyne.KeyEvent) {
-		handleKeyInputForMaster(e, node)
+		handleKeyInputForMaster(e, masterNode)
 	})
 
 	if node.State == nil {
@@ -142,8 +370,8 @@ func StartGameLoopForMaster(w fyne.Window, node *common.Node, gameContent *fyne.
 	}()
 }
 
-// handleKeyInput обработка клавиш
-func handleKeyInputForMaster(e *fyne.KeyEvent, node *common.Node) {
+// handleKeyInput обработка клавиш, поворот применяется на ближайшем ходу
+func handleKeyInputForMaster(e *fyne.KeyEvent, masterNode *master.Master) {
 	var newDirection pb.Direction
 
 	switch e.Name {
@@ -159,31 +387,7 @@ func handleKeyInputForMaster(e *fyne.KeyEvent, node *common.Node) {
 		return
 	}
 
-	node.Mu.Lock()
-	defer node.Mu.Unlock()
-
-	for _, snake := range node.State.Snakes {
-		if snake.GetPlayerId() == node.PlayerInfo.GetId() {
-			currentDirection := snake.GetHeadDirection()
-			// проверка направления
-			isOppositeDirection := func(cur, new pb.Direction) bool {
-				switch cur {
-				case pb.Direction_UP:
-					return new == pb.Direction_DOWN
-				case pb.Direction_DOWN:
-					return new == pb.Direction_UP
-				case pb.Direction_LEFT:
-					return new == pb.Direction_RIGHT
-				case pb.Direction_RIGHT:
-					return new == pb.Direction_LEFT
-				}
-				return false
-			}(currentDirection, newDirection)
-
-			if !isOppositeDirection {
-				snake.HeadDirection = newDirection.Enum()
-			}
-			break
-		}
-	}
+	masterNode.Node.Mu.Lock()
+	defer masterNode.Node.Mu.Unlock()
+	masterNode.Steer(masterNode.Node.PlayerInfo.GetId(), newDirection)
 }
@@ -1,31 +1,48 @@
 package ui
 
 import (
+	"SnakeGame/connection"
+	"SnakeGame/logging"
 	"SnakeGame/model/player"
 	pb "SnakeGame/model/proto"
 	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 	"google.golang.org/protobuf/proto"
-	"log"
 	"math/rand"
-	"net"
 	"time"
 )
 
 // ShowJoinGame отображает экран присоединения к игре
-func ShowJoinGame(w fyne.Window, multConn *net.UDPConn) {
-	log.Printf("присоединение...")
-	playerNode := player.NewPlayer(multConn)
+func ShowJoinGame(w fyne.Window, multConn connection.Conn) {
+	logging.UI.Debug("Opening join screen")
+	unicastConn, err := connection.Unicast()
+	if err != nil {
+		dialog.ShowError(err, w)
+		return
+	}
+	playerNode, err := player.NewPlayer(multConn, unicastConn)
+	if err != nil {
+		unicastConn.Close()
+		dialog.ShowError(err, w)
+		return
+	}
 	go playerNode.ReceiveMulticastMessages()
 
 	discoveryLabel := widget.NewLabel("Поиск доступных игр...")
 	discoveryLabel.Alignment = fyne.TextAlignCenter
 
-	gameList := widget.NewSelect([]string{}, func(value string) {
-		log.Printf("Selected game: %s", value)
-	})
+	// правила выбранной игры видны до присоединения
+	rulesLabel := widget.NewLabel("")
+	gameList := widget.NewSelect([]string{}, nil)
+	gameList.OnChanged = func(value string) {
+		logging.UI.Debug("Selected game", "game", value)
+		if game := getSelectedGame(playerNode, gameList); game != nil {
+			rulesLabel.SetText(describeRules(game.Config))
+		}
+	}
 	gameList.PlaceHolder = "Выберите игру"
 	gameList.Resize(fyne.NewSize(300, 50))
 
@@ -41,18 +58,30 @@ func ShowJoinGame(w fyne.Window, multConn *net.UDPConn) {
 		}
 		// получаем выбранную игру из списка
 		selectedGame := getSelectedGame(playerNode, gameList)
+		if selectedGame != nil && selectedGame.ConfigError != nil {
+			dialog.ShowInformation("Нельзя присоединиться",
+				fmt.Sprintf("У игры недопустимые параметры: %v", selectedGame.ConfigError), w)
+			return
+		}
+		if selectedGame != nil && len(selectedGame.MissingCapabilities) > 0 {
+			dialog.ShowInformation("Нельзя присоединиться",
+				fmt.Sprintf("Игра требует возможностей, которых нет в этой версии: %v", selectedGame.MissingCapabilities), w)
+			return
+		}
 		if selectedGame != nil {
 			ShowPlayerGameScreen(w, playerNode, playerName, selectedGame, multConn)
 		}
 	})
 
 	backButton := widget.NewButton("Назад", func() {
+		playerNode.Stop()
 		ShowMainMenu(w, multConn)
 	})
 
 	content := container.NewVBox(
 		discoveryLabel,
 		gameList,
+		rulesLabel,
 		widget.NewForm(
 			&widget.FormItem{Text: "Имя игрока", Widget: playerNameEntry},
 		),
@@ -85,20 +114,18 @@ func getSelectedGame(playerNode *player.Player, gameList *widget.Select) *player
 			return &game
 		}
 	}
-	log.Printf("Could't find selected game")
+	logging.UI.Warn("Could not find selected game", "game", gameList.Selected)
 	return nil
 }
 
 // ShowPlayerGameScreen инициализирует игрока и запускает UI игры
 func ShowPlayerGameScreen(w fyne.Window, playerNode *player.Player, playerName string,
-	selectedGame *player.DiscoveredGame, multConn *net.UDPConn) {
-
-	playerNode.Node.PlayerInfo.Name = proto.String(playerName)
-	playerNode.Node.Config = selectedGame.Config
-	playerNode.MasterAddr = selectedGame.MasterAddr
-	playerNode.AnnouncementMsg = selectedGame.AnnouncementMsg
-	playerNode.Start()
+	selectedGame *player.DiscoveredGame, multConn connection.Conn) {
 
+	if err := playerNode.JoinGame(playerName, selectedGame); err != nil {
+		dialog.ShowError(err, w)
+		return
+	}
 	gameContent := CreateGameContent(playerNode.Node.Config)
 
 	scoreLabel := widget.NewLabel("Счет: 0")
@@ -106,6 +133,7 @@ func ShowPlayerGameScreen(w fyne.Window, playerNode *player.Player, playerName s
 	roleLabel := widget.NewLabel("Роль: ")
 	infoPanel, scoreTable, foodCountLabel := createInfoPanel(playerNode.Node.Config, func() {
 		StopGameLoop()
+		playerNode.Stop()
 		ShowMainMenu(w, multConn)
 	}, scoreLabel, nameLabel, roleLabel)
 
@@ -146,11 +174,17 @@ func StartGameLoopForPlayer(w fyne.Window, playerNode *player.Player, gameConten
 		playerNode.Node.Mu.Unlock()
 	}
 
+	shownError := ""
 	go func() {
 		for isRunning {
 			select {
 			case <-gameTicker.C:
 				playerNode.Node.Mu.Lock()
+				// сообщение об ошибке от мастера показываем, не останавливая игру
+				if playerNode.LastError != shownError {
+					shownError = playerNode.LastError
+					dialog.ShowInformation("Сообщение от мастера", shownError, w)
+				}
 				stateCopy := proto.Clone(playerNode.Node.State).(*pb.GameState)
 				configCopy := proto.Clone(playerNode.Node.Config).(*pb.GameConfig)
 				// Обновление счёта
@@ -188,17 +222,5 @@ func handleKeyInputForPlayer(e *fyne.KeyEvent, playerNode *player.Player) {
 		return
 	}
 
-	playerNode.Node.Mu.Lock()
-	defer playerNode.Node.Mu.Unlock()
-
-	steerMsg := &pb.GameMessage{
-		MsgSeq: proto.Int64(playerNode.Node.MsgSeq),
-		Type: &pb.GameMessage_Steer{
-			Steer: &pb.GameMessage_SteerMsg{
-				Direction: newDirection.Enum(),
-			},
-		},
-	}
-
-	playerNode.Node.SendMessage(steerMsg, playerNode.MasterAddr)
+	playerNode.Steer(newDirection)
 }
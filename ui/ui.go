@@ -2,6 +2,7 @@ package ui
 
 import (
 	"SnakeGame/connection"
+	"SnakeGame/logging"
 	pb "SnakeGame/model/proto"
 	"fmt"
 	"fyne.io/fyne/v2"
@@ -9,7 +10,7 @@ import (
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
-	"net"
+	"os"
 	"time"
 )
 
@@ -17,7 +18,7 @@ var gameTicker *time.Ticker
 var isRunning bool
 
 // ShowMainMenu выводит главное меню
-func ShowMainMenu(w fyne.Window, multConn *net.UDPConn) {
+func ShowMainMenu(w fyne.Window, multConn connection.Conn) {
 	title := widget.NewLabel("Добро пожаловать в Snake Game!")
 	title.Alignment = fyne.TextAlignCenter
 
@@ -29,6 +30,10 @@ func ShowMainMenu(w fyne.Window, multConn *net.UDPConn) {
 		ShowJoinGame(w, multConn)
 	})
 
+	editorButton := widget.NewButton("Редактор карт", func() {
+		ShowMapEditor(w, multConn)
+	})
+
 	exitButton := widget.NewButton("Выход", func() {
 		w.Close()
 	})
@@ -37,6 +42,7 @@ func ShowMainMenu(w fyne.Window, multConn *net.UDPConn) {
 		title,
 		newGameButton,
 		joinGameButton,
+		editorButton,
 		exitButton,
 	)
 
@@ -86,7 +92,7 @@ func createInfoPanel(config *pb.GameConfig, onExit func(), scoreLabel *widget.La
 	scrollableTable := container.NewScroll(scoreTable)
 	scrollableTable.SetMinSize(fyne.NewSize(150, 300))
 
-	gameInfo := widget.NewLabel(fmt.Sprintf("Текущая игра:\n\nРазмер: %dx%d\n", config.GetWidth(), config.GetHeight()))
+	gameInfo := widget.NewLabel("Текущая игра:\n\n" + describeRules(config) + "\n")
 	foodCountLabel := widget.NewLabel("Еда: 0")
 
 	newGameButton := widget.NewButton("Новая игра", onExit)
@@ -106,6 +112,22 @@ func createInfoPanel(config *pb.GameConfig, onExit func(), scoreLabel *widget.La
 	return content, scoreTable, foodCountLabel
 }
 
+// describeRules размер поля и то, что происходит на его краях
+func describeRules(config *pb.GameConfig) string {
+	walls := "нет, поле склеено по краям"
+	if config.GetSolidWalls() {
+		walls = "есть, змея погибает на краю поля"
+	}
+	rules := fmt.Sprintf("Размер: %dx%d\nСтены: %s", config.GetWidth(), config.GetHeight(), walls)
+	if config.GetGameMap() != nil {
+		rules += "\nКарта: " + config.GetGameMap().GetName()
+	}
+	if portals := len(config.GetGameMap().GetPortals()); portals > 0 {
+		rules += fmt.Sprintf("\nПорталов: %d", portals)
+	}
+	return rules
+}
+
 // updateInfoPanel обновление инф панели
 func updateInfoPanel(scoreTable *widget.Table, foodCountLabel *widget.Label, state *pb.GameState) {
 	data := [][]string{
@@ -142,7 +164,11 @@ func RunApp() {
 	myWindow.Resize(fyne.NewSize(800, 600))
 	myWindow.CenterOnScreen()
 
-	multConn := connection.Connection()
+	multConn, err := connection.Connection()
+	if err != nil {
+		logging.UI.Error("Error creating multicast connection", logging.Err(err))
+		os.Exit(1)
+	}
 
 	ShowMainMenu(myWindow, multConn)
 